@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Helpers sticking a model to the build plate, by name
+var adhesionHelpers = map[string]func(m *model.Model, opts model.AdhesionOptions) (*model.Model, error){
+	"brim":  model.Brim,
+	"raft":  model.Raft,
+	"skirt": model.Skirt,
+}
+
+// Build a brim, a raft or a skirt around the footprint of a model
+func adhesionCommand(args []string) {
+	defaults := model.DefaultAdhesionOptions
+	flags := flag.NewFlagSet("adhesion", flag.ExitOnError)
+	kind := flags.String("type", "brim", "What to build: brim, raft or skirt")
+	output := flags.String("o", "", "Write the helper to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	width := flags.Float64("width", float64(defaults.Width), "How far the brim or the raft reach out of the part, or the width of the skirt")
+	thickness := flags.Float64("thickness", float64(defaults.Thickness), "Thickness of the helper")
+	distance := flags.Float64("distance", float64(defaults.Distance), "Distance between the skirt and the part")
+	resolution := flags.Int("resolution", defaults.Resolution, "Cells across the grid the footprint of the part is outlined on")
+	merge := flags.Bool("merge", false, "Write the part with the helper instead of the helper alone, moving the part onto the raft")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii adhesion [flags] pathtofile -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	build, known := adhesionHelpers[*kind]
+	if len(files) != 1 || *output == "" || !known || *width <= 0 || *thickness <= 0 || *distance < 0 || *resolution < 1 {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	helper, err := build(&aModel, model.AdhesionOptions{
+		Width: float32(*width), Thickness: float32(*thickness), Distance: float32(*distance), Resolution: *resolution,
+	})
+	check(err)
+	fmt.Printf("Triangles: %v\n", len(helper.Triangles))
+	if *merge {
+		//The raft goes under the part, so both go up by its thickness to rest on the plate
+		if *kind == "raft" {
+			lift := model.Translate(model.Vec3{0, 0, float32(*thickness)})
+			model.Transform(&aModel, lift)
+			model.Transform(helper, lift)
+		}
+		aModel.Append(helper.Triangles...)
+		helper = &aModel
+	}
+	check(saveModel(*output, "", helper, *ascii))
+}
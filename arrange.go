@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Lay several models out on a build plate
+func arrangeCommand(args []string) {
+	opts := model.ArrangeOptions{Plate: model.Vec2{220, 220}}
+	flags := flag.NewFlagSet("arrange", flag.ExitOnError)
+	output := flags.String("o", "", "Write all the models where they are placed to this file as a single model")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	flags.Func("plate", "Size x,y of the build plate (default 220,220)", func(value string) error {
+		size, err := model.ParseVec3(value + ",0")
+		opts.Plate = model.Vec2{size[0], size[1]}
+		return err
+	})
+	spacing := flags.Float64("spacing", 5, "Gap left between the models")
+	rotate := flags.Bool("rotate", false, "Turn models a quarter around Z when it helps them fit")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii arrange [flags] pathtofile ... -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) == 0 || *output == "" || *spacing < 0 || opts.Plate[0] <= 0 || opts.Plate[1] <= 0 {
+		flags.Usage()
+	}
+	opts.Spacing, opts.Rotate = float32(*spacing), *rotate
+
+	var scene model.Scene
+	for i, file := range files {
+		aModel, err := loadModel(file, "", false)
+		check(err)
+		scene.Add(filepath.Base(file), &aModel, model.IdentityAffine(), model.DistinctColors[i%len(model.DistinctColors)])
+	}
+	check(scene.Arrange(opts))
+	for _, instance := range scene.Instances {
+		bounds := instance.Bounds()
+		fmt.Printf("%v: %v to %v\n", instance.Name, bounds.Min, bounds.Max)
+	}
+	check(saveModel(*output, "", scene.Merge(), *ascii))
+}
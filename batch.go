@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/batch"
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Repair, convert and render every model of a directory tree or a list of files
+func batchCommand(args []string) {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	repair := flags.Bool("repair", false, "Repair every model first, running every pass")
+	to := flags.String("to", "", "Convert every model to this format (stl|obj|json|csv|tsv)")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	thumbs := flags.Int("thumbs", 0, "Render a thumbnail of every model this many pixels wide (0 for none)")
+	view := flags.String("view", "iso", "Direction to draw the thumbnails from (front|side|top|iso|dimetric)")
+	outputDir := flags.String("out", "", "Directory for the converted models and thumbnails, mirroring the tree of the inputs")
+	workers := flags.Int("workers", 0, "Models processed at the same time (0 for one per CPU)")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii batch [flags] directory|pathtofile ... -out directory")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	paths := parseArgs(flags, args)
+	if len(paths) == 0 || *outputDir == "" || (*to == "" && *thumbs == 0) || *thumbs < 0 || (*to != "" && !writableFormat(*to)) {
+		flags.Usage()
+	}
+	perspective, err := model.ParseProjection(*view)
+	if err != nil || perspective == nil {
+		flags.Usage()
+	}
+
+	//Outputs mirror the tree of every directory, files given one by one go right in the output directory
+	var inputs, roots []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		check(err)
+		if !info.IsDir() {
+			inputs = append(inputs, path)
+			continue
+		}
+		found, err := batch.Find(path)
+		check(err)
+		inputs = append(inputs, found...)
+		roots = append(roots, path)
+	}
+	var steps []batch.Step
+	if *repair {
+		steps = append(steps, batch.Repair(model.DefaultRepairOptions))
+	}
+	if *to != "" {
+		format := *to
+		steps = append(steps, batch.Write(batch.MirrorPath(roots, *outputDir, "."+format), func(w io.Writer, m *model.Model) error {
+			return writeModel(w, format, m, *ascii)
+		}))
+	}
+	if *thumbs > 0 {
+		steps = append(steps, batch.Thumbnail(batch.MirrorPath(roots, *outputDir, ".png"), *thumbs, perspective))
+	}
+	report := batch.Run(context.Background(), inputs, batch.Options{Workers: *workers, Progress: func(result batch.Result) {
+		if !*asJSON && result.Err == nil {
+			fmt.Printf("%s -> %v\n", result.Input, result.Outputs)
+		}
+	}}, steps...)
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		check(encoder.Encode(report))
+	} else {
+		fmt.Print(report)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,281 @@
+// Package batch runs a pipeline of steps over many model files at the same time, collecting the
+// error of every file instead of stopping at the first one.
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Job is a file going through the pipeline
+type Job struct {
+	Input string
+	//Model read from the input, which the steps may change
+	Model model.Model
+	//Files written by the steps
+	Outputs []string
+	//Files written by all the jobs of the run
+	written *outputSet
+}
+
+// outputSet records the files written during a run, so no two jobs write the same one
+type outputSet struct {
+	lock  sync.Mutex
+	paths map[string]string
+}
+
+// claim records that the input is about to write the file at path, failing if it was already
+// written in the run
+func (s *outputSet) claim(path, input string) error {
+	if s == nil {
+		return nil
+	}
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if other, found := s.paths[path]; found {
+		return fmt.Errorf("%s was already written for %s", path, other)
+	}
+	s.paths[path] = input
+	return nil
+}
+
+// Step is a named stage of a pipeline, changing the model of the job or writing it somewhere
+type Step struct {
+	Name string
+	Run  func(job *Job) error
+}
+
+// Encoder writes a model in a format, like model.WriteBinarySTL or model.WriteOBJ
+type Encoder func(w io.Writer, m *model.Model) error
+
+// Options tunes Run
+type Options struct {
+	//Files processed at the same time, one per CPU if 0
+	Workers int
+	//Called with every result as soon as its file is done, one call at a time
+	Progress func(Result)
+}
+
+// Result is what happened to one file
+type Result struct {
+	Input   string   `json:"input"`
+	Outputs []string `json:"outputs,omitempty"`
+	//Triangles of the model after the last step that ran
+	Triangles int `json:"triangles"`
+	//Name of the step that failed, read if the file couldn't be read
+	Step     string        `json:"step,omitempty"`
+	Err      error         `json:"-"`
+	Duration time.Duration `json:"duration"`
+}
+
+// MarshalJSON encodes the result with the message of its error
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	message := ""
+	if r.Err != nil {
+		message = r.Err.Error()
+	}
+	return json.Marshal(struct {
+		plain
+		Error string `json:"error,omitempty"`
+	}{plain(r), message})
+}
+
+// Report gathers the results of a run, in the order of the inputs
+type Report struct {
+	Results   []Result      `json:"results"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Triangles int           `json:"triangles"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// String prints the report in the same style as the Model, listing the failures
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Files: %v\nSucceeded: %v\nFailed: %v\nTriangles: %v\nDuration: %v\n",
+		len(r.Results), r.Succeeded, r.Failed, r.Triangles, r.Duration.Round(time.Millisecond))
+	for _, result := range r.Results {
+		if result.Err != nil {
+			fmt.Fprintf(&b, "%s: %s: %v\n", result.Input, result.Step, result.Err)
+		}
+	}
+	return b.String()
+}
+
+// Run reads every input and passes it through the steps in order, several files at a time. A file
+// stops at the first step failing, the others go on. A step writing a file another one already wrote
+// in the run fails instead of overwriting it. Once the context is done the files not started yet fail
+// with its error.
+func Run(ctx context.Context, inputs []string, opts Options, steps ...Step) (report Report) {
+	start := time.Now()
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	report.Results = make([]Result, len(inputs))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	var progress sync.Mutex
+	written := &outputSet{paths: make(map[string]string)}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				report.Results[i] = process(ctx, inputs[i], written, steps)
+				if opts.Progress != nil {
+					//Keep the callback from being called concurrently
+					progress.Lock()
+					opts.Progress(report.Results[i])
+					progress.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range inputs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	for _, result := range report.Results {
+		if result.Err != nil {
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+		report.Triangles += result.Triangles
+	}
+	report.Duration = time.Since(start)
+	return report
+}
+
+// process reads a file and runs the steps on it
+func process(ctx context.Context, input string, written *outputSet, steps []Step) (result Result) {
+	start := time.Now()
+	result.Input = input
+	defer func() { result.Duration = time.Since(start) }()
+	if result.Err = ctx.Err(); result.Err != nil {
+		result.Step = "read"
+		return result
+	}
+	job := Job{Input: input, written: written}
+	job.Model, result.Err = model.ReadFile(input)
+	if result.Err != nil {
+		result.Step = "read"
+		return result
+	}
+	for _, step := range steps {
+		if result.Err = step.Run(&job); result.Err != nil {
+			result.Step = step.Name
+			break
+		}
+	}
+	result.Triangles, result.Outputs = len(job.Model.Triangles), job.Outputs
+	return result
+}
+
+// Find returns the STL and OBJ files in the tree under root, in lexical order
+func Find(root string) (inputs []string, err error) {
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && model.IsModelFile(path) {
+			inputs = append(inputs, path)
+		}
+		return nil
+	})
+	return inputs, err
+}
+
+// MirrorPath returns a function placing the output of each input under outputDir, at the same place
+// as the input is under the first of the roots holding it, or right in outputDir when none does. With
+// several roots each one is mirrored in a directory named after it. The name of the input is kept with
+// its extension, the given one being added to it (part.stl becomes part.stl.png), so inputs in
+// different formats don't get the same output.
+func MirrorPath(roots []string, outputDir, extension string) func(input string) string {
+	return func(input string) string {
+		relative := filepath.Base(input)
+		for _, root := range roots {
+			rel, err := filepath.Rel(root, input)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			relative = rel
+			if len(roots) > 1 {
+				if absolute, err := filepath.Abs(root); err == nil {
+					root = absolute
+				}
+				relative = filepath.Join(filepath.Base(root), rel)
+			}
+			break
+		}
+		return filepath.Join(outputDir, relative+extension)
+	}
+}
+
+// Repair is a step fixing the common defects of the model with model.Repair
+func Repair(opts model.RepairOptions) Step {
+	return Step{Name: "repair", Run: func(job *Job) error {
+		model.Repair(&job.Model, opts)
+		return nil
+	}}
+}
+
+// Write is a step saving the model to the path given for the input, creating its directory
+func Write(outputPath func(input string) string, encode Encoder) Step {
+	return Step{Name: "write", Run: func(job *Job) error {
+		return writeFile(job, outputPath(job.Input), func(w io.Writer) error { return encode(w, &job.Model) })
+	}}
+}
+
+// Thumbnail is a step rendering the model as a shaded PNG of size x size pixels with model.Thumbnail,
+// to the path given for the input
+func Thumbnail(outputPath func(input string) string, size int, perspective model.Perspective) Step {
+	return Step{Name: "thumbnail", Run: func(job *Job) error {
+		return writeFile(job, outputPath(job.Input), func(w io.Writer) error {
+			return model.EncodeImage(w, model.Thumbnail(&job.Model, size, perspective), model.ImagePNG)
+		})
+	}}
+}
+
+// writeFile creates a file of the job with its directory, recording it in the outputs once written
+func writeFile(job *Job, path string, write func(w io.Writer) error) error {
+	if err := job.written.claim(path, job.Input); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(file)
+	err = write(buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		job.Outputs = append(job.Outputs, path)
+	}
+	return err
+}
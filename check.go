@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Check if a model can be printed, exiting with an error status when it can't
+func checkCommand(args []string) {
+	profile := model.DefaultPrinterProfile
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	flags.Func("build-volume", fmt.Sprintf("Size x,y,z of the space the printer builds in (default %v,%v,%v)",
+		profile.BuildVolume[0], profile.BuildVolume[1], profile.BuildVolume[2]), func(value string) (err error) {
+		profile.BuildVolume, err = model.ParseVec3(value)
+		return err
+	})
+	nozzle := flags.Float64("nozzle", float64(profile.NozzleDiameter), "Nozzle diameter")
+	minWall := flags.Float64("min-wall", float64(profile.MinWallThickness), "Thinnest wall printed reliably (0 for twice the nozzle diameter)")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii check [flags] pathtofile")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *nozzle <= 0 || *minWall < 0 {
+		flags.Usage()
+	}
+	profile.NozzleDiameter, profile.MinWallThickness = float32(*nozzle), float32(*minWall)
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	report := model.CheckPrintability(&aModel, profile)
+	if *asJSON {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		check(err)
+		fmt.Println(string(encoded))
+	} else {
+		fmt.Print(report)
+	}
+	if !report.Printable {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "flag"
+
+// Subcommands by name, receiving the arguments that follow it
+var commands = map[string]func(args []string){
+	"info":      infoCommand,
+	"convert":   convertCommand,
+	"render":    renderCommand,
+	"repair":    repairCommand,
+	"transform": transformCommand,
+	"slice":     sliceCommand,
+	"diff":      diffCommand,
+	"serve":     serveCommand,
+	"thumbs":    thumbsCommand,
+	"orient":    orientCommand,
+	"check":     checkCommand,
+	"hollow":    hollowCommand,
+	"offset":    offsetCommand,
+	"cut":       cutCommand,
+	"decompose": decomposeCommand,
+	"infill":    infillCommand,
+	"wireframe": wireframeCommand,
+	"scene":     sceneCommand,
+	"arrange":   arrangeCommand,
+	"watch":     watchCommand,
+	"batch":     batchCommand,
+	"validate":  validateCommand,
+	"supports":  supportsCommand,
+	"adhesion":  adhesionCommand,
+	"voxel":     voxelCommand,
+	"extrude":   extrudeCommand,
+	"gcode":     gcodeCommand,
+	"measure":   measureCommand,
+}
+
+// Parse the flags of a subcommand wherever they are among the other arguments, which are returned
+func parseArgs(flags *flag.FlagSet, args []string) (positional []string) {
+	for {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Convert models between formats, one file or every file matching a pattern into a directory
+func convertCommand(args []string) {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	binary := flags.Bool("binary", false, "Write STL files as binary (the default)")
+	from := flags.String("from", "", "Format of the input when the extension doesn't tell (stl|obj|json|csv|tsv)")
+	to := flags.String("to", "", "Format of the output when the extension doesn't tell, needed to convert into a directory (stl|obj|json|csv|tsv)")
+	preLoad := flags.Bool("pl", false, "Preload the files into memory (binary STL only)")
+	precision := flags.Int("precision", 0, "Digits of the numbers of ASCII STL and OBJ files, significant ones or decimals with -notation f (0 for as many as needed to read them back exactly)")
+	notation := flags.String("notation", "g", "Notation of the numbers of ASCII STL and OBJ files: e (always an exponent), f (never) or g (only for large and tiny numbers)")
+	trimZeros := flags.Bool("trim-zeros", false, "Drop the zeros ending the decimals of the numbers of ASCII STL and OBJ files")
+	canonical := flags.Bool("canonical", false, "Write the triangles in a canonical order, so the same model always gives the same file")
+	colors := flags.Bool("colors", false, "Keep the facet colors: read them from the attribute bytes of STL files and write them into those of binary STL files (JSON keeps them as attributes)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii convert [flags] input output")
+		fmt.Println("       stl2ascii convert [flags] 'pattern' directory")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 2 || (*ascii && *binary) || len(*notation) != 1 || !strings.Contains("efg", *notation) || *precision < 0 {
+		flags.Usage()
+	}
+	numbers := model.ASCIIOptions{Precision: *precision, Notation: (*notation)[0], TrimZeros: *trimZeros}
+
+	input, output := files[0], files[1]
+	inputs, err := filepath.Glob(input)
+	check(err)
+	if len(inputs) == 0 {
+		fmt.Fprintf(os.Stderr, "no files match %s\n", input)
+		os.Exit(1)
+	}
+	//Patterns and existing directories get one output file per input
+	outputInfo, err := os.Stat(output)
+	intoDirectory := (err == nil && outputInfo.IsDir()) || strings.ContainsAny(input, "*?[")
+	if intoDirectory {
+		if *to == "" {
+			fmt.Fprintln(os.Stderr, "-to is needed to convert into a directory")
+			os.Exit(1)
+		}
+		check(os.MkdirAll(output, 0755))
+	}
+	for _, inputPath := range inputs {
+		outputPath := output
+		if intoDirectory {
+			name := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+			outputPath = filepath.Join(output, name+"."+*to)
+		}
+		aModel, err := loadModel(inputPath, *from, *preLoad)
+		check(err)
+		if *canonical {
+			model.Canonicalize(&aModel)
+		}
+		if *colors {
+			//STL files keep the colors in the attribute bytes, the other formats as attributes
+			if formatOf(inputPath, *from, "stl") == "stl" {
+				model.LoadFacetColors(&aModel)
+			}
+			if formatOf(outputPath, *to, "") == "stl" {
+				model.StoreFacetColors(&aModel)
+			}
+		}
+		check(saveModelWith(outputPath, *to, &aModel, *ascii, numbers))
+		fmt.Printf("%s -> %s (%d triangles)\n", inputPath, outputPath, len(aModel.Triangles))
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Split a model in two closed parts with a plane
+func cutCommand(args []string) {
+	plane := model.Plane{Normal: model.Vec3{0, 0, 1}}
+	flags := flag.NewFlagSet("cut", flag.ExitOnError)
+	topOutput := flags.String("top", "", "Write the part above the plane to this file")
+	bottomOutput := flags.String("bottom", "", "Write the part below the plane to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	flags.Func("z", "Cut with the horizontal plane at this height", func(value string) (err error) {
+		plane.Point, err = model.ParseVec3("0,0," + value)
+		plane.Normal = model.Vec3{0, 0, 1}
+		return err
+	})
+	flags.Func("point", "Cut with the plane through this point x,y,z (default 0,0,0)", func(value string) (err error) {
+		plane.Point, err = model.ParseVec3(value)
+		return err
+	})
+	flags.Func("normal", "Direction x,y,z the top side of the plane faces (default 0,0,1)", func(value string) (err error) {
+		plane.Normal, err = model.ParseVec3(value)
+		return err
+	})
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii cut [flags] pathtofile -top output -bottom output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || (*topOutput == "" && *bottomOutput == "") || plane.Normal == (model.Vec3{}) {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	top, bottom := model.Cut(&aModel, plane)
+	fmt.Printf("Top triangles: %v\nBottom triangles: %v\n", len(top.Triangles), len(bottom.Triangles))
+	if *topOutput != "" {
+		check(saveModel(*topOutput, "", top, *ascii))
+	}
+	if *bottomOutput != "" {
+		check(saveModel(*bottomOutput, "", bottom, *ascii))
+	}
+}
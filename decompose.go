@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Approximate a model with convex parts, for collision shapes
+func decomposeCommand(args []string) {
+	flags := flag.NewFlagSet("decompose", flag.ExitOnError)
+	output := flags.String("o", "", "Write every part to this file with its number before the extension (hull.stl gives hull-0.stl, hull-1.stl...)")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	voxel := flags.Float64("voxel", 0, "Size of the voxels the solid is approximated with (0 for 1/32 of its longest side)")
+	concavity := flags.Float64("concavity", 0.02, "Largest volume the hull of a part may add to it, as a fraction of the volume of the model")
+	maxHulls := flags.Int("max-hulls", 16, "Largest number of parts")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii decompose [flags] pathtofile -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *output == "" || *voxel < 0 || *concavity <= 0 || *maxHulls < 1 {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	hulls, err := model.ConvexDecomposition(&aModel, model.ConvexDecompositionOptions{
+		VoxelSize: float32(*voxel),
+		Concavity: float32(*concavity),
+		MaxHulls:  *maxHulls,
+	})
+	check(err)
+	extension := filepath.Ext(*output)
+	for i, hull := range hulls {
+		check(saveModel(fmt.Sprintf("%s-%d%s", strings.TrimSuffix(*output, extension), i, extension), "", hull, *ascii))
+	}
+	fmt.Printf("Parts: %v\n", len(hulls))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Compare two models, optionally drawing them side by side or as a heatmap of their distance
+func diffCommand(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	samples := flags.Int("samples", 10000, "Points sampled on each surface to measure the distance, besides the vertices")
+	asJSON := flags.Bool("json", false, "Print the comparison as JSON, without drawing")
+	show := flags.String("show", "none", "Draw the models in the same frame (none|side|heat), heat shading each one by its distance to the other")
+	maxDistance := flags.Float64("max-distance", 0, "Distance drawn with the darkest shade in the heatmap (0 uses the Hausdorff distance)")
+	view := flags.String("view", "front", "Direction to draw the models from (front|side|top|iso|dimetric)")
+	size := flags.Int("size", 60, "Width of each drawing in characters")
+	height := flags.Int("height", 0, "Number of rows of the drawings (0 keeps the proportions of the size)")
+	aspect := flags.Float64("aspect", 2, "Height of a character relative to its width")
+	color := flags.String("color", "none", "Paint with terminal colors instead of characters (none|256|true|auto)")
+	palette := flags.String("palette", "block", "Characters used to draw, from the lowest to the highest value (block|ascii|custom characters)")
+	preLoad := flags.Bool("pl", false, "Preload the files into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii diff [flags] pathtofile pathtofile")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 2 || *size < 1 || *height < 0 || *aspect <= 0 {
+		flags.Usage()
+	}
+	perspective, err := model.ParseProjection(*view)
+	if err != nil || perspective == nil {
+		flags.Usage()
+	}
+	paint, err := renderSettings{color: *color, palette: *palette}.drawer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	modelA, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	modelB, err := loadModel(files[1], "", *preLoad)
+	check(err)
+	comparison := model.Compare(&modelA, &modelB, *samples)
+	if *asJSON {
+		encoded, err := json.MarshalIndent(comparison, "", "  ")
+		check(err)
+		fmt.Println(string(encoded))
+		return
+	}
+	fmt.Print(comparison)
+
+	viewport := model.NewViewport(*size+1, *height, float32(*aspect))
+	var matrixA, matrixB [][]float32
+	switch *show {
+	case "none":
+		return
+	case "side":
+		matrixA, matrixB = model.RasterizePair(&modelA, &modelB, viewport, perspective, model.RenderOptions{})
+	case "heat":
+		//Reuse the distance already measured with the samples
+		distance := float32(*maxDistance)
+		if distance <= 0 {
+			distance = comparison.Distance.Hausdorff
+		}
+		matrixA, matrixB = model.DeviationPair(&modelA, &modelB, viewport, perspective, distance)
+	default:
+		flags.Usage()
+	}
+	fmt.Println()
+	fmt.Print(model.JoinHorizontal("  ", "A: "+files[0]+"\n"+paint(matrixA), "B: "+files[1]+"\n"+paint(matrixB)))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+	"github.com/pmmaga/stl2ascii/primitives"
+)
+
+// Turn the outlines of an SVG or DXF drawing into a solid
+func extrudeCommand(args []string) {
+	flags := flag.NewFlagSet("extrude", flag.ExitOnError)
+	output := flags.String("o", "", "Write the solid to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	height := flags.Float64("height", 1, "Height of the solid")
+	tolerance := flags.Float64("tolerance", 0.05, "Farthest the flattened curves and arcs may stray from the drawing")
+	scale := flags.Float64("scale", 1, "Scale the drawing by this factor, such as 0.2646 for SVG pixels to millimeters")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii extrude [flags] drawing.svg|drawing.dxf -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *output == "" || *height <= 0 || *tolerance <= 0 || *scale <= 0 {
+		flags.Usage()
+	}
+
+	file, err := os.Open(files[0])
+	check(err)
+	defer file.Close()
+	var polygons [][]model.Vec2
+	//The tolerance is on the scaled drawing
+	switch strings.ToLower(filepath.Ext(files[0])) {
+	case ".svg":
+		polygons, err = primitives.ReadSVG(file, *tolerance / *scale)
+	case ".dxf":
+		polygons, err = primitives.ReadDXF(file, *tolerance / *scale)
+	default:
+		err = fmt.Errorf("Unknown drawing format %q, expected .svg or .dxf", filepath.Ext(files[0]))
+	}
+	check(err)
+	for _, polygon := range polygons {
+		for i := range polygon {
+			polygon[i] = model.Vec2{polygon[i][0] * float32(*scale), polygon[i][1] * float32(*scale)}
+		}
+	}
+	solid, err := primitives.ExtrudeProfile(polygons, *height)
+	check(err)
+	fmt.Printf("Outlines: %v\nTriangles: %v\n", len(polygons), len(solid.Triangles))
+	check(saveModel(*output, "", &solid, *ascii))
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Get the model format from the extension of a file
+func fileFormat(filePath string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".stl":
+		return "stl", nil
+	case ".obj":
+		return "obj", nil
+	case ".json":
+		return "json", nil
+	case ".csv":
+		return "csv", nil
+	case ".tsv":
+		return "tsv", nil
+	}
+	return "", errors.New("unknown file format, use a .stl, .obj, .json, .csv or .tsv extension")
+}
+
+// The format given by a flag, or else the one of the extension of the file, or else the fallback
+func formatOf(filePath string, format string, fallback string) string {
+	if format != "" {
+		return format
+	}
+	if format, err := fileFormat(filePath); err == nil {
+		return format
+	}
+	return fallback
+}
+
+// Read a model from a file in the given format, or the one of its extension (STL if unknown). HTTP
+// and HTTPS URLs are downloaded, sniffing the format if it isn't given.
+func loadModel(filePath string, format string, preLoad bool) (aModel model.Model, err error) {
+	if isURL(filePath) {
+		return model.CreateFromURLWith(context.Background(), filePath, model.URLOptions{Format: format})
+	}
+	if format == "" {
+		format, err = fileFormat(filePath)
+		if err != nil {
+			format = "stl"
+		}
+	}
+	switch format {
+	case "stl":
+		return loadSTL(filePath, preLoad)
+	case "obj", "json", "csv", "tsv":
+		fileHandle, err := os.Open(filePath)
+		if err != nil {
+			return aModel, err
+		}
+		defer fileHandle.Close()
+		return decodeModelFrom(bufio.NewReader(fileHandle), format)
+	}
+	return aModel, fmt.Errorf("unknown input format %q", format)
+}
+
+// Check if a model path is an HTTP or HTTPS URL
+func isURL(filePath string) bool {
+	return strings.HasPrefix(filePath, "http://") || strings.HasPrefix(filePath, "https://")
+}
+
+// Read an STL file, trying ASCII first when it looks like it
+func loadSTL(filePath string, preLoad bool) (aModel model.Model, err error) {
+	//If we want to preload the model in memory
+	if preLoad {
+		//Load the whole file to memory
+		fileSlice, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return aModel, err
+		}
+		//Create the model from it
+		return model.CreateFromByteSlice(fileSlice)
+	}
+	//Open the passed file for reading
+	fileHandle, err := os.Open(filePath)
+	if err != nil {
+		return aModel, err
+	}
+	defer fileHandle.Close()
+
+	//Create the reader
+	fileReader := bufio.NewReaderSize(fileHandle, 50*1000)
+
+	//Check if it is ASCII
+	asciiCheck, err := fileReader.Peek(5)
+	if err != nil {
+		return aModel, err
+	}
+
+	//Try ASCII if it looks like it
+	var asciiErr error
+	if string(asciiCheck) == "solid" {
+		//Keep the error for later, we try binary if this fails
+		aModel, asciiErr = model.CreateFromASCIISTL(fileReader)
+	}
+	//If it failed, try binary
+	if len(aModel.Triangles) == 0 {
+		//Reset the reader in case it tried ASCII
+		if string(asciiCheck) == "solid" {
+			fileHandle.Seek(0, 0)
+			fileReader = bufio.NewReader(fileHandle)
+		}
+		//Read the Binary STL
+		return model.CreateFromBinarySTL(fileReader)
+	}
+	return aModel, asciiErr
+}
+
+// Read a model from memory in the given format, like loadModel does from a file
+func decodeModel(data []byte, format string) (aModel model.Model, err error) {
+	if format == "stl" {
+		return model.CreateFromSTLBytes(data)
+	}
+	return decodeModelFrom(bytes.NewReader(data), format)
+}
+
+// Read a model in a text format
+func decodeModelFrom(r io.Reader, format string) (aModel model.Model, err error) {
+	switch format {
+	case "obj":
+		return model.CreateFromOBJ(r)
+	case "json":
+		return model.CreateFromJSON(r)
+	case "csv":
+		return model.CreateFromCSV(r, 0)
+	case "tsv":
+		return model.CreateFromCSV(r, '\t')
+	}
+	return aModel, fmt.Errorf("unknown input format %q", format)
+}
+
+// Write a model to a file in the given format, or the one of its extension (STL as binary unless ascii is set)
+func saveModel(filePath string, format string, aModel *model.Model, ascii bool) error {
+	return saveModelWith(filePath, format, aModel, ascii, model.ASCIIOptions{})
+}
+
+// Write a model to a file like saveModel, with the numbers of ASCII STL and OBJ files written as the options say
+func saveModelWith(filePath string, format string, aModel *model.Model, ascii bool, numbers model.ASCIIOptions) (err error) {
+	if format == "" {
+		format, err = fileFormat(filePath)
+		if err != nil {
+			return err
+		}
+	}
+	if !writableFormat(format) {
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	fileHandle, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(fileHandle)
+	err = writeModelWith(writer, format, aModel, ascii, numbers)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := fileHandle.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Check if writeModel knows a format
+func writableFormat(format string) bool {
+	switch format {
+	case "stl", "obj", "json", "csv", "tsv":
+		return true
+	}
+	return false
+}
+
+// Write a model in the given format (STL as binary unless ascii is set)
+func writeModel(w io.Writer, format string, aModel *model.Model, ascii bool) error {
+	return writeModelWith(w, format, aModel, ascii, model.ASCIIOptions{})
+}
+
+// Write a model like writeModel, with the numbers of ASCII STL and OBJ files written as the options say
+func writeModelWith(w io.Writer, format string, aModel *model.Model, ascii bool, numbers model.ASCIIOptions) error {
+	switch {
+	case format == "obj":
+		return model.WriteOBJWith(w, aModel, numbers)
+	case format == "json":
+		return model.WriteJSON(w, aModel, model.JSONOptions{})
+	case format == "csv":
+		return model.WriteCSV(w, aModel, ',')
+	case format == "tsv":
+		return model.WriteCSV(w, aModel, '\t')
+	case format != "stl":
+		return fmt.Errorf("unknown output format %q", format)
+	case ascii:
+		return model.WriteASCIISTLWith(w, aModel, numbers)
+	}
+	return model.WriteBinarySTL(w, aModel)
+}
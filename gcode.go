@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Draw the toolpath of a G-code file, alone or over the model it was sliced from
+func gcodeCommand(args []string) {
+	var settings renderSettings
+	flags := flag.NewFlagSet("gcode", flag.ExitOnError)
+	settings.addFlags(flags)
+	layers := flags.String("layers", "", "Draw only these layers, counting from 0 at the bottom (n or first:last, empty for all)")
+	travels := flags.Bool("travels", false, "Draw the travel moves too")
+	list := flags.Bool("list", false, "List the layers instead of drawing them")
+	source := flags.String("model", "", "Draw the toolpath over this model, highlighted, to compare them")
+	align := flags.Bool("align", true, "Center the toolpath on the model and put the build plate at its bottom")
+	preLoad := flags.Bool("pl", false, "Preload the model into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii gcode [flags] pathtofile.gcode")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 {
+		flags.Usage()
+	}
+	check(settings.applyViewFiles(flags))
+	file, err := os.Open(files[0])
+	check(err)
+	toolpath, err := model.ReadGCode(file)
+	file.Close()
+	check(err)
+	first, last, err := parseLayerRange(*layers, len(toolpath.Layers))
+	check(err)
+
+	fmt.Printf("Layers: %v\nFilament: %v\n", len(toolpath.Layers), toolpath.Filament)
+	if *list {
+		for i := first; i <= last; i++ {
+			layer := toolpath.Layers[i]
+			fmt.Printf("Layer %d: z %v, %d extrusions, length %v\n", i, layer.Z, len(layer.Extrusions), layer.Length())
+		}
+		return
+	}
+	if *source == "" {
+		err = renderPaths(toolpath.Paths(first, last, *travels), settings)
+	} else {
+		var aModel model.Model
+		aModel, err = loadModel(*source, "", *preLoad)
+		check(err)
+		if *align {
+			bounds, paths := aModel.Bounds(), toolpath.Bounds()
+			offset := bounds.Center().Sub(paths.Center())
+			toolpath.Translate(model.Vec3{offset[0], offset[1], bounds.Min[2]})
+		}
+		settings.lines = toolpath.Paths(first, last, *travels)
+		err = render(&aModel, settings)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Get the first and last layer of a range (n or first:last, empty for all of them)
+func parseLayerRange(s string, count int) (first, last int, err error) {
+	if s == "" {
+		return 0, count - 1, nil
+	}
+	from, to, found := strings.Cut(s, ":")
+	if first, err = strconv.Atoi(from); err == nil {
+		last = first
+		if found {
+			last, err = strconv.Atoi(to)
+		}
+	}
+	if err != nil || first < 0 || last < first || last >= count {
+		return 0, 0, fmt.Errorf("invalid layers %q, there are %d", s, count)
+	}
+	return first, last, nil
+}
+
+// Draw polylines to the terminal or to an image file, brighter when nearer
+func renderPaths(paths [][]model.Vec3, settings renderSettings) error {
+	perspective, err := settings.perspective()
+	if err != nil {
+		return err
+	}
+	if perspective == nil {
+		return errors.New("the sheet can't draw toolpaths")
+	}
+	if settings.size < 1 || settings.height < 0 || settings.aspect <= 0 {
+		return errors.New("the size must be positive and the height and the aspect can't be negative")
+	}
+	graphicsProtocol, err := model.ParseGraphicsProtocol(settings.graphics, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if settings.output != "" || graphicsProtocol != model.GraphicsNone {
+		factor := settings.supersampling()
+		viewport, err := settings.imageViewport(factor)
+		if err != nil {
+			return err
+		}
+		matrix := model.ProjectPaths(paths, viewport, perspective)
+		img := model.Downsample(model.DrawMatrixImage(matrix, image.Transparent), factor)
+		if settings.output == "" {
+			err := model.EncodeInlineImage(os.Stdout, img, graphicsProtocol)
+			fmt.Println()
+			return err
+		}
+		format, err := model.ImageFormatFromPath(settings.output)
+		if err != nil {
+			return err
+		}
+		return writeImage(settings.output, img, format)
+	}
+	paint, err := settings.highlightWriter(nil)
+	if err != nil {
+		return err
+	}
+	viewport, err := settings.gridViewport()
+	if err != nil {
+		return err
+	}
+	return writeMatrix(paint, model.ProjectPaths(paths, viewport, perspective))
+}
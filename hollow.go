@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Add a cavity inside a model, leaving walls of a given thickness
+func hollowCommand(args []string) {
+	var drains []model.Vec3
+	flags := flag.NewFlagSet("hollow", flag.ExitOnError)
+	output := flags.String("o", "", "Write the hollowed model to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	wall := flags.Float64("wall", 2, "Thickness of the walls around the cavity")
+	cell := flags.Float64("cell", 0, "Size of the cells of the distance field (0 for half the wall thickness)")
+	flags.Func("drain", "Punch a drain hole at this point x,y,z of the surface (repeat for more)", func(value string) error {
+		position, err := model.ParseVec3(value)
+		drains = append(drains, position)
+		return err
+	})
+	drainRadius := flags.Float64("drain-radius", 1.5, "Radius of the drain holes")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii hollow [flags] pathtofile -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *output == "" || *wall <= 0 || *cell < 0 || *drainRadius <= 0 {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	opts := model.HollowOptions{WallThickness: float32(*wall), CellSize: float32(*cell)}
+	for _, position := range drains {
+		opts.DrainHoles = append(opts.DrainHoles, model.DrainHole{Position: position, Radius: float32(*drainRadius)})
+	}
+	volume := model.Volume(&aModel)
+	check(model.HollowWith(&aModel, opts))
+	fmt.Printf("Volume: %v\nHollowed volume: %v\nTriangles: %v\n", volume, model.Volume(&aModel), len(aModel.Triangles))
+	check(saveModel(*output, "", &aModel, *ascii))
+}
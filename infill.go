@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Fill the inside of a model with a lattice to make it lighter
+func infillCommand(args []string) {
+	flags := flag.NewFlagSet("infill", flag.ExitOnError)
+	output := flags.String("o", "", "Write the filled model to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	pattern := flags.String("pattern", "gyroid", "Structure filling the model (gyroid|grid)")
+	density := flags.Float64("density", 0.2, "Fraction of the inside filled by the lattice (0 to 1)")
+	period := flags.Float64("period", 10, "Size of the cube the pattern repeats in")
+	wall := flags.Float64("wall", 1, "Thickness of the solid wall around the lattice (0 for none)")
+	cell := flags.Float64("cell", 0, "Size of the cells of the distance field (0 for half the thickness of the lattice)")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii infill [flags] pathtofile -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *output == "" || *density <= 0 || *density >= 1 || *period <= 0 || *wall < 0 || *cell < 0 {
+		flags.Usage()
+	}
+	opts := model.LatticeOptions{
+		Density: float32(*density), Period: float32(*period), WallThickness: float32(*wall), CellSize: float32(*cell),
+	}
+	switch *pattern {
+	case "gyroid":
+		opts.Pattern = model.GyroidLattice
+	case "grid":
+		opts.Pattern = model.GridLattice
+	default:
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	volume := model.Volume(&aModel)
+	check(model.InfillWith(&aModel, opts))
+	fmt.Printf("Volume: %v\nFilled volume: %v\nTriangles: %v\n", volume, model.Volume(&aModel), len(aModel.Triangles))
+	check(saveModel(*output, "", &aModel, *ascii))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Print the size and the soundness of a model
+func infoCommand(args []string) {
+	flags := flag.NewFlagSet("info", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print the information as JSON")
+	minWall := flags.Float64("min-wall", 0, "Also look for walls thinner than this (0 to skip it)")
+	material := flags.Bool("material", false, "Also estimate the material needed to print the model")
+	layerHeight := flags.Float64("layer-height", float64(model.DefaultMaterialOptions.LayerHeight), "Height of each layer for -material")
+	wall := flags.Float64("wall", float64(model.DefaultMaterialOptions.WallThickness), "Thickness of the walls, floors and roofs for -material")
+	infill := flags.Float64("infill", float64(model.DefaultMaterialOptions.Infill), "Percentage of the inside filled for -material")
+	supportDensity := flags.Float64("support-density", float64(model.DefaultMaterialOptions.SupportDensity), "Percentage of the space below the overhangs filled by supports for -material")
+	footprint := flags.Int("footprint", 0, "Also measure the area and the perimeter of the shadow of the model on the build plate, rasterized on a grid this many cells across (0 to skip it)")
+	quality := flags.Bool("quality", false, "Also measure the shape of the triangles: histograms and counts of slivers and needles")
+	quick := flags.Bool("quick", false, "Only read the header and a sample of the triangles of a binary STL, for huge files")
+	samples := flags.Int("samples", 1000, "Triangles read to estimate the dimensions with -quick")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii info [flags] pathtofile")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *minWall < 0 || *layerHeight <= 0 || *wall < 0 || *infill < 0 || *infill > 100 || *supportDensity < 0 || *supportDensity > 100 || *samples < 0 || *footprint < 0 {
+		flags.Usage()
+	}
+
+	if *quick {
+		summary, err := model.ReadSTLSummaryFile(files[0], *samples)
+		check(err)
+		if *asJSON {
+			encoded, err := json.MarshalIndent(summary, "", "  ")
+			check(err)
+			fmt.Println(string(encoded))
+			return
+		}
+		fmt.Print(summary)
+		return
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	//The thickness and the material are only measured on demand, they cast several rays per facet
+	output := struct {
+		model.Info
+		ThinWallFacets     *int                    `json:"thinWallFacets,omitempty"`
+		ThinWallArea       *float32                `json:"thinWallArea,omitempty"`
+		ThinnestWall       *float32                `json:"thinnestWall,omitempty"`
+		Material           *model.MaterialEstimate `json:"material,omitempty"`
+		FootprintArea      *float32                `json:"footprintArea,omitempty"`
+		FootprintPerimeter *float32                `json:"footprintPerimeter,omitempty"`
+		Quality            *model.QualityStats     `json:"quality,omitempty"`
+	}{Info: model.Inspect(&aModel)}
+	if *minWall > 0 {
+		thinWalls := model.FindThinWalls(&aModel, float32(*minWall))
+		count := len(thinWalls.Facets)
+		output.ThinWallFacets, output.ThinWallArea, output.ThinnestWall = &count, &thinWalls.Area, &thinWalls.Thinnest
+	}
+	if *material {
+		estimate := model.EstimateMaterial(&aModel, model.MaterialOptions{
+			LayerHeight:    float32(*layerHeight),
+			WallThickness:  float32(*wall),
+			Infill:         float32(*infill),
+			SupportDensity: float32(*supportDensity),
+			OverhangAngle:  model.DefaultOverhangAngle,
+		})
+		output.Material = &estimate
+	}
+	if *footprint > 0 {
+		shadow := model.ComputeFootprint(&aModel, *footprint)
+		output.FootprintArea, output.FootprintPerimeter = &shadow.Area, &shadow.Perimeter
+	}
+	if *quality {
+		stats := aModel.Stats()
+		output.Quality = &stats
+	}
+	if *asJSON {
+		encoded, err := json.MarshalIndent(output, "", "  ")
+		check(err)
+		fmt.Println(string(encoded))
+		return
+	}
+	fmt.Print(output.Info)
+	if *minWall > 0 {
+		fmt.Printf("Thin wall facets: %v\nThin wall area: %v\nThinnest wall: %v\n", *output.ThinWallFacets, *output.ThinWallArea, *output.ThinnestWall)
+	}
+	if *material {
+		fmt.Print(output.Material)
+	}
+	if *footprint > 0 {
+		fmt.Printf("Footprint area: %v\nFootprint perimeter: %v\n", *output.FootprintArea, *output.FootprintPerimeter)
+	}
+	if *quality {
+		fmt.Print(output.Quality)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Measure the distance between two points, the angle between two facets and the extent along a direction
+func measureCommand(args []string) {
+	flags := flag.NewFlagSet("measure", flag.ExitOnError)
+	from := flags.String("from", "", "Start of the distance, a point x,y,z or a vertex vN (numbered from 0 in the order of the file)")
+	to := flags.String("to", "", "End of the distance, a point x,y,z or a vertex vN")
+	snap := flags.Bool("snap", false, "Move the points given as x,y,z to the closest point of the surface")
+	angle := flags.String("angle", "", "Measure the angle between the normals of two facets, numbered from 0 (a,b)")
+	along := flags.String("along", "", "Measure the extent of the model along an axis or a direction (x|y|z|x,y,z)")
+	asJSON := flags.Bool("json", false, "Print the measurements as JSON")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii measure [flags] pathtofile")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || (*from == "") != (*to == "") || (*from == "" && *angle == "" && *along == "") {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	//Stop with the error of a measurement that can't be taken
+	exitOnError := func(err error) {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	output := struct {
+		Distance *model.Distance `json:"distance,omitempty"`
+		Angle    *float64        `json:"angle,omitempty"`
+		Extent   *model.Extent   `json:"extent,omitempty"`
+	}{}
+	if *from != "" {
+		var topology *model.Topology
+		var bvh *model.BVH
+		//Read a vertex of the model or a point, snapped to the surface if asked
+		point := func(s string) (model.Vec3, error) {
+			if index, found := strings.CutPrefix(s, "v"); found {
+				if topology == nil {
+					topology = model.NewTopology(&aModel)
+				}
+				i, err := strconv.Atoi(index)
+				if err != nil || i < 0 || i >= len(topology.Vertices) {
+					return model.Vec3{}, fmt.Errorf("invalid vertex %q, there are %d", s, len(topology.Vertices))
+				}
+				return topology.Vertices[i], nil
+			}
+			p, err := model.ParseVec3(s)
+			if err != nil || !*snap {
+				return p, err
+			}
+			if bvh == nil {
+				bvh = model.NewBVH(&aModel)
+			}
+			hit, ok := bvh.ClosestPoint(p)
+			if !ok {
+				return p, fmt.Errorf("the model has no surface to snap %q to", s)
+			}
+			return hit.Point, nil
+		}
+		start, err := point(*from)
+		exitOnError(err)
+		end, err := point(*to)
+		exitOnError(err)
+		distance := model.MeasureDistance(start, end)
+		output.Distance = &distance
+	}
+	if *angle != "" {
+		facets := strings.Split(*angle, ",")
+		if len(facets) != 2 {
+			flags.Usage()
+		}
+		a, errA := strconv.Atoi(strings.TrimSpace(facets[0]))
+		b, errB := strconv.Atoi(strings.TrimSpace(facets[1]))
+		if errA != nil || errB != nil {
+			flags.Usage()
+		}
+		degrees, err := model.FacetAngle(&aModel, a, b)
+		exitOnError(err)
+		output.Angle = &degrees
+	}
+	if *along != "" {
+		direction, found := map[string]model.Vec3{"x": {1, 0, 0}, "y": {0, 1, 0}, "z": {0, 0, 1}}[*along]
+		if !found {
+			direction, err = model.ParseVec3(*along)
+			exitOnError(err)
+		}
+		extent, err := model.MeasureExtent(&aModel, direction)
+		exitOnError(err)
+		output.Extent = &extent
+	}
+
+	if *asJSON {
+		encoded, err := json.MarshalIndent(output, "", "  ")
+		check(err)
+		fmt.Println(string(encoded))
+		return
+	}
+	if output.Distance != nil {
+		fmt.Print(output.Distance)
+	}
+	if output.Angle != nil {
+		fmt.Printf("Angle: %v\n", *output.Angle)
+	}
+	if output.Extent != nil {
+		fmt.Print(output.Extent)
+	}
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"errors"
+	"math"
+)
+
+// AdhesionOptions tunes Brim, Raft and Skirt
+type AdhesionOptions struct {
+	//How far the brim and the raft reach out of the footprint, and the width of the skirt
+	Width float32
+	//Height of the layer they make
+	Thickness float32
+	//Distance between the skirt and the footprint
+	Distance float32
+	//Cells across the grid the footprint is outlined on, see ComputeFootprint
+	Resolution int
+}
+
+// DefaultAdhesionOptions matches common settings for FDM printers
+var DefaultAdhesionOptions = AdhesionOptions{
+	Width:      5,
+	Thickness:  0.3,
+	Distance:   3,
+	Resolution: 256,
+}
+
+// Brim builds a flat ring hugging the footprint of the model and reaching Width out of it, resting on
+// the build plate with the part, to hold its edges down
+func Brim(m *Model, opts AdhesionOptions) (*Model, error) {
+	return adhesionHelper(m, opts, "Brim", 0, opts.Width, 0, true)
+}
+
+// Raft builds a flat slab under the whole footprint of the model, reaching Width out of it, for the
+// part to be printed on. The raft goes from Thickness below the lowest point of the part up to it, so
+// the part and the raft are moved up together before printing.
+func Raft(m *Model, opts AdhesionOptions) (*Model, error) {
+	return adhesionHelper(m, opts, "Raft", -1, opts.Width, -opts.Thickness, true)
+}
+
+// Skirt builds a flat ring of Width around the footprint of the model at Distance from it, resting
+// on the build plate with the part, priming the nozzle without touching the part. Unlike the brim,
+// it stays out of the holes of the footprint.
+func Skirt(m *Model, opts AdhesionOptions) (*Model, error) {
+	return adhesionHelper(m, opts, "Skirt", opts.Distance, opts.Distance+opts.Width, 0, false)
+}
+
+// adhesionHelper builds a slab covering the points of the XY plane farther than inner (or in the
+// footprint, when inner is negative) and up to outer from the footprint of the model, going up by the
+// thickness from the lowest point of the model moved by lift. When holes is false, the holes of
+// the footprint count as part of it.
+func adhesionHelper(m *Model, opts AdhesionOptions, header string, inner, outer, lift float32, holes bool) (*Model, error) {
+	if opts.Width <= 0 || opts.Thickness <= 0 {
+		return nil, errors.New("Width and thickness must be positive")
+	}
+	if opts.Distance < 0 || opts.Resolution < 1 {
+		return nil, errors.New("Distance can't be negative and the resolution must be positive")
+	}
+	footprint := ComputeFootprint(m, opts.Resolution)
+	if len(footprint.Regions) == 0 {
+		return nil, errors.New("Model has no footprint")
+	}
+	if !holes {
+		for r := range footprint.Regions {
+			footprint.Regions[r].Holes = nil
+		}
+	}
+	//A grid as fine as the one of the footprint, with room for the helper around it
+	area := EmptyBox()
+	for _, region := range footprint.Regions {
+		for _, p := range region.Outline {
+			area = area.Extend(Vec3{p[0], p[1], 0})
+		}
+	}
+	size := area.Size()
+	cell := max(size[0], size[1]) / float32(opts.Resolution)
+	margin := int(math.Ceil(float64(outer/cell))) + 1
+	columns, rows := int(math.Ceil(float64(size[0]/cell)))+2*margin, int(math.Ceil(float64(size[1]/cell)))+2*margin
+	left, top := area.Min[0]-float32(margin)*cell, area.Max[1]+float32(margin)*cell
+	center := func(row, col int) Vec2 {
+		return Vec2{left + (float32(col)+0.5)*cell, top - (float32(row)+0.5)*cell}
+	}
+	inside := make([]bool, rows*columns)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			inside[row*columns+col] = footprint.Contains(center(row, col))
+		}
+	}
+	//Distance of every cell to the footprint in cells, from the cells of its border
+	reach := float32(margin)
+	distances := make([]float32, rows*columns)
+	for n := range distances {
+		distances[n] = math.MaxFloat32
+	}
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			if !inside[row*columns+col] || (row > 0 && inside[(row-1)*columns+col] && row < rows-1 && inside[(row+1)*columns+col] &&
+				col > 0 && inside[row*columns+col-1] && col < columns-1 && inside[row*columns+col+1]) {
+				continue
+			}
+			for r := max(row-margin, 0); r <= min(row+margin, rows-1); r++ {
+				for c := max(col-margin, 0); c <= min(col+margin, columns-1); c++ {
+					d := float32(math.Hypot(float64(r-row), float64(c-col)))
+					if d <= reach && d < distances[r*columns+c] {
+						distances[r*columns+c] = d
+					}
+				}
+			}
+		}
+	}
+	covered := func(row, col int) bool {
+		if row < 0 || col < 0 || row >= rows || col >= columns {
+			return false
+		}
+		n := row * columns
+		if inside[n+col] {
+			return inner < 0
+		}
+		//The border of the footprint is half a cell away from the center of its cells
+		d := (distances[n+col] - 0.5) * cell
+		return d > inner && d <= outer
+	}
+	loops, _ := gridLoops(rows, columns, covered)
+	outlines := make([][]Vec2, len(loops))
+	for i, loop := range loops {
+		outlines[i] = make([]Vec2, len(loop))
+		for j, corner := range loop {
+			outlines[i][j] = Vec2{left + float32(corner[0])*cell, top - float32(corner[1])*cell}
+		}
+	}
+	bottom := m.Bounds().Min[2] + lift
+	helper := &Model{Header: header}
+	//Straightening the loops may make close ones cross, those are kept as they are on the grid
+	triangles, err := slabTriangles(footprintOf(outlines, cell), bottom, bottom+opts.Thickness)
+	if err != nil {
+		triangles, err = slabTriangles(footprintOf(outlines, 0), bottom, bottom+opts.Thickness)
+		if err != nil {
+			return nil, err
+		}
+	}
+	helper.Append(triangles...)
+	return helper, nil
+}
+
+// slabTriangles extrudes the regions of a footprint from the bottom to the top height
+func slabTriangles(f Footprint, bottom, top float32) (triangles []Triangle, err error) {
+	add := func(a, b, c Vec3) {
+		t := Triangle{Vertices: [3][3]float32{a, b, c}}
+		t.Normal = t.ComputedNormal()
+		triangles = append(triangles, t)
+	}
+	for _, region := range f.Regions {
+		caps, err := TriangulateWithHoles(region.Outline, region.Holes)
+		if err != nil {
+			return nil, err
+		}
+		//The points of the caps are those of the outline followed by those of the holes
+		points := append([]Vec2(nil), region.Outline...)
+		for _, hole := range region.Holes {
+			points = append(points, hole...)
+		}
+		at := func(p Vec2, z float32) Vec3 { return Vec3{p[0], p[1], z} }
+		for _, t := range caps {
+			add(at(points[t[0]], top), at(points[t[1]], top), at(points[t[2]], top))
+			add(at(points[t[0]], bottom), at(points[t[2]], bottom), at(points[t[1]], bottom))
+		}
+		//The outline is counter-clockwise and the holes clockwise, so the walls all face out
+		for _, loop := range append([][]Vec2{region.Outline}, region.Holes...) {
+			for i := range loop {
+				a, b := loop[i], loop[(i+1)%len(loop)]
+				add(at(a, bottom), at(b, bottom), at(b, top))
+				add(at(a, bottom), at(b, top), at(a, top))
+			}
+		}
+	}
+	return triangles, nil
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ArrangeOptions tunes Arrange
+type ArrangeOptions struct {
+	//Size of the build plate along X and Y, 220 by 220 if zero
+	Plate Vec2
+	//Gap left between the parts
+	Spacing float32
+	//Turn the parts a quarter around Z when it lets them fit or packs them better
+	Rotate bool
+}
+
+// Arrange lays the models side by side on a build plate going from the origin to the plate size,
+// resting on Z = 0, and returns the transformation placing each one there. The bounding boxes of the
+// models are packed in rows, the deepest ones first. It fails when the parts don't all fit on the
+// plate, without modifying the models either way.
+func Arrange(models []*Model, opts ArrangeOptions) ([]Affine, error) {
+	boxes := make([]Box, len(models))
+	for i, m := range models {
+		boxes[i] = m.Bounds()
+	}
+	return arrangeBoxes(boxes, opts)
+}
+
+// Arrange lays the instances of the scene side by side on a build plate like the Arrange function,
+// moving them from where they are placed
+func (s *Scene) Arrange(opts ArrangeOptions) error {
+	boxes := make([]Box, len(s.Instances))
+	for i := range s.Instances {
+		boxes[i] = s.Instances[i].Bounds()
+	}
+	placements, err := arrangeBoxes(boxes, opts)
+	if err != nil {
+		return err
+	}
+	for i := range s.Instances {
+		s.Instances[i].Transform = s.Instances[i].Transform.Then(placements[i])
+	}
+	return nil
+}
+
+// arrangeBoxes packs the footprints of the boxes on the plate in shelves, rows as deep as their first
+// and deepest part, putting every part in the first shelf with room for it
+func arrangeBoxes(boxes []Box, opts ArrangeOptions) ([]Affine, error) {
+	if len(boxes) == 0 {
+		return nil, errors.New("No parts to arrange")
+	}
+	if opts.Plate[0] < 0 || opts.Plate[1] < 0 || opts.Spacing < 0 {
+		return nil, errors.New("Plate size and spacing can't be negative")
+	}
+	plate := opts.Plate
+	if plate == (Vec2{}) {
+		plate = Vec2{220, 220}
+	}
+	quarter := RotateZ(math.Pi / 2)
+	//Footprint of every part once turned, if it is
+	turned := make([]bool, len(boxes))
+	footprints := make([]Box, len(boxes))
+	for i, box := range boxes {
+		if box == EmptyBox() {
+			return nil, fmt.Errorf("Part %d has no triangles", i)
+		}
+		size := box.Size()
+		fits := size[0] <= plate[0] && size[1] <= plate[1]
+		fitsTurned := size[1] <= plate[0] && size[0] <= plate[1]
+		if !fits && !(opts.Rotate && fitsTurned) {
+			return nil, fmt.Errorf("Part %d (%v by %v) doesn't fit on the build plate", i, size[0], size[1])
+		}
+		//Long sides along the rows keep the shelves shallow
+		turned[i] = opts.Rotate && fitsTurned && (!fits || size[1] > size[0])
+		footprints[i] = box
+		if turned[i] {
+			footprints[i] = EmptyBox()
+			for _, corner := range [4]Vec3{box.Min, {box.Max[0], box.Min[1], box.Min[2]}, {box.Min[0], box.Max[1], box.Max[2]}, box.Max} {
+				footprints[i] = footprints[i].Extend(quarter.Apply(corner))
+			}
+		}
+	}
+	order := make([]int, len(boxes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sizeA, sizeB := footprints[order[a]].Size(), footprints[order[b]].Size()
+		return sizeA[1] > sizeB[1] || sizeA[1] == sizeB[1] && sizeA[0] > sizeB[0]
+	})
+	type shelf struct {
+		y, depth, used float32
+	}
+	var shelves []shelf
+	placements := make([]Affine, len(boxes))
+	for _, i := range order {
+		size := footprints[i].Size()
+		found := -1
+		for s := range shelves {
+			if size[1] <= shelves[s].depth && shelves[s].used+opts.Spacing+size[0] <= plate[0] {
+				found = s
+				break
+			}
+		}
+		var x float32
+		if found >= 0 {
+			x = shelves[found].used + opts.Spacing
+		} else {
+			y := float32(0)
+			if len(shelves) > 0 {
+				last := shelves[len(shelves)-1]
+				y = last.y + last.depth + opts.Spacing
+			}
+			if y+size[1] > plate[1] {
+				return nil, errors.New("Parts don't fit together on the build plate")
+			}
+			shelves = append(shelves, shelf{y: y, depth: size[1]})
+			found = len(shelves) - 1
+		}
+		shelves[found].used = x + size[0]
+		placements[i] = Translate(Vec3{x, shelves[found].y, 0}.Sub(footprints[i].Min))
+		if turned[i] {
+			placements[i] = quarter.Then(placements[i])
+		}
+	}
+	return placements, nil
+}
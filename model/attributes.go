@@ -0,0 +1,123 @@
+package model
+
+import (
+	"image/color"
+	"maps"
+)
+
+// Attributes is what a triangle carries besides its geometry, for the formats and the programs that
+// know about it. Unlike the attribute bytes of binary STL files it isn't limited to 16 bits.
+type Attributes struct {
+	//Color of the triangle, none when the alpha is 0
+	Color color.RGBA
+	//Material the triangle is made of, 0 for none
+	Material int
+	//Region of the model the triangle belongs to, like a label from a segmentation, empty for none
+	Region string
+	//Anything else, nil for nothing
+	Tags map[string]string
+}
+
+// clone returns a copy of the attributes with their own tags
+func (a Attributes) clone() Attributes {
+	a.Tags = maps.Clone(a.Tags)
+	return a
+}
+
+// TriangleAttributes returns the attributes of the triangle at index i, the zero value when the
+// model has none
+func (m *Model) TriangleAttributes(i int) Attributes {
+	if i < len(m.Attributes) {
+		return m.Attributes[i]
+	}
+	return Attributes{}
+}
+
+// SetTriangleAttributes sets the attributes of the triangle at index i, giving every other triangle
+// empty ones if the model had none
+func (m *Model) SetTriangleAttributes(i int, a Attributes) {
+	m.Attributes = growAttributes(m.Attributes, len(m.Triangles))
+	m.Attributes[i] = a
+}
+
+// ClearAttributes drops the attributes of all the triangles
+func (m *Model) ClearAttributes() {
+	m.Attributes = nil
+}
+
+// growAttributes pads the attributes with empty ones up to n, cutting them if they are more
+func growAttributes(attributes []Attributes, n int) []Attributes {
+	if len(attributes) >= n {
+		return attributes[:n]
+	}
+	return append(attributes, make([]Attributes, n-len(attributes))...)
+}
+
+// keepTriangles drops the triangles, and their attributes, for which keep is false, asking for them
+// in order, and returns how many were dropped
+func (m *Model) keepTriangles(keep func(i int, t *Triangle) bool) (removed int) {
+	m.Invalidate()
+	if m.Attributes != nil {
+		m.Attributes = growAttributes(m.Attributes, len(m.Triangles))
+	}
+	kept := 0
+	for i := range m.Triangles {
+		if !keep(i, &m.Triangles[i]) {
+			continue
+		}
+		m.Triangles[kept] = m.Triangles[i]
+		if m.Attributes != nil {
+			m.Attributes[kept] = m.Attributes[i]
+		}
+		kept++
+	}
+	removed = len(m.Triangles) - kept
+	m.Triangles, m.NumTriangles = m.Triangles[:kept], uint32(kept)
+	if m.Attributes != nil {
+		m.Attributes = m.Attributes[:kept]
+	}
+	return removed
+}
+
+// LoadFacetColors sets the color of the triangles from their attribute bytes, as decoded by
+// FacetColor with the colors declared in the header, and returns how many had one
+func LoadFacetColors(m *Model) (colored int) {
+	info := ParseHeader(m.Header)
+	for i := range m.Triangles {
+		c, ok := info.FacetColor(&m.Triangles[i])
+		if !ok {
+			continue
+		}
+		a := m.TriangleAttributes(i)
+		a.Color = c
+		m.SetTriangleAttributes(i, a)
+		colored++
+	}
+	return colored
+}
+
+// StoreFacetColors writes the color of the triangles into their attribute bytes, 5 bits per channel,
+// so binary STL files carry them. The colors are stored the way FacetColor reads them back: as
+// Materialise Magics does when the header declares a color, and as VisCAM and SolidView do otherwise.
+// Triangles without a color get attribute bytes meaning none. It returns how many have one.
+func StoreFacetColors(m *Model) (colored int) {
+	magics := ParseHeader(m.Header).Color != nil
+	for i := range m.Triangles {
+		c := m.TriangleAttributes(i).Color
+		var attributes uint16
+		if c.A != 0 {
+			red, green, blue := uint16(c.R>>3), uint16(c.G>>3), uint16(c.B>>3)
+			if magics {
+				red, blue = blue, red
+			}
+			attributes = 0x8000 | red<<10 | green<<5 | blue
+			colored++
+		}
+		//Magics sets the top bit for the facets without a color of their own
+		if magics {
+			attributes ^= 0x8000
+		}
+		m.Triangles[i].AttrByteCount = attributes
+	}
+	return colored
+}
@@ -0,0 +1,74 @@
+package model
+
+import "fmt"
+
+// Builder makes a model one triangle at a time, computing the normals from the winding and keeping
+// the count of triangles in step. The zero value is ready to use.
+type Builder struct {
+	Header string
+	//Name of the solid in ASCII STL files
+	Name      string
+	triangles []Triangle
+	//Attributes of the triangles added so far, nil while none was given
+	attributes []Attributes
+}
+
+// AddTriangle adds the triangle v1 v2 v3, counter-clockwise seen from outside, with its normal. It
+// refuses triangles without an area or with NaN or infinite coordinates, which make no surface and
+// break the computations on the model.
+func (b *Builder) AddTriangle(v1, v2, v3 Vec3) error {
+	t := Triangle{Vertices: [3][3]float32{v1, v2, v3}}
+	if !finiteTriangle(&t) {
+		return fmt.Errorf("Triangle %d has NaN or infinite coordinates", len(b.triangles))
+	}
+	normal := v2.Sub(v1).Cross(v3.Sub(v1)).Normalize()
+	if normal == (Vec3{}) {
+		return fmt.Errorf("Triangle %d is degenerate, its vertices are in line", len(b.triangles))
+	}
+	t.Normal = normal
+	b.triangles = append(b.triangles, t)
+	if b.attributes != nil {
+		b.attributes = append(b.attributes, Attributes{})
+	}
+	return nil
+}
+
+// AddTriangleWith adds a triangle like AddTriangle, with its attributes
+func (b *Builder) AddTriangleWith(v1, v2, v3 Vec3, a Attributes) error {
+	if err := b.AddTriangle(v1, v2, v3); err != nil {
+		return err
+	}
+	b.attributes = growAttributes(b.attributes, len(b.triangles))
+	b.attributes[len(b.triangles)-1] = a
+	return nil
+}
+
+// AddQuad adds the quad v1 v2 v3 v4, counter-clockwise seen from outside, as the two triangles v1 v2
+// v3 and v1 v3 v4. Neither is added if one of them is refused.
+func (b *Builder) AddQuad(v1, v2, v3, v4 Vec3) error {
+	count := len(b.triangles)
+	if err := b.AddTriangle(v1, v2, v3); err != nil {
+		return err
+	}
+	if err := b.AddTriangle(v1, v3, v4); err != nil {
+		b.triangles = b.triangles[:count]
+		if b.attributes != nil {
+			b.attributes = b.attributes[:count]
+		}
+		return err
+	}
+	return nil
+}
+
+// Len returns the number of triangles added so far
+func (b *Builder) Len() int {
+	return len(b.triangles)
+}
+
+// Model returns the model of the triangles added so far and empties the builder, which can then start
+// another one with the same header and name
+func (b *Builder) Model() *Model {
+	m := &Model{Header: b.Header, Name: b.Name, NumTriangles: uint32(len(b.triangles)), Triangles: b.triangles, Attributes: b.attributes}
+	b.triangles, b.attributes = nil, nil
+	return m
+}
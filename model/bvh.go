@@ -0,0 +1,278 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+// Maximum number of triangles kept in a BVH leaf
+const bvhLeafSize = 4
+
+// Ray is a half line starting at Origin and going along Direction
+type Ray struct {
+	Origin    Vec3
+	Direction Vec3
+}
+
+// RayHit describes where a ray crossed a triangle
+type RayHit struct {
+	Triangle int
+	Distance float32
+	Point    Vec3
+}
+
+// NearestHit describes the surface point closest to a query point
+type NearestHit struct {
+	Triangle int
+	Distance float32
+	Point    Vec3
+}
+
+// BVH is a bounding volume hierarchy over the triangles of a model
+type BVH struct {
+	model   *Model
+	nodes   []bvhNode
+	indices []int
+}
+
+type bvhNode struct {
+	bounds Box
+	//Children of an inner node
+	left, right int
+	//Range in indices of a leaf node (count is 0 for inner nodes)
+	start, count int
+}
+
+// NewBVH builds the hierarchy for the triangles of m. The model must not be modified while the BVH is in use.
+func NewBVH(m *Model) *BVH {
+	b := &BVH{model: m, indices: make([]int, len(m.Triangles))}
+	if len(m.Triangles) == 0 {
+		return b
+	}
+	//Precompute the bounds and centroids of every triangle
+	bounds := make([]Box, len(m.Triangles))
+	centroids := make([]Vec3, len(m.Triangles))
+	for i := range m.Triangles {
+		b.indices[i] = i
+		bounds[i] = m.Triangles[i].Bounds()
+		centroids[i] = bounds[i].Center()
+	}
+	b.nodes = make([]bvhNode, 0, 2*len(m.Triangles)/bvhLeafSize+1)
+	b.build(0, len(b.indices), bounds, centroids)
+	return b
+}
+
+// build creates the node for indices[start:end] and returns its position
+func (b *BVH) build(start, end int, bounds []Box, centroids []Vec3) int {
+	//Compute the bounds of the node and of the centroids
+	nodeBounds, centroidBounds := EmptyBox(), EmptyBox()
+	for _, i := range b.indices[start:end] {
+		nodeBounds = nodeBounds.Union(bounds[i])
+		centroidBounds = centroidBounds.Extend(centroids[i])
+	}
+	nodeIndex := len(b.nodes)
+	b.nodes = append(b.nodes, bvhNode{bounds: nodeBounds})
+	//Make a leaf if there are few triangles left
+	if end-start <= bvhLeafSize {
+		b.nodes[nodeIndex].start, b.nodes[nodeIndex].count = start, end-start
+		return nodeIndex
+	}
+	//Split at the median along the longest axis of the centroids
+	size := centroidBounds.Size()
+	axis := 0
+	if size[1] > size[axis] {
+		axis = 1
+	}
+	if size[2] > size[axis] {
+		axis = 2
+	}
+	part := b.indices[start:end]
+	sort.Slice(part, func(i, j int) bool {
+		return centroids[part[i]][axis] < centroids[part[j]][axis]
+	})
+	mid := start + (end-start)/2
+	left := b.build(start, mid, bounds, centroids)
+	right := b.build(mid, end, bounds, centroids)
+	b.nodes[nodeIndex].left, b.nodes[nodeIndex].right = left, right
+	return nodeIndex
+}
+
+// Model returns the model the BVH was built from
+func (b *BVH) Model() *Model {
+	return b.model
+}
+
+// Bounds returns the bounding box of the whole model
+func (b *BVH) Bounds() Box {
+	if len(b.nodes) == 0 {
+		return EmptyBox()
+	}
+	return b.nodes[0].bounds
+}
+
+// Raycast returns the closest triangle crossed by the ray
+func (b *BVH) Raycast(r Ray) (hit RayHit, ok bool) {
+	hit.Distance = math.MaxFloat32
+	b.traverseRay(r, func(t int, distance float32) {
+		if distance < hit.Distance {
+			hit.Triangle, hit.Distance, ok = t, distance, true
+		}
+	}, func() float32 { return hit.Distance })
+	if ok {
+		hit.Point = r.Origin.Add(r.Direction.Scale(hit.Distance))
+	}
+	return hit, ok
+}
+
+// RaycastAll returns every triangle crossed by the ray, sorted by distance
+func (b *BVH) RaycastAll(r Ray) []RayHit {
+	var hits []RayHit
+	b.traverseRay(r, func(t int, distance float32) {
+		hits = append(hits, RayHit{Triangle: t, Distance: distance, Point: r.Origin.Add(r.Direction.Scale(distance))})
+	}, func() float32 { return math.MaxFloat32 })
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Distance < hits[j].Distance })
+	return hits
+}
+
+// traverseRay calls onHit for each triangle crossed by r closer than maxDistance()
+func (b *BVH) traverseRay(r Ray, onHit func(t int, distance float32), maxDistance func() float32) {
+	if len(b.nodes) == 0 {
+		return
+	}
+	//Precompute the inverse direction for the slab tests
+	var invDir Vec3
+	for i := range invDir {
+		invDir[i] = 1 / r.Direction[i]
+	}
+	stack := []int{0}
+	for len(stack) > 0 {
+		node := &b.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if !rayIntersectsBox(r.Origin, invDir, node.bounds, maxDistance()) {
+			continue
+		}
+		if node.count == 0 {
+			stack = append(stack, node.left, node.right)
+			continue
+		}
+		for _, t := range b.indices[node.start : node.start+node.count] {
+			tri := &b.model.Triangles[t]
+			if distance, crossed := rayIntersectsTriangle(r, tri.Vertices[0], tri.Vertices[1], tri.Vertices[2]); crossed {
+				onHit(t, distance)
+			}
+		}
+	}
+}
+
+// QueryBox returns the indices of the triangles whose bounds overlap the box
+func (b *BVH) QueryBox(box Box) []int {
+	var found []int
+	if len(b.nodes) == 0 {
+		return found
+	}
+	stack := []int{0}
+	for len(stack) > 0 {
+		node := &b.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if !node.bounds.Intersects(box) {
+			continue
+		}
+		if node.count == 0 {
+			stack = append(stack, node.left, node.right)
+			continue
+		}
+		for _, t := range b.indices[node.start : node.start+node.count] {
+			if b.model.Triangles[t].Bounds().Intersects(box) {
+				found = append(found, t)
+			}
+		}
+	}
+	return found
+}
+
+// Nearest returns the surface point of the model closest to p
+func (b *BVH) Nearest(p Vec3) (hit NearestHit, ok bool) {
+	if len(b.nodes) == 0 {
+		return hit, false
+	}
+	best := float32(math.MaxFloat32)
+	stack := []int{0}
+	for len(stack) > 0 {
+		node := &b.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if node.bounds.distanceSquared(p) > best {
+			continue
+		}
+		if node.count == 0 {
+			//Visit the closest child first so the other one is more likely to be pruned
+			left, right := node.left, node.right
+			if b.nodes[left].bounds.distanceSquared(p) < b.nodes[right].bounds.distanceSquared(p) {
+				left, right = right, left
+			}
+			stack = append(stack, left, right)
+			continue
+		}
+		for _, t := range b.indices[node.start : node.start+node.count] {
+			tri := &b.model.Triangles[t]
+			point := closestPointOnTriangle(p, tri.Vertices[0], tri.Vertices[1], tri.Vertices[2])
+			diff := point.Sub(p)
+			if d := diff.Dot(diff); d < best {
+				best = d
+				hit.Triangle, hit.Point, ok = t, point, true
+			}
+		}
+	}
+	hit.Distance = float32(math.Sqrt(float64(best)))
+	return hit, ok
+}
+
+// rayIntersectsBox checks if the ray crosses the box before maxDistance (slab test)
+func rayIntersectsBox(origin, invDir Vec3, box Box, maxDistance float32) bool {
+	tMin, tMax := float32(0), maxDistance
+	for i := range origin {
+		t1 := (box.Min[i] - origin[i]) * invDir[i]
+		t2 := (box.Max[i] - origin[i]) * invDir[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		//A NaN (origin on the slab plane with a parallel direction) fails both comparisons and is ignored
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return false
+		}
+	}
+	return true
+}
+
+// rayIntersectsTriangle computes the distance along r to triangle abc (Möller–Trumbore)
+func rayIntersectsTriangle(r Ray, a, b, c Vec3) (distance float32, ok bool) {
+	const epsilon = 1e-9
+	ab, ac := Vec3(b).Sub(a), Vec3(c).Sub(a)
+	p := r.Direction.Cross(ac)
+	det := ab.Dot(p)
+	if det > -epsilon && det < epsilon {
+		//The ray is parallel to the triangle
+		return 0, false
+	}
+	invDet := 1 / det
+	s := r.Origin.Sub(a)
+	u := s.Dot(p) * invDet
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	q := s.Cross(ab)
+	v := r.Direction.Dot(q) * invDet
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+	distance = ac.Dot(q) * invDet
+	if distance < 0 {
+		return 0, false
+	}
+	return distance, true
+}
@@ -0,0 +1,68 @@
+package model
+
+// modelCache keeps the properties of a model computed by scanning all its triangles
+type modelCache struct {
+	//Which of the properties were computed
+	hasBounds, hasArea, hasVolume bool
+	bounds                        Box
+	area, volume                  float32
+}
+
+// cached returns the cache of the model, a new one when it was invalidated since it was filled
+func (m *Model) cached() *modelCache {
+	if m.cache == nil {
+		m.cache = &modelCache{}
+	}
+	return m.cache
+}
+
+// Invalidate drops the cached Bounds, SurfaceArea and Volume of the model. Any change to the
+// triangles made outside of this package, be it replacing them, adding, removing or editing them in
+// place, must be followed by a call to Invalidate. The functions of this package changing a model do
+// it already.
+func (m *Model) Invalidate() {
+	m.cache = nil
+}
+
+// Bounds returns the box around the model, empty when it has no triangles. It is only computed once
+// until the model changes, see Invalidate.
+func (m *Model) Bounds() Box {
+	cache := m.cached()
+	if !cache.hasBounds {
+		cache.bounds = EmptyBox()
+		if len(m.Triangles) > 0 {
+			mins, maxs := getMinsMaxs(m)
+			cache.bounds = Box{Min: mins, Max: maxs}
+		}
+		cache.hasBounds = true
+	}
+	return cache.bounds
+}
+
+// Dimensions returns the size of the model along each axis, zero when it has no triangles
+func (m *Model) Dimensions() Vec3 {
+	if len(m.Triangles) == 0 {
+		return Vec3{}
+	}
+	return m.Bounds().Size()
+}
+
+// SurfaceArea returns the total area of the triangles like the SurfaceArea function, only computing
+// it once until the model changes
+func (m *Model) SurfaceArea() float32 {
+	cache := m.cached()
+	if !cache.hasArea {
+		cache.area, cache.hasArea = SurfaceArea(m), true
+	}
+	return cache.area
+}
+
+// Volume returns the volume enclosed like the Volume function, only computing it once until the model
+// changes
+func (m *Model) Volume() float32 {
+	cache := m.cached()
+	if !cache.hasVolume {
+		cache.volume, cache.hasVolume = Volume(m), true
+	}
+	return cache.volume
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Perspective orients the projection of a model. ProjectFrom gives the axis aligned views and
+// Camera any other angle.
+type Perspective interface {
+	//Rotation from model coordinates to view coordinates, whose rows are the vertical axis of the
+	//matrix, the horizontal axis and the axis pointing to the viewer (the value)
+	ViewRotation() Mat3
+}
+
+// Camera looks at the model from any angle, with Z being up. All angles are in degrees.
+// The zero Camera is a front view looking from -Y towards +Y.
+type Camera struct {
+	//Turns the viewer counter-clockwise around the model (seen from the top), 90 looks from +X
+	Yaw float64 `json:"yaw"`
+	//Raises the viewer above the model, 90 looks straight down
+	Pitch float64 `json:"pitch"`
+	//Tilts the camera clockwise around the view direction (so the image turns the other way)
+	Roll float64 `json:"roll,omitempty"`
+}
+
+// LookAt returns the camera looking from eye towards target
+func LookAt(eye, target Vec3) Camera {
+	direction := eye.Sub(target)
+	horizontal := math.Hypot(float64(direction[0]), float64(direction[1]))
+	return Camera{
+		Yaw:   degrees(math.Atan2(float64(direction[0]), -float64(direction[1]))),
+		Pitch: degrees(math.Atan2(float64(direction[2]), horizontal)),
+	}
+}
+
+// ParseCamera reads a camera from "yaw,pitch[,roll]"
+func ParseCamera(s string) (c Camera, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return c, errors.New("Camera must be yaw,pitch or yaw,pitch,roll")
+	}
+	angles := [3]float64{}
+	for i := range parts {
+		angles[i], err = strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+		if err != nil {
+			return c, err
+		}
+	}
+	return Camera{Yaw: angles[0], Pitch: angles[1], Roll: angles[2]}, nil
+}
+
+// Axes returns the directions of the right, up and towards the viewer axis of the image in model coordinates
+func (c Camera) Axes() (right, up, toViewer Vec3) {
+	yaw, pitch, roll := radians(c.Yaw), radians(c.Pitch), radians(c.Roll)
+	toViewer = Vec3{
+		float32(math.Sin(yaw) * math.Cos(pitch)),
+		float32(-math.Cos(yaw) * math.Cos(pitch)),
+		float32(math.Sin(pitch)),
+	}
+	right = Vec3{float32(math.Cos(yaw)), float32(math.Sin(yaw)), 0}
+	up = toViewer.Cross(right)
+	//Roll both image axes around the view direction
+	sin, cos := float32(math.Sin(roll)), float32(math.Cos(roll))
+	right, up = right.Scale(cos).Sub(up.Scale(sin)), up.Scale(cos).Add(right.Scale(sin))
+	return right, up, toViewer
+}
+
+// ViewRotation implements Perspective
+func (c Camera) ViewRotation() Mat3 {
+	right, up, toViewer := c.Axes()
+	return Mat3{up, right, toViewer}
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
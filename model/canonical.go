@@ -0,0 +1,77 @@
+package model
+
+import (
+	"cmp"
+	"sort"
+)
+
+// Canonicalize puts the model in a canonical form, so the same geometry is always written the same
+// way whatever order the program making it produced the triangles in: every triangle starts from its
+// smallest vertex, keeping the winding, the triangles are sorted by their vertices (then normals and
+// attributes) and negative zeros become zeros. Exports of the canonical model are byte for byte the
+// same across runs, and their diffs only show what really changed.
+func Canonicalize(m *Model) {
+	m.Invalidate()
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		//Adding zero turns negative zeros into zeros and leaves anything else as it is
+		for j := range t.Vertices {
+			for k := range t.Vertices[j] {
+				t.Vertices[j][k] += 0
+			}
+		}
+		for k := range t.Normal {
+			t.Normal[k] += 0
+		}
+		first := 0
+		for j := 1; j < 3; j++ {
+			if compareVertices(t.Vertices[j], t.Vertices[first]) < 0 {
+				first = j
+			}
+		}
+		t.Vertices = [3][3]float32{t.Vertices[first], t.Vertices[(first+1)%3], t.Vertices[(first+2)%3]}
+	}
+	order := make([]int, len(m.Triangles))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return compareTriangles(&m.Triangles[order[a]], &m.Triangles[order[b]]) < 0
+	})
+	//Move the attributes with their triangles
+	triangles := make([]Triangle, len(order))
+	var attributes []Attributes
+	if m.Attributes != nil {
+		attributes = make([]Attributes, len(order))
+	}
+	for i, index := range order {
+		triangles[i] = m.Triangles[index]
+		if attributes != nil {
+			attributes[i] = m.TriangleAttributes(index)
+		}
+	}
+	m.Triangles, m.Attributes = triangles, attributes
+}
+
+// compareTriangles orders triangles by their vertices, then their normals and attributes
+func compareTriangles(a, b *Triangle) int {
+	for j := range a.Vertices {
+		if c := compareVertices(a.Vertices[j], b.Vertices[j]); c != 0 {
+			return c
+		}
+	}
+	if c := compareVertices(a.Normal, b.Normal); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.AttrByteCount, b.AttrByteCount)
+}
+
+// compareVertices orders vertices by X, then Y and Z, NaN before any number
+func compareVertices(a, b [3]float32) int {
+	for k := range a {
+		if c := cmp.Compare(a[k], b[k]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
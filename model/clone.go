@@ -0,0 +1,30 @@
+package model
+
+// Clone returns a copy of the model with its own triangles, so changing one doesn't change the other
+func (m *Model) Clone() *Model {
+	clone := &Model{Header: m.Header, Name: m.Name, NumTriangles: m.NumTriangles}
+	if m.Triangles != nil {
+		clone.Triangles = append(make([]Triangle, 0, len(m.Triangles)), m.Triangles...)
+	}
+	if m.Attributes != nil {
+		clone.Attributes = make([]Attributes, len(m.Attributes))
+		for i, a := range m.Attributes {
+			clone.Attributes[i] = a.clone()
+		}
+	}
+	return clone
+}
+
+// Subset returns a new model with a copy of the triangles at the given indices, in that order, keeping
+// the header and the name. It panics like indexing the triangles when an index is out of range.
+func (m *Model) Subset(indices []int) *Model {
+	subset := &Model{Header: m.Header, Name: m.Name, Triangles: make([]Triangle, len(indices))}
+	for i, index := range indices {
+		subset.Triangles[i] = m.Triangles[index]
+		if m.Attributes != nil {
+			subset.SetTriangleAttributes(i, m.TriangleAttributes(index).clone())
+		}
+	}
+	subset.NumTriangles = uint32(len(subset.Triangles))
+	return subset
+}
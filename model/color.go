@@ -0,0 +1,179 @@
+package model
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+)
+
+// Escape sequence going back to the default colors
+const colorReset = "\x1b[0m"
+
+// ColorMode is the color capability of the terminal used by DrawMatrixColor
+type ColorMode int
+
+const (
+	ColorNone ColorMode = iota
+	Color256
+	ColorTrue
+)
+
+// ParseColorMode converts a name (none, 256, true or auto) to a ColorMode, auto detecting it for f
+func ParseColorMode(name string, f *os.File) (ColorMode, error) {
+	switch name {
+	case "none":
+		return ColorNone, nil
+	case "256":
+		return Color256, nil
+	case "true", "truecolor", "24bit":
+		return ColorTrue, nil
+	case "auto":
+		return DetectColorMode(f), nil
+	}
+	return ColorNone, fmt.Errorf("Unknown color mode %q", name)
+}
+
+// DetectColorMode guesses the colors supported by the terminal behind f from the environment.
+// It returns ColorNone when f is not a terminal or NO_COLOR is set.
+func DetectColorMode(f *os.File) ColorMode {
+	if _, noColor := os.LookupEnv("NO_COLOR"); noColor {
+		return ColorNone
+	}
+	if !isTerminal(f) {
+		return ColorNone
+	}
+	colorTerm := strings.ToLower(os.Getenv("COLORTERM"))
+	if colorTerm == "truecolor" || colorTerm == "24bit" {
+		return ColorTrue
+	}
+	term := strings.ToLower(os.Getenv("TERM"))
+	switch {
+	case strings.Contains(term, "truecolor"), strings.Contains(term, "24bit"), strings.Contains(term, "direct"):
+		return ColorTrue
+	case strings.Contains(term, "256"):
+		return Color256
+	}
+	return ColorNone
+}
+
+// isTerminal checks if f is a character device, which is what a terminal looks like
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// DrawMatrixColor draws a matrix painting each cell with a background color for its value
+// instead of shade characters. ColorNone falls back to DrawMatrix.
+func DrawMatrixColor(matrix [][]float32, mode ColorMode) string {
+	return DrawMatrixColorHighlighted(matrix, nil, mode)
+}
+
+// DrawMatrixColorHighlighted draws a matrix like DrawMatrixColor, painting the cells set in the mask
+// (which may be nil) in red. ColorNone falls back to the block palette marking them with X.
+func DrawMatrixColorHighlighted(matrix [][]float32, mask [][]bool, mode ColorMode) string {
+	var builder strings.Builder
+	RenderMatrixColorHighlighted(&builder, matrix, mask, mode)
+	return builder.String()
+}
+
+// DrawMatrixColorMap draws a matrix like DrawMatrixColor, painting each cell with the color of its
+// value in the map. ColorNone falls back to DrawMatrix.
+func DrawMatrixColorMap(matrix [][]float32, colorMap ColorMap, mode ColorMode) string {
+	var builder strings.Builder
+	RenderMatrixColorMap(&builder, matrix, colorMap, mode)
+	return builder.String()
+}
+
+// RenderMatrixColor writes what DrawMatrixColor draws, a row at a time
+func RenderMatrixColor(w io.Writer, matrix [][]float32, mode ColorMode) error {
+	return RenderMatrixColorHighlighted(w, matrix, nil, mode)
+}
+
+// RenderMatrixColorHighlighted writes what DrawMatrixColorHighlighted draws, a row at a time
+func RenderMatrixColorHighlighted(w io.Writer, matrix [][]float32, mask [][]bool, mode ColorMode) error {
+	if mode == ColorNone {
+		return RenderMatrixPaletteHighlighted(w, matrix, mask, BlockPalette, 'X')
+	}
+	return renderColorRows(w, matrix, func(i, j int) string {
+		if matrix[i][j] <= 0 {
+			return colorReset
+		}
+		return colorCode(matrix[i][j], mode, highlighted(mask, i, j))
+	})
+}
+
+// RenderMatrixColorMap writes what DrawMatrixColorMap draws, a row at a time
+func RenderMatrixColorMap(w io.Writer, matrix [][]float32, colorMap ColorMap, mode ColorMode) error {
+	if mode == ColorNone {
+		return RenderMatrix(w, matrix)
+	}
+	return renderColorRows(w, matrix, func(i, j int) string {
+		if matrix[i][j] <= 0 {
+			return colorReset
+		}
+		return rgbCode(colorMap(matrix[i][j]), mode)
+	})
+}
+
+// renderColorRows writes a space per cell of the rows painted with the escape sequence of code,
+// which is only emitted when the color changes
+func renderColorRows[T any](w io.Writer, cells [][]T, code func(i, j int) string) error {
+	rows := rowWriter{w: w}
+	for i := range cells {
+		current := colorReset
+		for j := range cells[i] {
+			if c := code(i, j); c != current {
+				rows.row = append(rows.row, c...)
+				current = c
+			}
+			rows.row = append(rows.row, ' ')
+		}
+		//Reset before the new row so the color doesn't bleed to the end of the line
+		rows.row = append(rows.row, colorReset...)
+		rows.end()
+	}
+	return rows.err
+}
+
+// rgbCode returns the escape sequence setting the background to a color, the closest one of the 6x6x6
+// color cube in Color256 mode
+func rgbCode(c color.RGBA, mode ColorMode) string {
+	if mode == Color256 {
+		cube := func(level uint8) int { return (int(level)*5 + 127) / 255 }
+		return fmt.Sprintf("\x1b[48;5;%dm", 16+36*cube(c.R)+6*cube(c.G)+cube(c.B))
+	}
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", c.R, c.G, c.B)
+}
+
+// colorCode returns the escape sequence setting the background for a value between 0 and 1, in
+// gray or in red for highlighted cells
+func colorCode(value float32, mode ColorMode, highlight bool) string {
+	intensity := grayIntensity(value)
+	if mode == Color256 {
+		if highlight {
+			//The reds of the 256 color cube go from 52 to 196 in steps of 36
+			return fmt.Sprintf("\x1b[48;5;%dm", 52+36*int(intensity*4+0.5))
+		}
+		//The grayscale ramp of the 256 color palette goes from 232 to 255
+		return fmt.Sprintf("\x1b[48;5;%dm", 232+int(intensity*23+0.5))
+	}
+	level := int(intensity*255 + 0.5)
+	if highlight {
+		return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", level, level/4, level/4)
+	}
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", level, level, level)
+}
+
+// grayIntensity maps a value between 0 and 1 to a gray level, keeping the far end of the model
+// visible against a dark background
+func grayIntensity(value float32) float32 {
+	if value > 1 {
+		value = 1
+	}
+	return 0.2 + 0.8*value
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+)
+
+// DistinctColors are easily told apart from each other, given in order to the parts of a render
+var DistinctColors = []color.RGBA{
+	{230, 80, 70, 255}, {80, 160, 230, 255}, {110, 200, 90, 255}, {240, 190, 60, 255},
+	{170, 110, 220, 255}, {70, 200, 190, 255}, {240, 140, 190, 255}, {200, 200, 200, 255},
+}
+
+// ColorBy is what decides the color of each triangle in TriangleColors
+type ColorBy int
+
+const (
+	//The connected piece the triangle belongs to
+	ColorByShell ColorBy = iota
+	//The color attribute of the triangle
+	ColorByAttribute
+	//The material attribute of the triangle
+	ColorByMaterial
+	//The region attribute of the triangle
+	ColorByRegion
+)
+
+// ParseColorBy converts a name (shell, color, material or region) to a ColorBy
+func ParseColorBy(name string) (ColorBy, error) {
+	switch name {
+	case "shell":
+		return ColorByShell, nil
+	case "color":
+		return ColorByAttribute, nil
+	case "material":
+		return ColorByMaterial, nil
+	case "region":
+		return ColorByRegion, nil
+	}
+	return ColorByShell, fmt.Errorf("Unknown color by %q", name)
+}
+
+// TriangleColors gives every triangle of the model a color. Shells, materials and regions take the
+// DistinctColors in turn, shells in the order of their first triangle and materials and regions in
+// increasing order. Triangles without the attribute are transparent.
+func TriangleColors(m *Model, by ColorBy) []color.RGBA {
+	colors := make([]color.RGBA, len(m.Triangles))
+	distinct := func(i int) color.RGBA { return DistinctColors[i%len(DistinctColors)] }
+	switch by {
+	case ColorByShell:
+		for shell, faces := range NewTopology(m).Shells() {
+			for _, face := range faces {
+				colors[face] = distinct(shell)
+			}
+		}
+	case ColorByAttribute:
+		for i := range colors {
+			colors[i] = m.TriangleAttributes(i).Color
+		}
+	case ColorByMaterial:
+		var materials []int
+		seen := make(map[int]bool)
+		for i := range m.Attributes {
+			if material := m.Attributes[i].Material; material != 0 && !seen[material] {
+				seen[material] = true
+				materials = append(materials, material)
+			}
+		}
+		sort.Ints(materials)
+		for i := range m.Attributes {
+			if material := m.Attributes[i].Material; material != 0 {
+				colors[i] = distinct(sort.SearchInts(materials, material))
+			}
+		}
+	case ColorByRegion:
+		var regions []string
+		seen := make(map[string]bool)
+		for i := range m.Attributes {
+			if region := m.Attributes[i].Region; region != "" && !seen[region] {
+				seen[region] = true
+				regions = append(regions, region)
+			}
+		}
+		sort.Strings(regions)
+		for i := range m.Attributes {
+			if region := m.Attributes[i].Region; region != "" {
+				colors[i] = distinct(sort.SearchStrings(regions, region))
+			}
+		}
+	}
+	return colors
+}
+
+// RenderColored shades the model like RasterizeShaded, painting each triangle with its color or in
+// gray if it is transparent. Empty cells are transparent.
+func RenderColored(m *Model, viewport Viewport, perspective Perspective, opts RenderOptions, light Lighting, colors []color.RGBA) [][]color.RGBA {
+	buffer := RasterizeDepthBuffer(m, viewport, perspective, opts)
+	shaded := ShadeDepthBuffer(m, buffer, perspective, light)
+	painted := make([][]color.RGBA, len(shaded))
+	for i := range shaded {
+		painted[i] = make([]color.RGBA, len(shaded[i]))
+		for j, t := range buffer.Triangles[i] {
+			if t < 0 {
+				continue
+			}
+			c := color.RGBA{255, 255, 255, 255}
+			if t < len(colors) && colors[t].A != 0 {
+				c = colors[t]
+			}
+			intensity := grayIntensity(shaded[i][j])
+			painted[i][j] = color.RGBA{
+				uint8(float32(c.R)*intensity + 0.5), uint8(float32(c.G)*intensity + 0.5), uint8(float32(c.B)*intensity + 0.5), 255,
+			}
+		}
+	}
+	return painted
+}
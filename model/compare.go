@@ -0,0 +1,101 @@
+package model
+
+import "fmt"
+
+// Comparison summarizes how a model B differs from a model A
+type Comparison struct {
+	A Info `json:"a"`
+	B Info `json:"b"`
+	//Changes from A to B
+	TrianglesDelta   int     `json:"trianglesDelta"`
+	MinsDelta        Vec3    `json:"minsDelta"`
+	MaxsDelta        Vec3    `json:"maxsDelta"`
+	DimensionsDelta  Vec3    `json:"dimensionsDelta"`
+	SurfaceAreaDelta float32 `json:"surfaceAreaDelta"`
+	VolumeDelta      float32 `json:"volumeDelta"`
+	//How far apart the surfaces are
+	Distance DistanceMetrics `json:"distance"`
+}
+
+// Compare inspects both models and measures the distance between their surfaces, sampling samples
+// points on each one (see SurfaceDistance)
+func Compare(a, b *Model, samples int) (c Comparison) {
+	c.A, c.B = Inspect(a), Inspect(b)
+	c.TrianglesDelta = c.B.Triangles - c.A.Triangles
+	c.MinsDelta = c.B.Mins.Sub(c.A.Mins)
+	c.MaxsDelta = c.B.Maxs.Sub(c.A.Maxs)
+	c.DimensionsDelta = c.B.Dimensions.Sub(c.A.Dimensions)
+	c.SurfaceAreaDelta = c.B.SurfaceArea - c.A.SurfaceArea
+	c.VolumeDelta = c.B.Volume - c.A.Volume
+	c.Distance = SurfaceDistance(a, b, samples)
+	return c
+}
+
+// String prints the values of both models with the change between them, then the distances
+func (c Comparison) String() string {
+	return fmt.Sprintf("Triangles: %v -> %v (%+d)\nDimensions: %v -> %v (%v)\nMins: %v -> %v (%v)\nMaxs: %v -> %v (%v)\n"+
+		"Surface area: %v -> %v (%+g)\nVolume: %v -> %v (%+g)\nWatertight: %v -> %v\n"+
+		"Hausdorff distance: %v\nMax distance A to B: %v\nMax distance B to A: %v\nMean distance: %v\nRMS distance: %v\n",
+		c.A.Triangles, c.B.Triangles, c.TrianglesDelta, c.A.Dimensions, c.B.Dimensions, c.DimensionsDelta,
+		c.A.Mins, c.B.Mins, c.MinsDelta, c.A.Maxs, c.B.Maxs, c.MaxsDelta,
+		c.A.SurfaceArea, c.B.SurfaceArea, c.SurfaceAreaDelta, c.A.Volume, c.B.Volume, c.VolumeDelta, c.A.Watertight, c.B.Watertight,
+		c.Distance.Hausdorff, c.Distance.MaxAToB, c.Distance.MaxBToA, c.Distance.Mean, c.Distance.RMS)
+}
+
+// RasterizePair fills the triangles of both models like RasterizeModelTriangles, fitting the two of
+// them in the same frame so their sizes and positions can be compared side by side
+func RasterizePair(a, b *Model, viewport Viewport, perspective Perspective, opts RenderOptions) (matrixA, matrixB [][]float32) {
+	p := newPairProjection(a, b, viewport, perspective)
+	return p.rasterize(a, opts).Depth, p.rasterize(b, opts).Depth
+}
+
+// DeviationPair draws each model in the same frame with the distance of its visible surface to the
+// other model, from 0 where they match to 1 at maxDistance or more (0 uses the Hausdorff distance)
+func DeviationPair(a, b *Model, viewport Viewport, perspective Perspective, maxDistance float32) (matrixA, matrixB [][]float32) {
+	if maxDistance <= 0 {
+		maxDistance = SurfaceDistance(a, b, 0).Hausdorff
+	}
+	p := newPairProjection(a, b, viewport, perspective)
+	bvhA, bvhB := NewBVH(a), NewBVH(b)
+	return p.deviation(a, bvhB, maxDistance), p.deviation(b, bvhA, maxDistance)
+}
+
+// newPairProjection prepares a projection fitting both models
+func newPairProjection(a, b *Model, viewport Viewport, perspective Perspective) projection {
+	both := Model{Triangles: make([]Triangle, 0, len(a.Triangles)+len(b.Triangles))}
+	both.Triangles = append(append(both.Triangles, a.Triangles...), b.Triangles...)
+	return newProjection(&both, viewport, perspective)
+}
+
+// deviation replaces the depth of every visible cell of m with its distance to the target
+func (p projection) deviation(m *Model, target *BVH, maxDistance float32) [][]float32 {
+	buffer := p.rasterize(m, RenderOptions{})
+	for row := range buffer.Depth {
+		for col := range buffer.Depth[row] {
+			if buffer.Triangles[row][col] < 0 {
+				continue
+			}
+			//Keep matching surfaces above 0 so they are still drawn
+			value := float32(0.001)
+			if hit, ok := target.Nearest(p.unproject(row, col, buffer.Depth[row][col])); ok && maxDistance > 0 {
+				value += hit.Distance / maxDistance
+			}
+			if value > 1 {
+				value = 1
+			}
+			buffer.Depth[row][col] = value
+		}
+	}
+	return buffer.Depth
+}
+
+// unproject returns the model point at the center of a cell with the given depth value
+func (p projection) unproject(row, col int, value float32) Vec3 {
+	view := Vec3{
+		p.mins[0] + (p.height-(float32(row)+0.5)*p.viewport.aspect())*p.scale,
+		p.mins[1] + (float32(col)+0.5)*p.scale,
+		p.mins[2] + value*p.dimensions[2],
+	}
+	//The rotation is orthonormal, so the transpose takes it back
+	return p.rotation.Transpose().MulVec(view)
+}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"errors"
+	"math"
+)
+
+// ConvexDecompositionOptions tunes ConvexDecomposition
+type ConvexDecompositionOptions struct {
+	//Size of the voxels the solid is approximated with, 1/32 of the longest side of the model if 0
+	//(but never so small that the longest side takes more than maxFieldCells voxels)
+	VoxelSize float32
+	//Largest volume the hull of a piece may add to the piece, as a fraction of the volume of the
+	//model, 0.02 if 0
+	Concavity float32
+	//Largest number of pieces, 16 if 0
+	MaxHulls int
+}
+
+// Number of planes tried along each axis when splitting a piece
+const convexSplitCandidates = 8
+
+// convexPiece is a set of voxels of the solid, by their indices along each axis
+type convexPiece struct {
+	voxels [][3]int
+	//Volume of the hull of the voxels minus the volume of the voxels
+	concavity float32
+}
+
+// ConvexDecomposition approximates the watertight model with convex models, for collision shapes.
+// The solid is turned into voxels and split in two with the axis aligned plane that makes the hulls
+// of both halves fit them best, again and again for the piece whose hull is the worst fit, until
+// they all fit within the concavity or there are MaxHulls of them. The hulls cover whole voxels, so
+// they stick out of the model by up to a voxel.
+func ConvexDecomposition(m *Model, opts ConvexDecompositionOptions) (hulls []*Model, err error) {
+	if opts.VoxelSize < 0 || opts.Concavity < 0 || opts.MaxHulls < 0 {
+		return nil, errors.New("Voxel size, concavity and number of hulls can't be negative")
+	}
+	if !NewTopology(m).IsWatertight() {
+		return nil, errors.New("Model must be watertight to be decomposed")
+	}
+	concavity, maxHulls := opts.Concavity, opts.MaxHulls
+	if concavity == 0 {
+		concavity = 0.02
+	}
+	if maxHulls == 0 {
+		maxHulls = 16
+	}
+	bvh := NewBVH(m)
+	size := bvh.Bounds().Size()
+	cell := opts.VoxelSize
+	if cell == 0 {
+		cell = float32(math.Max(float64(size[0]), math.Max(float64(size[1]), float64(size[2])))) / 32
+	}
+	cell = fieldCell(bvh.Bounds(), cell)
+	grid := newDistanceGrid(bvh.Bounds(), cell, 0)
+	bvh.signedDistance(grid)
+	//Every sample inside is the center of a voxel
+	var all convexPiece
+	for k := 0; k < grid.size[2]; k++ {
+		for j := 0; j < grid.size[1]; j++ {
+			for i := 0; i < grid.size[0]; i++ {
+				if grid.values[grid.index(i, j, k)] < 0 {
+					all.voxels = append(all.voxels, [3]int{i, j, k})
+				}
+			}
+		}
+	}
+	if len(all.voxels) == 0 {
+		return nil, errors.New("Model is too thin for the voxels")
+	}
+	maxConcavity := concavity * m.Volume()
+	all.concavity = grid.pieceConcavity(all.voxels)
+	pieces := []convexPiece{all}
+	for len(pieces) < maxHulls {
+		worst := 0
+		for i := range pieces {
+			if pieces[i].concavity > pieces[worst].concavity {
+				worst = i
+			}
+		}
+		if pieces[worst].concavity <= maxConcavity {
+			break
+		}
+		first, second, ok := grid.splitPiece(pieces[worst].voxels)
+		if !ok {
+			//Can't be split any more, don't try again
+			pieces[worst].concavity = -pieces[worst].concavity
+			continue
+		}
+		pieces[worst] = first
+		pieces = append(pieces, second)
+	}
+	for _, piece := range pieces {
+		hull, err := ConvexHull(grid.voxelCorners(piece.voxels))
+		if err != nil {
+			return nil, err
+		}
+		hull.Header = m.Header
+		hulls = append(hulls, hull)
+	}
+	return hulls, nil
+}
+
+// splitPiece cuts the voxels with the axis aligned plane whose halves have the least concavity
+func (g *distanceGrid) splitPiece(voxels [][3]int) (first, second convexPiece, ok bool) {
+	var mins, maxs [3]int
+	for k := range mins {
+		mins[k], maxs[k] = math.MaxInt32, math.MinInt32
+	}
+	for _, v := range voxels {
+		for k := range v {
+			if v[k] < mins[k] {
+				mins[k] = v[k]
+			}
+			if v[k] > maxs[k] {
+				maxs[k] = v[k]
+			}
+		}
+	}
+	best := float32(math.MaxFloat32)
+	for axis := range mins {
+		tried := make(map[int]bool)
+		for c := 1; c <= convexSplitCandidates; c++ {
+			//Voxels below the plane go to the first half
+			plane := mins[axis] + (maxs[axis]-mins[axis]+1)*c/(convexSplitCandidates+1)
+			if plane <= mins[axis] || plane > maxs[axis] || tried[plane] {
+				continue
+			}
+			tried[plane] = true
+			var below, above convexPiece
+			for _, v := range voxels {
+				if v[axis] < plane {
+					below.voxels = append(below.voxels, v)
+				} else {
+					above.voxels = append(above.voxels, v)
+				}
+			}
+			if len(below.voxels) == 0 || len(above.voxels) == 0 {
+				continue
+			}
+			below.concavity, above.concavity = g.pieceConcavity(below.voxels), g.pieceConcavity(above.voxels)
+			if cost := below.concavity + above.concavity; cost < best {
+				best, first, second, ok = cost, below, above, true
+			}
+		}
+	}
+	return first, second, ok
+}
+
+// pieceConcavity returns the volume the hull of the voxels adds to them
+func (g *distanceGrid) pieceConcavity(voxels [][3]int) float32 {
+	hull, err := ConvexHull(g.voxelCorners(voxels))
+	if err != nil {
+		return 0
+	}
+	return Volume(hull) - float32(len(voxels))*g.cell*g.cell*g.cell
+}
+
+// voxelCorners returns the corners of the voxels that could be on the hull of the set: a voxel with
+// others further along both ways of every axis can't
+func (g *distanceGrid) voxelCorners(voxels [][3]int) (corners []Vec3) {
+	//Lowest and highest voxel of every row along each axis, keyed by the axis and the other indices
+	lowest, highest := make(map[[3]int]int), make(map[[3]int]int)
+	rowOf := func(v [3]int, axis int) [3]int {
+		return [3]int{axis, v[(axis+1)%3], v[(axis+2)%3]}
+	}
+	for _, v := range voxels {
+		for axis := range v {
+			row := rowOf(v, axis)
+			if low, found := lowest[row]; !found || v[axis] < low {
+				lowest[row] = v[axis]
+			}
+			if high, found := highest[row]; !found || v[axis] > high {
+				highest[row] = v[axis]
+			}
+		}
+	}
+	half := Vec3{1, 1, 1}.Scale(g.cell / 2)
+	for _, v := range voxels {
+		extreme := false
+		for axis := range v {
+			row := rowOf(v, axis)
+			extreme = extreme || v[axis] == lowest[row] || v[axis] == highest[row]
+		}
+		if !extreme {
+			continue
+		}
+		low := g.point(v[0], v[1], v[2]).Sub(half)
+		for _, corner := range cellCorners {
+			corners = append(corners, low.Add(Vec3{float32(corner[0]), float32(corner[1]), float32(corner[2])}.Scale(g.cell)))
+		}
+	}
+	return corners
+}
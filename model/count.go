@@ -0,0 +1,44 @@
+package model
+
+import "fmt"
+
+// Append adds triangles at the end of the model, keeping NumTriangles and the attributes in step.
+// The new triangles have no attributes.
+func (m *Model) Append(triangles ...Triangle) {
+	m.Invalidate()
+	m.Triangles = append(m.Triangles, triangles...)
+	if m.Attributes != nil {
+		m.Attributes = growAttributes(m.Attributes, len(m.Triangles))
+	}
+	m.NumTriangles = uint32(len(m.Triangles))
+}
+
+// RemoveTriangles drops the triangles at the given indices, and their attributes, keeping the order
+// of the others and NumTriangles in step. Indices out of range are ignored. It returns how many were
+// removed.
+func (m *Model) RemoveTriangles(indices ...int) (removed int) {
+	m.Invalidate()
+	drop := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		drop[i] = true
+	}
+	return m.keepTriangles(func(i int, _ *Triangle) bool { return !drop[i] })
+}
+
+// SyncCount sets NumTriangles to the number of triangles, after changing the slice directly
+func (m *Model) SyncCount() {
+	m.NumTriangles = uint32(len(m.Triangles))
+}
+
+// CheckCount fails when NumTriangles doesn't match the number of triangles, or the attributes are
+// not one per triangle, which happens when the slices are changed directly without calling SyncCount.
+// The writers use the real number of triangles, but everything showing NumTriangles is wrong.
+func (m *Model) CheckCount() error {
+	if int64(m.NumTriangles) != int64(len(m.Triangles)) {
+		return fmt.Errorf("NumTriangles is %d but the model has %d triangles", m.NumTriangles, len(m.Triangles))
+	}
+	if m.Attributes != nil && len(m.Attributes) != len(m.Triangles) {
+		return fmt.Errorf("The model has %d triangles but %d attributes", len(m.Triangles), len(m.Attributes))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package model
+
+// Crop returns a new model with the triangles of m touching the box from min to max, dropping the
+// ones fully outside it. When clip is true the triangles crossing the faces of the box are cut along
+// them, keeping only their part inside. The result is left open where the box cuts the surface.
+func (m *Model) Crop(min, max Vec3, clip bool) *Model {
+	cropped := &Model{Header: m.Header, Name: m.Name}
+	box := Box{Min: min, Max: max}
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		if !box.Intersects(t.Bounds()) {
+			continue
+		}
+		polygon := []Vec3{t.Vertices[0], t.Vertices[1], t.Vertices[2]}
+		for axis := 0; axis < 3 && len(polygon) > 0; axis++ {
+			polygon = clipPolygon(polygon, func(v Vec3) float32 { return v[axis] - min[axis] })
+			polygon = clipPolygon(polygon, func(v Vec3) float32 { return max[axis] - v[axis] })
+		}
+		switch {
+		case len(polygon) < 3:
+			//Only touching the box on an edge or a corner, or missing it
+			continue
+		case clip:
+			cropped.Triangles = appendFan(cropped.Triangles, polygon, t)
+		default:
+			cropped.Triangles = append(cropped.Triangles, *t)
+		}
+		if m.Attributes != nil {
+			for j := len(cropped.Attributes); j < len(cropped.Triangles); j++ {
+				cropped.Attributes = append(cropped.Attributes, m.TriangleAttributes(i).clone())
+			}
+		}
+	}
+	cropped.NumTriangles = uint32(len(cropped.Triangles))
+	return cropped
+}
+
+// clipPolygon keeps the part of a convex polygon where distance is not negative, keeping its winding
+func clipPolygon(polygon []Vec3, distance func(v Vec3) float32) (clipped []Vec3) {
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		da, db := distance(a), distance(b)
+		if da >= 0 {
+			clipped = append(clipped, a)
+		}
+		if (da >= 0) != (db >= 0) {
+			clipped = append(clipped, a.Add(b.Sub(a).Scale(da/(da-db))))
+		}
+	}
+	return clipped
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvColumns names the columns of the rows written by WriteCSV
+var csvColumns = []string{"nx", "ny", "nz", "x1", "y1", "z1", "x2", "y2", "z2", "x3", "y3", "z3"}
+
+// WriteCSV writes one row per triangle with its normal and its three vertices, after a row naming the
+// columns (nx, ny, nz, x1, y1, z1... z3). The separator is comma, or tab for TSV files.
+func WriteCSV(w io.Writer, m *Model, separator rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = separator
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+	row := make([]string, len(csvColumns))
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		for k := 0; k < 3; k++ {
+			row[k] = formatFloat(t.Normal[k])
+			for j := range t.Vertices {
+				row[3+3*j+k] = formatFloat(t.Vertices[j][k])
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// CreateFromCSV reads the triangles written by WriteCSV, one per row. The separator is guessed from
+// the first line (tab if it has one, comma otherwise) when 0, and a first row that isn't numbers is
+// skipped as the names of the columns. Rows of 9 numbers are the vertices alone, the normals are then
+// computed from the winding.
+func CreateFromCSV(r io.Reader, separator rune) (m Model, err error) {
+	m.Header = "Imported from CSV by stl2ascii"
+	buffered := bufio.NewReader(r)
+	if separator == 0 {
+		first, _ := buffered.Peek(4096)
+		line := string(first)
+		if end := strings.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end]
+		}
+		separator = ','
+		if strings.ContainsRune(line, '\t') {
+			separator = '\t'
+		}
+	}
+	reader := csv.NewReader(buffered)
+	reader.Comma = separator
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, err
+		}
+		if len(record) != 12 && len(record) != 9 {
+			return m, fmt.Errorf("Row %d has %d columns instead of 12 (or 9 without the normal)", row, len(record))
+		}
+		values := make([]float32, len(record))
+		for i, field := range record {
+			value, err := strconv.ParseFloat(strings.TrimSpace(field), 32)
+			if err != nil {
+				if row == 1 {
+					//The names of the columns
+					values = nil
+					break
+				}
+				return m, fmt.Errorf("Invalid number %q on row %d", field, row)
+			}
+			values[i] = float32(value)
+		}
+		if values == nil {
+			continue
+		}
+		var t Triangle
+		offset := 0
+		if len(values) == 12 {
+			copy(t.Normal[:], values[:3])
+			offset = 3
+		}
+		for j := range t.Vertices {
+			copy(t.Vertices[j][:], values[offset+3*j:offset+3*j+3])
+		}
+		if len(values) == 9 {
+			t.Normal = triangleNormal(&t)
+		}
+		m.Triangles = append(m.Triangles, t)
+	}
+	m.NumTriangles = uint32(len(m.Triangles))
+	return m, nil
+}
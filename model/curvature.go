@@ -0,0 +1,169 @@
+package model
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// CurvatureKind chooses the curvature shown by RasterizeCurvature
+type CurvatureKind int
+
+const (
+	MeanCurvature CurvatureKind = iota
+	GaussianCurvature
+)
+
+// Curvature holds the estimated curvature at every vertex of a Topology
+type Curvature struct {
+	//Average of the principal curvatures, positive where the surface bulges outwards
+	Mean []float32
+	//Product of the principal curvatures, positive on domes and hollows and negative on saddles
+	Gaussian []float32
+}
+
+// Curvature estimates the curvature at every vertex from the faces around it: the mean curvature with
+// the cotangent Laplacian and the Gaussian one with the angle deficit, both over a third of the area
+// of those faces. Vertices on the border of holes or without area around them get 0.
+func (t *Topology) Curvature() (c Curvature) {
+	c.Mean, c.Gaussian = make([]float32, len(t.Vertices)), make([]float32, len(t.Vertices))
+	laplacians := make([][3]float64, len(t.Vertices))
+	normals := make([][3]float64, len(t.Vertices))
+	areas := make([]float64, len(t.Vertices))
+	angles := make([]float64, len(t.Vertices))
+	for _, face := range t.Faces {
+		var points [3][3]float64
+		for j := range face {
+			for k := range points[j] {
+				points[j][k] = float64(t.Vertices[face[j]][k])
+			}
+		}
+		normal := cross64(sub64(points[1], points[0]), sub64(points[2], points[0]))
+		doubleArea := math.Sqrt(dot64(normal, normal))
+		if doubleArea == 0 {
+			continue
+		}
+		for j := range face {
+			//The angle at this corner weights the opposite edge
+			p, q := (j+1)%3, (j+2)%3
+			u, v := sub64(points[p], points[j]), sub64(points[q], points[j])
+			cot := dot64(u, v) / doubleArea
+			edge := sub64(points[q], points[p])
+			for k := range edge {
+				laplacians[face[p]][k] += cot * edge[k]
+				laplacians[face[q]][k] -= cot * edge[k]
+				normals[face[j]][k] += normal[k]
+			}
+			areas[face[j]] += doubleArea / 6
+			angles[face[j]] += math.Atan2(math.Sqrt(dot64(cross64(u, v), cross64(u, v))), dot64(u, v))
+		}
+	}
+	border := make([]bool, len(t.Vertices))
+	for key, use := range t.edges {
+		if len(use.faces) == 1 {
+			border[key[0]], border[key[1]] = true, true
+		}
+	}
+	for i := range t.Vertices {
+		if border[i] || areas[i] == 0 {
+			continue
+		}
+		//The Laplacian is twice the mean curvature along the normal, pointing inwards on bulges
+		mean := math.Sqrt(dot64(laplacians[i], laplacians[i])) / (4 * areas[i])
+		if dot64(laplacians[i], normals[i]) > 0 {
+			mean = -mean
+		}
+		c.Mean[i] = float32(mean)
+		c.Gaussian[i] = float32((2*math.Pi - angles[i]) / areas[i])
+	}
+	return c
+}
+
+func sub64(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func dot64(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross64(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+// RasterizeCurvature projects the model like RasterizeModelTriangles, but each cell holds the chosen
+// curvature of its facet (the average of its vertices) instead of its depth. Values go from just above
+// 0 for the most concave facets to 1 for the most convex ones, flat ones being 0.5, and are meant to be
+// painted with a ColorMap. The scale ignores the sharpest 5% of the vertices so a few corners don't
+// wash out the rest.
+func RasterizeCurvature(m *Model, viewport Viewport, perspective Perspective, opts RenderOptions, kind CurvatureKind) [][]float32 {
+	buffer := RasterizeDepthBuffer(m, viewport, perspective, opts)
+	topology := NewTopology(m)
+	curvature := topology.Curvature()
+	values := curvature.Mean
+	if kind == GaussianCurvature {
+		values = curvature.Gaussian
+	}
+	var scale float32
+	if len(values) > 0 {
+		magnitudes := make([]float64, len(values))
+		for i := range values {
+			magnitudes[i] = math.Abs(float64(values[i]))
+		}
+		sort.Float64s(magnitudes)
+		scale = float32(magnitudes[len(magnitudes)*95/100])
+	}
+	matrix := make([][]float32, len(buffer.Triangles))
+	for i := range buffer.Triangles {
+		matrix[i] = make([]float32, len(buffer.Triangles[i]))
+		for j, t := range buffer.Triangles[i] {
+			if t < 0 {
+				continue
+			}
+			face := topology.Faces[t]
+			value := float32(0.5)
+			if scale > 0 {
+				value += (values[face[0]] + values[face[1]] + values[face[2]]) / (6 * scale)
+			}
+			//Keep painted cells distinguishable from empty ones
+			matrix[i][j] = float32(math.Max(0.01, math.Min(1, float64(value))))
+		}
+	}
+	return matrix
+}
+
+// ColorMap paints a value between 0 and 1
+type ColorMap func(value float32) color.RGBA
+
+// DivergingColorMap goes from blue for 0 to white for 0.5 and red for 1, showing how far values are
+// from the middle on either side
+func DivergingColorMap(value float32) color.RGBA {
+	value = float32(math.Max(0, math.Min(1, float64(value))))
+	if value < 0.5 {
+		level := uint8(value*2*255 + 0.5)
+		return color.RGBA{level, level, 255, 255}
+	}
+	level := uint8((1-value)*2*255 + 0.5)
+	return color.RGBA{255, level, level, 255}
+}
+
+// DrawMatrixImageColorMap paints a matrix as an image, one pixel per cell colored by the map. Empty
+// cells are painted with the background.
+func DrawMatrixImageColorMap(matrix [][]float32, colorMap ColorMap, background color.Color) *image.RGBA {
+	width := 0
+	if len(matrix) > 0 {
+		width = len(matrix[0])
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, len(matrix)))
+	for i := range matrix {
+		for j := range matrix[i] {
+			if matrix[i][j] <= 0 {
+				img.Set(j, i, background)
+				continue
+			}
+			img.Set(j, i, colorMap(matrix[i][j]))
+		}
+	}
+	return img
+}
@@ -0,0 +1,277 @@
+package model
+
+// Plane is the infinite plane through Point perpendicular to Normal
+type Plane struct {
+	Point Vec3
+	//Points towards the top side
+	Normal Vec3
+}
+
+// distance returns how far p is above the plane, in units of the length of the normal
+func (p Plane) distance(v Vec3) float32 {
+	return p.Normal.Dot(v.Sub(p.Point))
+}
+
+// Cut splits the model with the plane in the part above it (on the side the normal points to) and the
+// part below it, closing both with flat caps where the plane crosses the solid. Vertices lying on the
+// plane (closer than DefaultTolerances.Relative times the size of the model) count as above it. When
+// the model is watertight and consistently oriented, so are both parts. Either part is empty when the
+// plane misses the model. The pieces of the facets keep their attributes, the caps have none.
+func Cut(m *Model, plane Plane) (top, bottom *Model) {
+	return CutWith(m, plane, DefaultTolerances)
+}
+
+// CutWith is Cut taking the vertices closer to the plane than tolerances.Relative times the size of
+// the model as lying on it
+func CutWith(m *Model, plane Plane, tolerances Tolerances) (top, bottom *Model) {
+	top, bottom = &Model{Header: m.Header}, &Model{Header: m.Header}
+	if len(m.Triangles) == 0 {
+		return top, bottom
+	}
+	plane.Normal = plane.Normal.Normalize()
+	//Vertices closer than this to the plane are on it, crossing edges right next to them would leave
+	//slivers too thin for the caps
+	mins, maxs := getMinsMaxs(m)
+	epsilon := tolerances.Epsilon(Box{Min: mins, Max: maxs})
+	//Where the plane crosses an edge, interpolated from the same end whatever the facet so the
+	//crossings are exactly the same on both sides of the edge
+	crossing := func(a, b Vec3, da, db float32) Vec3 {
+		if da == 0 {
+			return a
+		}
+		if db == 0 {
+			return b
+		}
+		if lessFloat32s(b[:], a[:]) {
+			a, b, da, db = b, a, db, da
+		}
+		return a.Add(b.Sub(a).Scale(da / (da - db)))
+	}
+	//Edges of the cap of the bottom part, going counter-clockwise around it seen from above. An edge
+	//added both ways is no edge at all.
+	segments := make(map[Vec3][]Vec3)
+	addSegment := func(from, to Vec3) {
+		for i, end := range segments[to] {
+			if end == from {
+				segments[to] = append(segments[to][:i], segments[to][i+1:]...)
+				if len(segments[to]) == 0 {
+					delete(segments, to)
+				}
+				return
+			}
+		}
+		segments[from] = append(segments[from], to)
+	}
+	//Give the pieces of a facet added to a part the attributes of the facet
+	keepAttributes := func(part *Model, i int) {
+		if m.Attributes != nil {
+			for j := len(part.Attributes); j < len(part.Triangles); j++ {
+				part.Attributes = append(part.Attributes, m.TriangleAttributes(i).clone())
+			}
+		}
+	}
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		var distances [3]float32
+		above := 0
+		for j, v := range t.Vertices {
+			distances[j] = plane.distance(v)
+			if abs32(distances[j]) < epsilon {
+				distances[j] = 0
+			}
+			if distances[j] >= 0 {
+				above++
+			}
+		}
+		switch {
+		case distances == [3]float32{} && triangleNormal(t).Dot(plane.Normal) > 0:
+			//On the plane with the solid below: it caps the bottom part already, and the top part
+			//has nothing there
+			bottom.Triangles = append(bottom.Triangles, *t)
+			keepAttributes(bottom, i)
+			for j := range t.Vertices {
+				addSegment(t.Vertices[(j+1)%3], t.Vertices[j])
+			}
+			continue
+		case above == 3:
+			top.Triangles = append(top.Triangles, *t)
+			keepAttributes(top, i)
+			continue
+		case above == 0:
+			bottom.Triangles = append(bottom.Triangles, *t)
+			keepAttributes(bottom, i)
+			continue
+		}
+		//Walk around the facet keeping the winding, putting every vertex and crossing on its sides
+		var upper, lower []Vec3
+		//Position in lower of the crossing where the walk goes down, and of the one where it goes up
+		down, up := -1, -1
+		for j := range t.Vertices {
+			a, b := Vec3(t.Vertices[j]), Vec3(t.Vertices[(j+1)%3])
+			da, db := distances[j], distances[(j+1)%3]
+			if da >= 0 {
+				upper = append(upper, a)
+			} else {
+				lower = append(lower, a)
+			}
+			if (da >= 0) != (db >= 0) {
+				point := crossing(a, b, da, db)
+				upper = append(upper, point)
+				if da >= 0 {
+					down = len(lower)
+				} else {
+					up = len(lower)
+				}
+				lower = append(lower, point)
+			}
+		}
+		top.Triangles = appendFan(top.Triangles, upper, t)
+		bottom.Triangles = appendFan(bottom.Triangles, lower, t)
+		keepAttributes(top, i)
+		keepAttributes(bottom, i)
+		//The bottom piece goes along the plane from where it came up to where it goes down, the cap
+		//the other way
+		if from, to := lower[down], lower[up]; from != to {
+			addSegment(from, to)
+		}
+	}
+	for _, cap := range planarCap(segments, plane.Normal) {
+		bottom.Triangles = append(bottom.Triangles, cap)
+		cap.Vertices[1], cap.Vertices[2] = cap.Vertices[2], cap.Vertices[1]
+		cap.Normal = Vec3(cap.Normal).Scale(-1)
+		top.Triangles = append(top.Triangles, cap)
+	}
+	if m.Attributes != nil {
+		top.Attributes = growAttributes(top.Attributes, len(top.Triangles))
+		bottom.Attributes = growAttributes(bottom.Attributes, len(bottom.Triangles))
+	}
+	top.NumTriangles, bottom.NumTriangles = uint32(len(top.Triangles)), uint32(len(bottom.Triangles))
+	return top, bottom
+}
+
+// appendFan appends the triangles of a fan around the first point of a convex piece of the facet,
+// leaving out the ones with a repeated point. Slivers too thin to have an area in float32 are kept, their
+// edges are shared with the neighbors.
+func appendFan(triangles []Triangle, polygon []Vec3, facet *Triangle) []Triangle {
+	for i := 1; i+1 < len(polygon); i++ {
+		if polygon[0] == polygon[i] || polygon[i] == polygon[i+1] || polygon[i+1] == polygon[0] {
+			continue
+		}
+		t := Triangle{Normal: facet.Normal, Vertices: [3][3]float32{polygon[0], polygon[i], polygon[i+1]}, AttrByteCount: facet.AttrByteCount}
+		t.Normal = triangleNormal(&t)
+		triangles = append(triangles, t)
+	}
+	return triangles
+}
+
+// planarCap chains the segments in closed loops on a plane and fills them with triangles facing
+// along the normal, the loops going clockwise seen from there being holes in the others
+func planarCap(segments map[Vec3][]Vec3, normal Vec3) (triangles []Triangle) {
+	//Right handed directions on the plane
+	helper := Vec3{1, 0, 0}
+	if abs32(normal[0]) > 0.9 {
+		helper = Vec3{0, 1, 0}
+	}
+	u := helper.Cross(normal).Normalize()
+	v := normal.Cross(u)
+	flatten := func(loop []Vec3) []Vec2 {
+		polygon := make([]Vec2, len(loop))
+		for i, p := range loop {
+			polygon[i] = Vec2{p.Dot(u), p.Dot(v)}
+		}
+		return polygon
+	}
+	type loop struct {
+		points  []Vec3
+		polygon []Vec2
+		area    float32
+		holes   []int
+	}
+	var loops []loop
+	for len(segments) > 0 {
+		//Start from the lowest point so the result doesn't depend on the map order
+		var start Vec3
+		first := true
+		for p := range segments {
+			if first || lessFloat32s(p[:], start[:]) {
+				start, first = p, false
+			}
+		}
+		points := []Vec3{start}
+		closed := false
+		for current := start; ; {
+			ends := segments[current]
+			if len(ends) == 0 {
+				//Open chain, the model has a hole here
+				break
+			}
+			next := ends[len(ends)-1]
+			if len(ends) == 1 {
+				delete(segments, current)
+			} else {
+				segments[current] = ends[:len(ends)-1]
+			}
+			if next == start {
+				closed = true
+				break
+			}
+			points = append(points, next)
+			current = next
+		}
+		if !closed || len(points) < 3 {
+			continue
+		}
+		polygon := flatten(points)
+		loops = append(loops, loop{points: points, polygon: polygon, area: PolygonArea(polygon)})
+	}
+	//Every hole belongs to the smallest outer loop around it
+	for h := range loops {
+		if loops[h].area >= 0 {
+			continue
+		}
+		outer := -1
+		for o := range loops {
+			if loops[o].area > 0 && pointInPolygon(loops[h].polygon[0], loops[o].polygon) && (outer < 0 || loops[o].area < loops[outer].area) {
+				outer = o
+			}
+		}
+		if outer >= 0 {
+			loops[outer].holes = append(loops[outer].holes, h)
+		}
+	}
+	for _, l := range loops {
+		if l.area <= 0 {
+			continue
+		}
+		points := append([]Vec3(nil), l.points...)
+		var holes [][]Vec2
+		for _, h := range l.holes {
+			points = append(points, loops[h].points...)
+			holes = append(holes, loops[h].polygon)
+		}
+		indices, err := TriangulateWithHoles(l.polygon, holes)
+		if err != nil && len(holes) == 0 {
+			//Like the holes filled by Repair, fall back to a fan
+			indices = indices[:0]
+			for i := 1; i+1 < len(l.points); i++ {
+				indices = append(indices, [3]int{0, i, i + 1})
+			}
+		}
+		for _, index := range indices {
+			t := Triangle{Vertices: [3][3]float32{points[index[0]], points[index[1]], points[index[2]]}, Normal: normal}
+			triangles = append(triangles, t)
+		}
+	}
+	return triangles
+}
+
+// pointInPolygon checks if p is inside the polygon by the parity of the edges crossed going right
+func pointInPolygon(p Vec2, polygon []Vec2) (inside bool) {
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < a[0]+(p[1]-a[1])/(b[1]-a[1])*(b[0]-a[0]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+)
+
+// DecodeOptions tunes CreateFromSTLBytesWith
+type DecodeOptions struct {
+	//What to do with the facets having NaN or infinite numbers, which poison the bounds and the
+	//projections of the whole model
+	NonFinite NonFinitePolicy
+	//Fail on anything a warning would be given for, instead of reading what can be read
+	Strict bool
+	//Byte order of the numbers of binary files
+	ByteOrder ByteOrder
+	//When the triangle count in the header of a binary file doesn't match its size, read every
+	//triangle the file has room for instead of trusting the count, for headers filled with junk
+	Resync bool
+	//Tolerances telling the normals not matching their winding, DefaultTolerances for the fields
+	//left at 0
+	Tolerances Tolerances
+}
+
+// ByteOrder is how the numbers of a binary STL are stored
+type ByteOrder int
+
+const (
+	//Least significant byte first, as the format says
+	LittleEndianSTL ByteOrder = iota
+	//Most significant byte first, written by some legacy tools
+	BigEndianSTL
+	//Little endian unless only big endian gives a triangle count matching the size of the file, or
+	//numbers that make sense
+	DetectByteOrder
+)
+
+// DecodeWarning is something off in a file that didn't stop it from being read
+type DecodeWarning struct {
+	Message string `json:"message"`
+	//Facets concerned, when it can be pinned down to some
+	Facets []int `json:"facets,omitempty"`
+}
+
+// String returns the message
+func (w DecodeWarning) String() string {
+	return w.Message
+}
+
+// CreateFromSTLBytesWith reads an ASCII or binary STL from memory like CreateFromSTLBytes, then
+// applies the options. It reads as much as it can, returning warnings for what was off: a triangle
+// count not matching the size of a binary file, text left after an ASCII solid or a facet that could
+// not be read, normals not matching the winding and NaN or infinite numbers. With opts.Strict the
+// first of them is returned as an error instead.
+func CreateFromSTLBytesWith(data []byte, opts DecodeOptions) (m Model, warnings []DecodeWarning, err error) {
+	warn := func(facets []int, format string, args ...interface{}) {
+		warnings = append(warnings, DecodeWarning{Message: fmt.Sprintf(format, args...), Facets: facets})
+	}
+	if bytes.HasPrefix(data, []byte("solid")) {
+		reader := bufio.NewReader(bytes.NewReader(data))
+		var asciiErr error
+		m, asciiErr = CreateFromASCIISTL(reader)
+		if len(m.Triangles) > 0 {
+			rest, _ := ioutil.ReadAll(reader)
+			rest = bytes.TrimSpace(rest)
+			switch {
+			case asciiErr != nil:
+				warn(nil, "Stopped reading after %v facets: %v", len(m.Triangles), asciiErr)
+			case bytes.HasPrefix(rest, []byte("solid")):
+				warn(nil, "More solids follow the first one, only it was read")
+			case len(rest) > 0:
+				warn(nil, "%v bytes after the last facet were not read", len(rest))
+			case !endsWithEndsolid(data):
+				warn(nil, "The solid has no endsolid")
+			}
+		} else {
+			m = Model{}
+		}
+	}
+	if len(m.Triangles) == 0 {
+		if len(data) < binaryHeaderSize+4 {
+			return Model{}, warnings, errors.New("Truncated binary STL")
+		}
+		order := binaryByteOrder(data, opts.ByteOrder)
+		if opts.ByteOrder == DetectByteOrder && order == binary.BigEndian {
+			warn(nil, "The numbers are big endian")
+		}
+		count := uint64(order.Uint32(data[binaryHeaderSize:]))
+		available := uint64(len(data)-binaryHeaderSize-4) / binaryTriangleSize
+		switch {
+		case available < count:
+			warn(nil, "The header announces %v triangles but there is room for %v", count, available)
+			count = available
+		case opts.Resync && available > count:
+			warn(nil, "The header announces %v triangles but there is room for %v, reading them all", count, available)
+			count = available
+		case uint64(len(data)-binaryHeaderSize-4) > binaryTriangleSize*count:
+			warn(nil, "%v bytes after the triangles were not read", uint64(len(data)-binaryHeaderSize-4)-binaryTriangleSize*count)
+		}
+		decodeBinarySTL(&m, data, int(count), order)
+	}
+
+	var badNormals []int
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		if t.Normal != [3]float32{} && finiteTriangle(t) && !opts.Tolerances.NormalMatches(t) {
+			badNormals = append(badNormals, i)
+		}
+	}
+	if len(badNormals) > 0 {
+		warn(badNormals, "%v facets have normals not matching their winding", len(badNormals))
+	}
+	nonFinite, err := ApplyNonFinitePolicy(&m, opts.NonFinite)
+	if err != nil {
+		return m, warnings, err
+	}
+	if len(nonFinite) > 0 {
+		warn(nonFinite, "%v facets have NaN or infinite numbers", len(nonFinite))
+	}
+	if opts.Strict && len(warnings) > 0 {
+		return m, warnings, errors.New(warnings[0].Message)
+	}
+	return m, warnings, nil
+}
+
+// binaryByteOrder returns the byte order of a binary STL of at least 84 bytes, telling it when asked to
+func binaryByteOrder(data []byte, order ByteOrder) binary.ByteOrder {
+	switch order {
+	case LittleEndianSTL:
+		return binary.LittleEndian
+	case BigEndianSTL:
+		return binary.BigEndian
+	}
+	payload := uint64(len(data) - binaryHeaderSize - 4)
+	little, big := uint64(binary.LittleEndian.Uint32(data[binaryHeaderSize:])), uint64(binary.BigEndian.Uint32(data[binaryHeaderSize:]))
+	switch {
+	case little*binaryTriangleSize == payload:
+		return binary.LittleEndian
+	case big*binaryTriangleSize == payload:
+		return binary.BigEndian
+	}
+	//Neither count can be trusted, look at the numbers of the first triangle instead
+	if payload >= binaryTriangleSize && !plausibleTriangle(data[binaryHeaderSize+4:], binary.LittleEndian) &&
+		plausibleTriangle(data[binaryHeaderSize+4:], binary.BigEndian) {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// plausibleTriangle checks if the numbers of a triangle record read in a byte order are finite and of
+// a size a model would have: zero, or between 1e-10 and 1e10
+func plausibleTriangle(record []byte, order binary.ByteOrder) bool {
+	var t Triangle
+	decodeTriangle(record, &t, order)
+	values := append(t.Normal[:], t.Vertices[0][:]...)
+	values = append(append(values, t.Vertices[1][:]...), t.Vertices[2][:]...)
+	for _, value := range values {
+		magnitude := math.Abs(float64(value))
+		if magnitude != 0 && (magnitude < 1e-10 || magnitude > 1e10) || math.IsNaN(magnitude) {
+			return false
+		}
+	}
+	return true
+}
+
+// endsWithEndsolid checks if the last line of the text starts with endsolid
+func endsWithEndsolid(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return bytes.HasPrefix(bytes.TrimSpace(data[bytes.LastIndexByte(data, '\n')+1:]), []byte("endsolid"))
+}
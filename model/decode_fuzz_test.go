@@ -0,0 +1,74 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+// checkDecoded fails unless the decoded model counts its triangles right and survives a round trip
+// through WriteTo and ReadFrom, the second encoding matching the first
+func checkDecoded(t *testing.T, m *Model) {
+	t.Helper()
+	if int(m.NumTriangles) != len(m.Triangles) {
+		t.Fatalf("NumTriangles is %d but the model has %d triangles", m.NumTriangles, len(m.Triangles))
+	}
+	var first bytes.Buffer
+	if _, err := m.WriteTo(&first); err != nil {
+		t.Fatalf("writing the model: %v", err)
+	}
+	var again Model
+	n, err := again.ReadFrom(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		t.Fatalf("reading the model back: %v", err)
+	}
+	if n != int64(first.Len()) {
+		t.Fatalf("read %d bytes of the %d written", n, first.Len())
+	}
+	if len(again.Triangles) != len(m.Triangles) || int(again.NumTriangles) != len(again.Triangles) {
+		t.Fatalf("read back %d triangles (NumTriangles %d) of %d", len(again.Triangles), again.NumTriangles, len(m.Triangles))
+	}
+	var second bytes.Buffer
+	if _, err := again.WriteTo(&second); err != nil {
+		t.Fatalf("writing the model again: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatal("the round trip changed the model")
+	}
+}
+
+// FuzzDecodeBinary feeds arbitrary bytes to the binary STL readers, which must never panic nor trust
+// the count of the header to allocate
+func FuzzDecodeBinary(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(make([]byte, 83))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if m, err := CreateFromByteSlice(data); err == nil {
+			checkDecoded(t, &m)
+		}
+		if m, err := CreateFromBinarySTL(bytes.NewReader(data)); err == nil {
+			checkDecoded(t, &m)
+		}
+		if m, err := CreateFromSTLBytes(data); err == nil {
+			checkDecoded(t, &m)
+		}
+		if p, err := CreatePackedFromBinarySTL(bytes.NewReader(data)); err == nil && 9*p.Len() != len(p.Positions) {
+			t.Fatalf("the packed model has %d triangles but %d coordinates", p.Len(), len(p.Positions))
+		}
+	})
+}
+
+// FuzzDecodeASCII feeds arbitrary text to the ASCII STL reader, which must never panic
+func FuzzDecodeASCII(f *testing.F) {
+	f.Add([]byte("solid"))
+	f.Add([]byte("solid s\nendsolid s\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m, _ := CreateFromASCIISTL(bufio.NewReader(bytes.NewReader(data)))
+		checkDecoded(t, &m)
+		if models, err := CreateAllFromASCIISTL(bufio.NewReader(bytes.NewReader(data))); err == nil {
+			for i := range models {
+				checkDecoded(t, &models[i])
+			}
+		}
+	})
+}
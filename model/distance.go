@@ -0,0 +1,104 @@
+package model
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// DistanceMetrics summarizes how far apart the surfaces of two models are
+type DistanceMetrics struct {
+	//Symmetric Hausdorff distance (worst case deviation in either direction)
+	Hausdorff float32 `json:"hausdorff"`
+	//Largest distance from a sample of A to B and from a sample of B to A
+	MaxAToB float32 `json:"maxAToB"`
+	MaxBToA float32 `json:"maxBToA"`
+	//Mean distance from the samples of A to B and from the samples of B to A
+	MeanAToB float32 `json:"meanAToB"`
+	MeanBToA float32 `json:"meanBToA"`
+	//Mean of both directions
+	Mean float32 `json:"mean"`
+	//Root mean square of all sampled distances
+	RMS float32 `json:"rms"`
+}
+
+// SurfaceDistance measures the distance between the surfaces of a and b by sampling samples points
+// (plus the vertices) on each one and finding the closest point on the other with a BVH
+func SurfaceDistance(a, b *Model, samples int) DistanceMetrics {
+	var metrics DistanceMetrics
+	bvhA, bvhB := NewBVH(a), NewBVH(b)
+	maxAB, meanAB, squaresAB, countAB := directedDistance(a, bvhB, samples)
+	maxBA, meanBA, squaresBA, countBA := directedDistance(b, bvhA, samples)
+	metrics.MaxAToB, metrics.MeanAToB = maxAB, meanAB
+	metrics.MaxBToA, metrics.MeanBToA = maxBA, meanBA
+	metrics.Hausdorff = maxAB
+	if maxBA > maxAB {
+		metrics.Hausdorff = maxBA
+	}
+	metrics.Mean = (meanAB + meanBA) / 2
+	if countAB+countBA > 0 {
+		metrics.RMS = float32(math.Sqrt((squaresAB + squaresBA) / float64(countAB+countBA)))
+	}
+	return metrics
+}
+
+// directedDistance samples the surface of from and measures the distance of each sample to target
+func directedDistance(from *Model, target *BVH, samples int) (maxDistance, meanDistance float32, squares float64, count int) {
+	points := SampleSurface(from, samples)
+	var sum float64
+	for _, p := range points {
+		hit, ok := target.Nearest(p)
+		if !ok {
+			continue
+		}
+		if hit.Distance > maxDistance {
+			maxDistance = hit.Distance
+		}
+		sum += float64(hit.Distance)
+		squares += float64(hit.Distance) * float64(hit.Distance)
+		count++
+	}
+	if count > 0 {
+		meanDistance = float32(sum / float64(count))
+	}
+	return maxDistance, meanDistance, squares, count
+}
+
+// SampleSurface returns the vertices of m plus samples points spread over its surface proportionally
+// to the area of each triangle. The sampling is deterministic so results are reproducible.
+func SampleSurface(m *Model, samples int) []Vec3 {
+	points := make([]Vec3, 0, 3*len(m.Triangles)+samples)
+	cumulativeAreas := make([]float64, len(m.Triangles))
+	total := float64(0)
+	for i := range m.Triangles {
+		//Always include the vertices, the largest deviations are often there
+		for _, v := range m.Triangles[i].Vertices {
+			points = append(points, v)
+		}
+		total += float64(triangleArea(&m.Triangles[i]))
+		cumulativeAreas[i] = total
+	}
+	if total == 0 || samples <= 0 {
+		return points
+	}
+	random := rand.New(rand.NewSource(1))
+	for s := 0; s < samples; s++ {
+		//Pick a triangle weighted by area
+		i := sort.SearchFloat64s(cumulativeAreas, random.Float64()*total)
+		if i >= len(m.Triangles) {
+			i = len(m.Triangles) - 1
+		}
+		//Pick a uniform point in it
+		r1, r2 := float32(math.Sqrt(random.Float64())), float32(random.Float64())
+		a, b, c := Vec3(m.Triangles[i].Vertices[0]), Vec3(m.Triangles[i].Vertices[1]), Vec3(m.Triangles[i].Vertices[2])
+		points = append(points, a.Scale(1-r1).Add(b.Scale(r1*(1-r2))).Add(c.Scale(r1*r2)))
+	}
+	return points
+}
+
+// triangleArea returns the area of the triangle
+func triangleArea(t *Triangle) float32 {
+	ab := Vec3(t.Vertices[1]).Sub(t.Vertices[0])
+	ac := Vec3(t.Vertices[2]).Sub(t.Vertices[0])
+	return ab.Cross(ac).Length() / 2
+}
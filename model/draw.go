@@ -0,0 +1,171 @@
+package model
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Palette is the ramp of characters used to draw the values of a matrix
+type Palette struct {
+	//Characters from the lowest to the highest value
+	Runes []rune
+	//A value uses the last rune whose threshold it exceeds, so they must be increasing
+	Thresholds []float32
+	//Character for the cells below every threshold
+	Empty rune
+}
+
+// BlockPalette is the default palette using unicode shade blocks
+var BlockPalette = Palette{Runes: []rune("░▒▓"), Thresholds: []float32{0, 0.375, 0.75}, Empty: ' '}
+
+// ASCIIPalette only uses ASCII characters for terminals and fonts without block characters
+var ASCIIPalette = Palette{Runes: []rune(".:-=+*#%@"), Thresholds: []float32{0, 1.0 / 9, 2.0 / 9, 3.0 / 9, 4.0 / 9, 5.0 / 9, 6.0 / 9, 7.0 / 9, 8.0 / 9}, Empty: ' '}
+
+// NewPalette creates a palette from a ramp of characters (lowest value first) with evenly spaced thresholds
+func NewPalette(ramp string) (p Palette, err error) {
+	if ramp == "" || !utf8.ValidString(ramp) {
+		return p, errors.New("Palette needs at least one valid character")
+	}
+	p.Runes = []rune(ramp)
+	p.Thresholds = make([]float32, len(p.Runes))
+	for i := range p.Thresholds {
+		p.Thresholds[i] = float32(i) / float32(len(p.Runes))
+	}
+	p.Empty = ' '
+	return p, nil
+}
+
+// ParsePalette returns a named palette (block or ascii) or creates one from a ramp of characters
+func ParsePalette(name string) (Palette, error) {
+	switch name {
+	case "block":
+		return BlockPalette, nil
+	case "ascii":
+		return ASCIIPalette, nil
+	}
+	return NewPalette(name)
+}
+
+// Validate checks that there is a threshold for every rune and that they are increasing
+func (p Palette) Validate() error {
+	if len(p.Runes) == 0 || len(p.Runes) != len(p.Thresholds) {
+		return errors.New("Palette needs one threshold per character")
+	}
+	for i := 1; i < len(p.Thresholds); i++ {
+		if p.Thresholds[i] < p.Thresholds[i-1] {
+			return errors.New("Palette thresholds must be increasing")
+		}
+	}
+	return nil
+}
+
+// Rune returns the character for a value. Palettes failing Validate don't panic: thresholds without
+// a character of their own give the last one, and a palette without characters only gives Empty.
+func (p Palette) Rune(value float32) rune {
+	for i := len(p.Thresholds) - 1; i >= 0; i-- {
+		if value > p.Thresholds[i] && len(p.Runes) > 0 {
+			return p.Runes[min(i, len(p.Runes)-1)]
+		}
+	}
+	return p.Empty
+}
+
+// DrawMatrixPalette draws a matrix with the characters of the palette for the value axis (see Validate for custom palettes)
+func DrawMatrixPalette(matrix [][]float32, palette Palette) string {
+	return DrawMatrixPaletteHighlighted(matrix, nil, palette, 0)
+}
+
+// DrawMatrixPaletteHighlighted draws a matrix like DrawMatrixPalette, using the mark character for the
+// cells set in the mask (which may be nil) that are not empty
+func DrawMatrixPaletteHighlighted(matrix [][]float32, mask [][]bool, palette Palette, mark rune) string {
+	var builder strings.Builder
+	RenderMatrixPaletteHighlighted(&builder, matrix, mask, palette, mark)
+	return builder.String()
+}
+
+// RenderMatrixPalette writes what DrawMatrixPalette draws, a row at a time
+func RenderMatrixPalette(w io.Writer, matrix [][]float32, palette Palette) error {
+	return RenderMatrixPaletteHighlighted(w, matrix, nil, palette, 0)
+}
+
+// RenderMatrixPaletteHighlighted writes what DrawMatrixPaletteHighlighted draws, a row at a time
+func RenderMatrixPaletteHighlighted(w io.Writer, matrix [][]float32, mask [][]bool, palette Palette, mark rune) error {
+	rows := rowWriter{w: w}
+	for i := range matrix {
+		for j := range matrix[i] {
+			if matrix[i][j] > 0 && highlighted(mask, i, j) {
+				rows.row = utf8.AppendRune(rows.row, mark)
+				continue
+			}
+			rows.row = utf8.AppendRune(rows.row, palette.Rune(matrix[i][j]))
+		}
+		rows.end()
+	}
+	return rows.err
+}
+
+// rowWriter writes the lines of a drawing as they are finished, keeping the first error
+type rowWriter struct {
+	w   io.Writer
+	row []byte
+	err error
+}
+
+// end writes the current row with its new line and starts the next one
+func (r *rowWriter) end() {
+	r.row = append(r.row, '\n')
+	if r.err == nil {
+		_, r.err = r.w.Write(r.row)
+	}
+	r.row = r.row[:0]
+}
+
+// highlighted checks if a cell is set in a mask, which may be nil or smaller than the matrix
+func highlighted(mask [][]bool, row, col int) bool {
+	return row < len(mask) && col < len(mask[row]) && mask[row][col]
+}
+
+// Bits of the braille dots for a 4 rows x 2 columns cell
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// DrawMatrixBraille draws a matrix with unicode braille characters, each one showing 2 columns by 4
+// rows of cells as its 2 x 4 dots. The result is half the width and a quarter of the height of
+// DrawMatrix for the same matrix, so a matrix twice as wide and four times as tall, with cells of half
+// the aspect, fills the same space with eight times the detail.
+func DrawMatrixBraille(matrix [][]float32) string {
+	var builder strings.Builder
+	RenderMatrixBraille(&builder, matrix)
+	return builder.String()
+}
+
+// RenderMatrixBraille writes what DrawMatrixBraille draws, a row of characters at a time
+func RenderMatrixBraille(w io.Writer, matrix [][]float32) error {
+	rows := rowWriter{w: w}
+	for i := 0; i < len(matrix); i += 4 {
+		for j := 0; j < len(matrix[i]); j += 2 {
+			var dots rune
+			for row := 0; row < 4 && i+row < len(matrix); row++ {
+				for col := 0; col < 2; col++ {
+					if j+col < len(matrix[i+row]) && matrix[i+row][j+col] > 0 {
+						dots |= brailleDots[row][col]
+					}
+				}
+			}
+			if dots == 0 {
+				//Plain spaces keep empty areas clean on terminals with narrow blank braille glyphs
+				rows.row = append(rows.row, ' ')
+			} else {
+				rows.row = utf8.AppendRune(rows.row, 0x2800+dots)
+			}
+		}
+		rows.end()
+	}
+	return rows.err
+}
@@ -0,0 +1,222 @@
+package model
+
+import "math"
+
+// EqualOptions chooses how Equal and Diff match the facets of two models
+type EqualOptions struct {
+	//Match the facets wherever they are in the list instead of position by position
+	IgnoreOrder bool
+	//Largest difference between two coordinates that are still the same, 0 to compare them exactly
+	Tolerance float32
+	//Also compare the normals (with the same tolerance), the attribute byte counts and the headers
+	CompareNormals, CompareAttributes, CompareHeader bool
+}
+
+// FacetChange pairs a facet of A with the facet of B that replaced it
+type FacetChange struct {
+	A, B int
+}
+
+// Difference lists what changed from a model A to a model B
+type Difference struct {
+	//Indices in B of the facets that are not in A
+	Added []int
+	//Indices in A of the facets that are not in B
+	Removed []int
+	//Facets that are in both but differ: in the normal or the attributes, or at the same position
+	//(keeping the order) or sharing two vertices (ignoring it) with a different geometry
+	Changed []FacetChange
+	//The headers differ, only checked with CompareHeader
+	HeaderChanged bool
+}
+
+// Equal checks if there are no differences at all
+func (d Difference) Equal() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && !d.HeaderChanged
+}
+
+// Equal checks if two models have the same facets, comparing them as the options say. Two facets
+// are the same when they have the same vertices in the same winding, whichever one comes first.
+func Equal(a, b *Model, opts EqualOptions) bool {
+	if !opts.IgnoreOrder && len(a.Triangles) != len(b.Triangles) {
+		return false
+	}
+	return Diff(a, b, opts).Equal()
+}
+
+// Diff lists the facets added, removed and changed from a to b, matching them as the options say
+func Diff(a, b *Model, opts EqualOptions) (d Difference) {
+	d.HeaderChanged = opts.CompareHeader && a.Header != b.Header
+	if !opts.IgnoreOrder {
+		for i := range a.Triangles {
+			if i >= len(b.Triangles) {
+				d.Removed = append(d.Removed, i)
+			} else if !sameGeometry(&a.Triangles[i], &b.Triangles[i], opts.Tolerance) || !sameDetails(&a.Triangles[i], &b.Triangles[i], opts) {
+				d.Changed = append(d.Changed, FacetChange{i, i})
+			}
+		}
+		for i := len(a.Triangles); i < len(b.Triangles); i++ {
+			d.Added = append(d.Added, i)
+		}
+		return d
+	}
+	//Find the facets of a by the corner of their bounding box, which doesn't depend on the vertex order
+	grid := toleranceGrid{cell: opts.Tolerance}
+	facets := make(map[[3]int64][]int)
+	for i := range a.Triangles {
+		key := grid.key(facetCorner(&a.Triangles[i]))
+		facets[key] = append(facets[key], i)
+	}
+	matched := make([]bool, len(a.Triangles))
+	var unmatched []int
+	for j := range b.Triangles {
+		found := -1
+		grid.neighbors(facetCorner(&b.Triangles[j]), func(key [3]int64) bool {
+			for _, i := range facets[key] {
+				if !matched[i] && sameGeometry(&a.Triangles[i], &b.Triangles[j], opts.Tolerance) {
+					found = i
+					return false
+				}
+			}
+			return true
+		})
+		if found < 0 {
+			unmatched = append(unmatched, j)
+			continue
+		}
+		matched[found] = true
+		if !sameDetails(&a.Triangles[found], &b.Triangles[j], opts) {
+			d.Changed = append(d.Changed, FacetChange{found, j})
+		}
+	}
+	//Pair the leftover facets sharing two vertices, they were most likely edited
+	vertices := make(map[[3]int64][]int)
+	for i := range a.Triangles {
+		if matched[i] {
+			continue
+		}
+		for _, v := range a.Triangles[i].Vertices {
+			key := grid.key(v)
+			vertices[key] = append(vertices[key], i)
+		}
+	}
+	for _, j := range unmatched {
+		shared := make(map[int]int)
+		for _, w := range b.Triangles[j].Vertices {
+			seen := make(map[int]bool)
+			grid.neighbors(w, func(key [3]int64) bool {
+				for _, i := range vertices[key] {
+					if seen[i] || matched[i] {
+						continue
+					}
+					for _, v := range a.Triangles[i].Vertices {
+						if sameVertex(v, w, opts.Tolerance) {
+							seen[i] = true
+							shared[i]++
+							break
+						}
+					}
+				}
+				return true
+			})
+		}
+		found := -1
+		for i, count := range shared {
+			if count >= 2 && (found < 0 || i < found) {
+				found = i
+			}
+		}
+		if found < 0 {
+			d.Added = append(d.Added, j)
+			continue
+		}
+		matched[found] = true
+		d.Changed = append(d.Changed, FacetChange{found, j})
+	}
+	for i := range a.Triangles {
+		if !matched[i] {
+			d.Removed = append(d.Removed, i)
+		}
+	}
+	return d
+}
+
+// sameGeometry checks if two facets have the same vertices in the same winding
+func sameGeometry(s, t *Triangle, tolerance float32) bool {
+	for shift := 0; shift < 3; shift++ {
+		if sameVertex(s.Vertices[0], t.Vertices[shift], tolerance) &&
+			sameVertex(s.Vertices[1], t.Vertices[(shift+1)%3], tolerance) &&
+			sameVertex(s.Vertices[2], t.Vertices[(shift+2)%3], tolerance) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameDetails compares the normals and the attributes of two facets if the options ask for it
+func sameDetails(s, t *Triangle, opts EqualOptions) bool {
+	if opts.CompareNormals && !sameVertex(s.Normal, t.Normal, opts.Tolerance) {
+		return false
+	}
+	return !opts.CompareAttributes || s.AttrByteCount == t.AttrByteCount
+}
+
+// sameVertex checks if every coordinate of two points differs by at most the tolerance
+func sameVertex(v, w [3]float32, tolerance float32) bool {
+	for k := range v {
+		if abs32(v[k]-w[k]) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// facetCorner returns the lowest corner of the bounding box of a facet
+func facetCorner(t *Triangle) [3]float32 {
+	corner := t.Vertices[0]
+	for _, v := range t.Vertices[1:] {
+		for k := range corner {
+			if v[k] < corner[k] {
+				corner[k] = v[k]
+			}
+		}
+	}
+	return corner
+}
+
+// toleranceGrid buckets points so the ones closer than the tolerance are in the same or neighboring cells
+type toleranceGrid struct {
+	//Size of the cells, the tolerance, or 0 for one cell per exact point
+	cell float32
+}
+
+// key returns the cell of a point
+func (g toleranceGrid) key(p [3]float32) (key [3]int64) {
+	for k := range p {
+		if g.cell > 0 {
+			key[k] = int64(math.Floor(float64(p[k] / g.cell)))
+		} else if p[k] != 0 {
+			//Both zeros are the same point
+			key[k] = int64(math.Float32bits(p[k]))
+		}
+	}
+	return key
+}
+
+// neighbors calls visit with the cell of the point and, with a tolerance, the ones around it, until it returns false
+func (g toleranceGrid) neighbors(p [3]float32, visit func(key [3]int64) bool) {
+	key := g.key(p)
+	if g.cell <= 0 {
+		visit(key)
+		return
+	}
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for dz := int64(-1); dz <= 1; dz++ {
+				if !visit([3]int64{key[0] + dx, key[1] + dy, key[2] + dz}) {
+					return
+				}
+			}
+		}
+	}
+}
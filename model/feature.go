@@ -0,0 +1,102 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+// FeatureEdges returns the edges, as pairs of vertex indices in increasing order, where the surface
+// bends by more than angle degrees between the two faces sharing them. Edges on the border of holes
+// and edges shared by more than two faces are features too, and faces without area are ignored.
+func (t *Topology) FeatureEdges(angle float64) (edges [][2]int) {
+	threshold := float32(math.Cos(radians(angle)))
+	normal := func(face int) Vec3 {
+		a, b, c := t.Vertices[t.Faces[face][0]], t.Vertices[t.Faces[face][1]], t.Vertices[t.Faces[face][2]]
+		return b.Sub(a).Cross(c.Sub(a)).Normalize()
+	}
+	for key, use := range t.edges {
+		var normals []Vec3
+		for _, face := range use.faces {
+			if n := normal(face); n != (Vec3{}) {
+				normals = append(normals, n)
+			}
+		}
+		if len(normals) != 2 || normals[0].Dot(normals[1]) < threshold {
+			edges = append(edges, key)
+		}
+	}
+	sortEdges(edges)
+	return edges
+}
+
+// sortEdges puts the edges in order of their first and then their second vertex, so the results
+// don't depend on the order of the map they come from
+func sortEdges(edges [][2]int) {
+	sort.Slice(edges, func(i, j int) bool { return lessEdge(edges[i], edges[j]) })
+}
+
+// lessEdge orders edge keys by their first and then their second vertex
+func lessEdge(a, b [2]int) bool {
+	return a[0] < b[0] || a[0] == b[0] && a[1] < b[1]
+}
+
+// FeatureLines chains the FeatureEdges in polylines, breaking them where more or less than two of
+// them meet. Closed loops end with their first point.
+func (t *Topology) FeatureLines(angle float64) (lines [][]Vec3) {
+	edges := t.FeatureEdges(angle)
+	ends := make(map[int][]int)
+	for i, edge := range edges {
+		ends[edge[0]] = append(ends[edge[0]], i)
+		ends[edge[1]] = append(ends[edge[1]], i)
+	}
+	used := make([]bool, len(edges))
+	//Follow the edges from a vertex until the line ends or branches
+	follow := func(vertex, edge int) (line []Vec3) {
+		line = append(line, t.Vertices[vertex])
+		for !used[edge] {
+			used[edge] = true
+			vertex = edges[edge][0] + edges[edge][1] - vertex
+			line = append(line, t.Vertices[vertex])
+			if len(ends[vertex]) != 2 {
+				break
+			}
+			edge = ends[vertex][0] + ends[vertex][1] - edge
+		}
+		return line
+	}
+	//Start from the ends and branches first, what remains are closed loops
+	for _, loops := range []bool{false, true} {
+		for i, edge := range edges {
+			for _, vertex := range edge {
+				if !used[i] && (loops || len(ends[vertex]) != 2) {
+					lines = append(lines, follow(vertex, i))
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// HighlightLines returns a mask of the cells of a projection of the model crossed by the polylines,
+// to draw them with DrawMatrixPaletteHighlighted, DrawMatrixColorHighlighted or
+// DrawMatrixImageHighlighted over a render of the same viewport and perspective. Lines hidden behind
+// the surface are marked too.
+func HighlightLines(m *Model, viewport Viewport, perspective Perspective, lines [][]Vec3) [][]bool {
+	p := newProjection(m, viewport, perspective)
+	mask := make([][]bool, p.viewport.Height)
+	for i := range mask {
+		mask[i] = make([]bool, p.viewport.Width)
+	}
+	for _, line := range lines {
+		for i := 1; i < len(line); i++ {
+			row0, col0, _ := p.cell(line[i-1])
+			row1, col1, _ := p.cell(line[i])
+			bresenham(row0, col0, row1, col1, func(row, col int, _ float32) {
+				if row >= 0 && row < len(mask) && col >= 0 && col < len(mask[row]) {
+					mask[row][col] = true
+				}
+			})
+		}
+	}
+	return mask
+}
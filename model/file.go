@@ -0,0 +1,141 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// IsModelFile checks if the extension of a file is one ReadFile knows (.stl or .obj)
+func IsModelFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".stl", ".obj":
+		return true
+	}
+	return false
+}
+
+// ReadFile reads a model from an OBJ file, or an ASCII or binary STL file for any other extension
+func ReadFile(path string) (m Model, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".obj" {
+		return CreateFromOBJ(bytes.NewReader(data))
+	}
+	return CreateFromSTLBytes(data)
+}
+
+// CreateFromSTLBytes reads an ASCII or binary STL from memory, checking the size of binary files
+// before allocating the triangles they announce
+func CreateFromSTLBytes(data []byte) (m Model, err error) {
+	//Try ASCII if it looks like it, falling back to binary
+	if bytes.HasPrefix(data, []byte("solid")) {
+		m, err = CreateFromASCIISTL(bufio.NewReader(bytes.NewReader(data)))
+		if len(m.Triangles) > 0 {
+			return m, err
+		}
+	}
+	//CreateFromByteSlice checks the size against the count of the header
+	return CreateFromByteSlice(data)
+}
+
+// CreateAllFromASCIISTL reads every solid of an ASCII STL file holding several of them one after
+// the other, each one as a separate model. Anything after the last solid is ignored.
+func CreateAllFromASCIISTL(r *bufio.Reader) (models []Model, err error) {
+	for {
+		//Skip the blank lines between solids
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				return models, nil
+			}
+			if !unicode.IsSpace(rune(b)) {
+				r.UnreadByte()
+				break
+			}
+		}
+		if next, _ := r.Peek(5); string(next) != "solid" {
+			return models, nil
+		}
+		m, err := CreateFromASCIISTL(r)
+		if err != nil {
+			return models, err
+		}
+		models = append(models, m)
+	}
+}
+
+// Reset empties the model keeping the room of its triangles, so it can be filled again by DecodeInto
+// without allocating them anew
+func (m *Model) Reset() {
+	*m = Model{Triangles: m.Triangles[:0]}
+}
+
+// DecodeInto reads an ASCII or binary STL from memory like CreateFromSTLBytes, but into m, reusing
+// the room of its triangles when there is enough. Whatever m held before is lost.
+func DecodeInto(m *Model, data []byte) (err error) {
+	m.Reset()
+	//Try ASCII if it looks like it, falling back to binary
+	if bytes.HasPrefix(data, []byte("solid")) {
+		*m, err = decodeASCIISTL(bufio.NewReader(bytes.NewReader(data)), *m)
+		if len(m.Triangles) > 0 {
+			return err
+		}
+		m.Reset()
+	}
+	count, err := binaryCount(data)
+	if err != nil {
+		return err
+	}
+	decodeBinarySTL(m, data, count, binary.LittleEndian)
+	return nil
+}
+
+// binaryCount returns the number of triangles announced by the header of a binary STL in memory,
+// failing when data is too short to hold them
+func binaryCount(data []byte) (count int, err error) {
+	if len(data) < binaryHeaderSize+4 {
+		return 0, errors.New("Truncated binary STL")
+	}
+	announced := binary.LittleEndian.Uint32(data[binaryHeaderSize:])
+	if uint64(len(data)-binaryHeaderSize-4) < binaryTriangleSize*uint64(announced) {
+		return 0, errors.New("Truncated binary STL")
+	}
+	return int(announced), nil
+}
+
+// decodeBinarySTL reads the header and the first count triangles of a binary STL into m, which must
+// have room for them in data
+func decodeBinarySTL(m *Model, data []byte, count int, order binary.ByteOrder) {
+	m.Header = string(bytes.TrimRight(data[:binaryHeaderSize], "\x00"))
+	if cap(m.Triangles) < count {
+		m.Triangles = make([]Triangle, count)
+	}
+	m.Triangles, m.NumTriangles = m.Triangles[:count], uint32(count)
+	for i := range m.Triangles {
+		decodeTriangle(data[binaryHeaderSize+4+binaryTriangleSize*i:], &m.Triangles[i], order)
+	}
+}
+
+// decodeTriangle reads a triangle from its 50 bytes in a binary STL, little endian unless it is a
+// legacy file
+func decodeTriangle(record []byte, t *Triangle, order binary.ByteOrder) {
+	floats := func(record []byte, values []float32) {
+		for k := range values {
+			values[k] = math.Float32frombits(order.Uint32(record[4*k:]))
+		}
+	}
+	floats(record, t.Normal[:])
+	for j := range t.Vertices {
+		floats(record[12+12*j:], t.Vertices[j][:])
+	}
+	t.AttrByteCount = order.Uint16(record[48:])
+}
@@ -0,0 +1,213 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// FingerprintOptions chooses what Fingerprint ignores besides the order of the triangles
+type FingerprintOptions struct {
+	//Recognize copies of the model moved elsewhere
+	IgnoreTranslation bool
+	//Recognize copies of the model moved elsewhere and turned
+	IgnoreRotation bool
+	//Significant digits of the measurements hashed when ignoring the translation or the rotation, 4 if 0.
+	//Fewer digits tolerate bigger differences from exporting but tell fewer models apart.
+	Digits int
+}
+
+// Fingerprint returns a hash of the geometry of the model that doesn't depend on the order of the
+// triangles nor on which of its vertices each one starts from. The header, the normals and the
+// attributes are not part of it.
+func (m *Model) Fingerprint() string {
+	return m.FingerprintWith(FingerprintOptions{})
+}
+
+// FingerprintWith returns a hash of the model like Fingerprint, optionally recognizing copies that
+// were moved or turned. Those can't be compared vertex by vertex after the floating point errors of
+// transforming them, so the hash is made of their size, area, volume and spread around the centroid
+// (the covariance of the surface, or its principal moments when ignoring the rotation) instead.
+func (m *Model) FingerprintWith(opts FingerprintOptions) string {
+	if opts.IgnoreTranslation || opts.IgnoreRotation {
+		return m.poseFingerprint(opts)
+	}
+	quantize := func(v float32) int64 {
+		//Both zeros are the same
+		if v == 0 {
+			return 0
+		}
+		return int64(math.Float32bits(v))
+	}
+	//Canonical triangles: starting from the smallest vertex, keeping the winding
+	triangles := make([][9]int64, len(m.Triangles))
+	for i := range m.Triangles {
+		var vertices [3][3]int64
+		for j, v := range m.Triangles[i].Vertices {
+			vertices[j] = [3]int64{quantize(v[0]), quantize(v[1]), quantize(v[2])}
+		}
+		first := 0
+		for j := 1; j < 3; j++ {
+			if lessInt64s(vertices[j][:], vertices[first][:]) {
+				first = j
+			}
+		}
+		for j := 0; j < 3; j++ {
+			copy(triangles[i][3*j:], vertices[(first+j)%3][:])
+		}
+	}
+	sort.Slice(triangles, func(a, b int) bool {
+		return lessInt64s(triangles[a][:], triangles[b][:])
+	})
+	hash := sha256.New()
+	var buffer [8 * 9]byte
+	for i := range triangles {
+		for j, value := range triangles[i] {
+			binary.LittleEndian.PutUint64(buffer[8*j:], uint64(value))
+		}
+		hash.Write(buffer[:])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// poseFingerprint hashes measurements of the model that don't change when it is moved (or turned),
+// relative to its radius so values close to 0 round the same way whatever the size
+func (m *Model) poseFingerprint(opts FingerprintOptions) string {
+	digits := opts.Digits
+	if digits <= 0 {
+		digits = 4
+	}
+	centroid, covariance := surfaceMoments(m)
+	//Largest distance to the centroid, and the volume around it so open models don't depend on where they are
+	radius, volume := 0.0, 0.0
+	for i := range m.Triangles {
+		var vertices [3][3]float64
+		for j, v := range m.Triangles[i].Vertices {
+			vertices[j] = [3]float64{float64(v[0]) - centroid[0], float64(v[1]) - centroid[1], float64(v[2]) - centroid[2]}
+			radius = math.Max(radius, math.Sqrt(vertices[j][0]*vertices[j][0]+vertices[j][1]*vertices[j][1]+vertices[j][2]*vertices[j][2]))
+		}
+		a, b, c := vertices[0], vertices[1], vertices[2]
+		volume += (a[0]*(b[1]*c[2]-b[2]*c[1]) + a[1]*(b[2]*c[0]-b[0]*c[2]) + a[2]*(b[0]*c[1]-b[1]*c[0])) / 6
+	}
+	if radius == 0 {
+		radius = 1
+	}
+	measures := []float64{float64(SurfaceArea(m)) / (radius * radius), volume / (radius * radius * radius)}
+	if opts.IgnoreRotation {
+		values, _ := symmetricEigen(covariance)
+		sorted := values[:]
+		sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+		for _, value := range sorted {
+			measures = append(measures, value/(radius*radius))
+		}
+	} else {
+		for r := 0; r < 3; r++ {
+			for c := r; c < 3; c++ {
+				measures = append(measures, covariance[r][c]/(radius*radius))
+			}
+		}
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d %d %.*g", len(m.Triangles), len(NewTopology(m).Vertices), digits, radius)
+	scale := math.Pow(10, float64(digits))
+	for _, measure := range measures {
+		fmt.Fprintf(hash, " %d", int64(math.Round(measure*scale)))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// lessInt64s compares two slices of the same length lexicographically
+func lessInt64s(a, b []int64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// surfaceMoments returns the centroid of the surface of the model and its covariance, both weighted
+// by the area of the triangles
+func surfaceMoments(m *Model) (centroid [3]float64, covariance [3][3]float64) {
+	var total float64
+	var moments [3][3]float64
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		area := float64(triangleArea(t))
+		var sum [3]float64
+		for _, v := range t.Vertices {
+			for k := range sum {
+				sum[k] += float64(v[k])
+			}
+		}
+		for k := range sum {
+			centroid[k] += area * sum[k] / 3
+		}
+		//The integral of x xT over a triangle is area/12 (sum of v vT + s sT), s being the sum of the vertices
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				vertexSum := 0.0
+				for _, v := range t.Vertices {
+					vertexSum += float64(v[r]) * float64(v[c])
+				}
+				moments[r][c] += area / 12 * (vertexSum + sum[r]*sum[c])
+			}
+		}
+		total += area
+	}
+	if total == 0 {
+		return centroid, covariance
+	}
+	for k := range centroid {
+		centroid[k] /= total
+	}
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			covariance[r][c] = moments[r][c]/total - centroid[r]*centroid[c]
+		}
+	}
+	return centroid, covariance
+}
+
+// symmetricEigen diagonalizes a symmetric matrix with Jacobi rotations, returning the eigenvalues and
+// the eigenvectors as the columns of a matrix
+func symmetricEigen(a [3][3]float64) (values [3]float64, vectors [3][3]float64) {
+	vectors = [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	for sweep := 0; sweep < 50; sweep++ {
+		off := a[0][1]*a[0][1] + a[0][2]*a[0][2] + a[1][2]*a[1][2]
+		if off < 1e-30 {
+			break
+		}
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				//Rotation zeroing a[p][q]
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < 3; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p], a[k][q] = c*akp-s*akq, s*akp+c*akq
+				}
+				for k := 0; k < 3; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k], a[q][k] = c*apk-s*aqk, s*apk+c*aqk
+				}
+				for k := 0; k < 3; k++ {
+					vkp, vkq := vectors[k][p], vectors[k][q]
+					vectors[k][p], vectors[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+	return [3]float64{a[0][0], a[1][1], a[2][2]}, vectors
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// NonFinitePolicy tells what to do with the facets having NaN or infinite numbers
+type NonFinitePolicy int
+
+const (
+	//Keep them as they are
+	NonFiniteAllow NonFinitePolicy = iota
+	//Fail with an error listing them
+	NonFiniteReject
+	//Replace the NaN and infinite coordinates with 0, and the normals with the ones of the winding
+	NonFiniteZero
+	//Remove the facets
+	NonFiniteDrop
+)
+
+// NonFiniteFacets returns the facets with a NaN or infinite coordinate or normal
+func NonFiniteFacets(m *Model) (facets []int) {
+	for i := range m.Triangles {
+		if !finiteTriangle(&m.Triangles[i]) {
+			facets = append(facets, i)
+		}
+	}
+	return facets
+}
+
+// ApplyNonFinitePolicy finds the facets with NaN or infinite numbers and handles them with the policy,
+// returning them (their positions before any was dropped). NonFiniteReject returns an error when there
+// are some, leaving the model as it is.
+func ApplyNonFinitePolicy(m *Model, policy NonFinitePolicy) (facets []int, err error) {
+	facets = NonFiniteFacets(m)
+	if len(facets) == 0 {
+		return facets, nil
+	}
+	switch policy {
+	case NonFiniteReject:
+		return facets, fmt.Errorf("%v facets have NaN or infinite numbers, the first one is %v", len(facets), facets[0])
+	case NonFiniteZero:
+		for _, i := range facets {
+			t := &m.Triangles[i]
+			for j := range t.Vertices {
+				for k, f := range t.Vertices[j] {
+					if !finite(f) {
+						t.Vertices[j][k] = 0
+					}
+				}
+			}
+			if !finite(t.Normal[0]) || !finite(t.Normal[1]) || !finite(t.Normal[2]) {
+				t.Normal = [3]float32{}
+				t.Normal = triangleNormal(t)
+			}
+		}
+		m.Invalidate()
+	case NonFiniteDrop:
+		m.keepTriangles(func(_ int, t *Triangle) bool { return finiteTriangle(t) })
+	}
+	return facets, nil
+}
+
+// finiteTriangle checks that the normal and the vertices of the triangle are neither NaN nor infinite
+func finiteTriangle(t *Triangle) bool {
+	for k := range t.Normal {
+		if !finite(t.Normal[k]) || !finite(t.Vertices[0][k]) || !finite(t.Vertices[1][k]) || !finite(t.Vertices[2][k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// finite checks that f is neither NaN nor infinite
+func finite(f float32) bool {
+	return !math.IsNaN(float64(f)) && !math.IsInf(float64(f), 0)
+}
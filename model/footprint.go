@@ -0,0 +1,158 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+// FootprintRegion is a connected part of a footprint
+type FootprintRegion struct {
+	//Outer boundary on the XY plane, counter-clockwise, the last point not repeated
+	Outline []Vec2
+	//Boundaries of the holes inside it, clockwise
+	Holes [][]Vec2
+}
+
+// Footprint is the shadow of a model on the build plate: the area covered by its projection along Z,
+// with the holes going all the way through it
+type Footprint struct {
+	//Separate parts of the shadow, the largest first
+	Regions []FootprintRegion
+	//Area covered, without the holes
+	Area float32
+	//Length of all the boundaries, the holes included
+	Perimeter float32
+}
+
+// ComputeFootprint outlines the shadow of the model on the XY plane. Like ProjectSilhouette, the
+// projection is rasterized on a grid of resolution x resolution cells, and the staircases it leaves
+// along the slanted sides are then straightened within a cell, so the area and the perimeter are
+// close to those of the real shape.
+func ComputeFootprint(m *Model, resolution int) Footprint {
+	s, cell := projectSilhouette(m, resolution, ProjectFromTop)
+	return footprintOf(s.Outlines, cell)
+}
+
+// footprintOf sorts the outlines of a grid into regions with their holes, straightening them within
+// tolerance
+func footprintOf(outlines [][]Vec2, tolerance float32) (f Footprint) {
+	//Sort the loops into outer boundaries and holes, before straightening them moves their points
+	var holes [][]Vec2
+	for _, outline := range outlines {
+		if PolygonArea(outline) > 0 {
+			f.Regions = append(f.Regions, FootprintRegion{Outline: outline})
+		} else {
+			holes = append(holes, outline)
+		}
+	}
+	sort.SliceStable(f.Regions, func(a, b int) bool {
+		return PolygonArea(f.Regions[a].Outline) > PolygonArea(f.Regions[b].Outline)
+	})
+	//Every hole belongs to the smallest region around it
+	for _, hole := range holes {
+		for r := len(f.Regions) - 1; r >= 0; r-- {
+			if pointInPolygon(hole[0], f.Regions[r].Outline) {
+				f.Regions[r].Holes = append(f.Regions[r].Holes, hole)
+				break
+			}
+		}
+	}
+	for r := range f.Regions {
+		region := &f.Regions[r]
+		region.Outline = straightenLoop(region.Outline, tolerance)
+		f.Area += PolygonArea(region.Outline)
+		f.Perimeter += loopLength(region.Outline)
+		for h := range region.Holes {
+			region.Holes[h] = straightenLoop(region.Holes[h], tolerance)
+			f.Area += PolygonArea(region.Holes[h])
+			f.Perimeter += loopLength(region.Holes[h])
+		}
+	}
+	return f
+}
+
+// Contains checks if the point of the XY plane is covered by the footprint
+func (f Footprint) Contains(p Vec2) bool {
+	for _, region := range f.Regions {
+		if !pointInPolygon(p, region.Outline) {
+			continue
+		}
+		inHole := false
+		for _, hole := range region.Holes {
+			if pointInPolygon(p, hole) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// straightenLoop drops the points of the closed loop that are within tolerance of the line through
+// the points kept around them (Douglas-Peucker), keeping the loop as it is if it would collapse
+func straightenLoop(loop []Vec2, tolerance float32) []Vec2 {
+	if len(loop) < 4 {
+		return loop
+	}
+	//Split the loop at the point the furthest from the first one and straighten both halves
+	far := 0
+	for i := range loop {
+		if planeDistance(loop[i], loop[0]) > planeDistance(loop[far], loop[0]) {
+			far = i
+		}
+	}
+	keep := make([]bool, len(loop))
+	keep[0], keep[far] = true, true
+	var straighten func(from, to int)
+	straighten = func(from, to int) {
+		worst, worstDistance := -1, tolerance
+		for i := from + 1; i < to; i++ {
+			if d := distanceToSide(loop[i%len(loop)], loop[from%len(loop)], loop[to%len(loop)]); d > worstDistance {
+				worst, worstDistance = i, d
+			}
+		}
+		if worst >= 0 {
+			keep[worst%len(loop)] = true
+			straighten(from, worst)
+			straighten(worst, to)
+		}
+	}
+	straighten(0, far)
+	straighten(far, len(loop))
+	var straightened []Vec2
+	for i, p := range loop {
+		if keep[i] {
+			straightened = append(straightened, p)
+		}
+	}
+	if len(straightened) < 3 {
+		return loop
+	}
+	return straightened
+}
+
+// distanceToSide returns the distance from p to the segment between a and b of a plane
+func distanceToSide(p, a, b Vec2) float32 {
+	ab, ap := b.Sub(a), p.Sub(a)
+	t := float32(0)
+	if length2 := ab[0]*ab[0] + ab[1]*ab[1]; length2 > 0 {
+		t = min(max((ap[0]*ab[0]+ap[1]*ab[1])/length2, 0), 1)
+	}
+	return planeDistance(ap, Vec2{ab[0] * t, ab[1] * t})
+}
+
+// planeDistance returns the distance between two points of a plane
+func planeDistance(a, b Vec2) float32 {
+	return float32(math.Hypot(float64(a[0]-b[0]), float64(a[1]-b[1])))
+}
+
+// loopLength returns the length of the closed loop
+func loopLength(loop []Vec2) (length float32) {
+	for i := range loop {
+		length += planeDistance(loop[i], loop[(i+1)%len(loop)])
+	}
+	return length
+}
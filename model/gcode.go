@@ -0,0 +1,363 @@
+package model
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Toolpath is the path of the nozzle read from G-code, split in the layers it prints
+type Toolpath struct {
+	Layers []ToolpathLayer
+	//Length of filament pushed by the extruder
+	Filament float32
+}
+
+// ToolpathLayer is what the nozzle does at a height: the polylines along which it extrudes and those
+// along which it travels without extruding, in the order of the G-code
+type ToolpathLayer struct {
+	Z          float32
+	Extrusions [][]Vec3
+	Travels    [][]Vec3
+}
+
+// Commands moving the nozzle or setting its position
+var gcodeMoves = map[string]bool{"G0": true, "G1": true, "G2": true, "G3": true, "G92": true}
+
+// Farthest apart the segments of the arcs of G2 and G3 are, in radians
+const gcodeArcStep = math.Pi / 36
+
+// ReadGCode reads the moves of G-code for FFF printers: G0 and G1 moves and G2 and G3 arcs, in
+// absolute or relative coordinates (G90, G91) and extrusion (M82, M83), in millimeters or inches
+// (G21, G20), homing (G28) and set positions (G92). A move extrudes when it goes forward in the
+// filament, and a layer starts when the nozzle extrudes at a new height. Every other command is
+// ignored.
+func ReadGCode(r io.Reader) (*Toolpath, error) {
+	t := &Toolpath{}
+	var position [3]float64
+	var filament float64
+	absolute, absoluteE := true, true
+	unit := 1.0
+	//Whether the last segment added extruded, to tell if the next one continues its polyline
+	extruding := false
+	var layer *ToolpathLayer
+	addSegment := func(from, to Vec3, extrusion bool) {
+		if extrusion && (layer == nil || math.Abs(float64(to[2]-layer.Z)) > 1e-4) {
+			t.Layers = append(t.Layers, ToolpathLayer{Z: to[2]})
+			layer = &t.Layers[len(t.Layers)-1]
+			extruding = false
+		}
+		if layer == nil {
+			//Travels before the first layer are dropped
+			return
+		}
+		polylines := &layer.Travels
+		if extrusion {
+			polylines = &layer.Extrusions
+		}
+		if n := len(*polylines); n > 0 && extruding == extrusion && (*polylines)[n-1][len((*polylines)[n-1])-1] == from {
+			(*polylines)[n-1] = append((*polylines)[n-1], to)
+		} else {
+			*polylines = append(*polylines, []Vec3{from, to})
+		}
+		extruding = extrusion
+	}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		command, params, err := parseGCodeLine(scanner.Text())
+		//Only the moves need their parameters, other commands like M117 may carry any text
+		if err != nil && gcodeMoves[command] {
+			return nil, fmt.Errorf("G-code line %d: %v", line, err)
+		}
+		//The target of the axes given by the command
+		target := func() (to [3]float64) {
+			for k, axis := range "XYZ" {
+				to[k] = position[k]
+				if value, ok := params[axis]; ok {
+					if absolute {
+						to[k] = value * unit
+					} else {
+						to[k] += value * unit
+					}
+				}
+			}
+			return to
+		}
+		//The filament pushed by the command, negative when it retracts
+		extruded := func() (pushed float64) {
+			value, ok := params['E']
+			if !ok {
+				return 0
+			}
+			if absoluteE {
+				pushed, filament = value*unit-filament, value*unit
+			} else {
+				pushed = value * unit
+				filament += pushed
+			}
+			if pushed > 0 {
+				t.Filament += float32(pushed)
+			}
+			return pushed
+		}
+		switch command {
+		case "G0", "G1":
+			to := target()
+			extrusion := extruded() > 0
+			if to != position {
+				addSegment(gcodePoint(position), gcodePoint(to), extrusion)
+			}
+			position = to
+		case "G2", "G3":
+			to := target()
+			extrusion := extruded() > 0
+			points, err := gcodeArc(position, to, params, unit, command == "G3")
+			if err != nil {
+				return nil, fmt.Errorf("G-code line %d: %v", line, err)
+			}
+			from := gcodePoint(position)
+			for _, p := range points {
+				addSegment(from, p, extrusion)
+				from = p
+			}
+			position = to
+		case "G20":
+			unit = 25.4
+		case "G21":
+			unit = 1
+		case "G28":
+			_, x := params['X']
+			_, y := params['Y']
+			_, z := params['Z']
+			all := !x && !y && !z
+			for k, homed := range [3]bool{x, y, z} {
+				if all || homed {
+					position[k] = 0
+				}
+			}
+		case "G90":
+			absolute, absoluteE = true, true
+		case "G91":
+			absolute, absoluteE = false, false
+		case "G92":
+			for k, axis := range "XYZ" {
+				if value, ok := params[axis]; ok {
+					position[k] = value * unit
+				}
+			}
+			if value, ok := params['E']; ok {
+				filament = value * unit
+			}
+		case "M82":
+			absoluteE = true
+		case "M83":
+			absoluteE = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(t.Layers) == 0 {
+		return nil, errors.New("G-code has no extrusion moves")
+	}
+	return t, nil
+}
+
+// parseGCodeLine splits a line in its command, like G1, and the values of its parameters by letter,
+// dropping the comments, the line number and the checksum. Words may be written without spaces
+// between them, as in G1X10Y5.
+func parseGCodeLine(line string) (command string, params map[rune]float64, err error) {
+	if i := strings.IndexAny(line, ";*"); i >= 0 {
+		line = line[:i]
+	}
+	for {
+		open := strings.IndexByte(line, '(')
+		if open < 0 {
+			break
+		}
+		close := strings.IndexByte(line[open:], ')')
+		if close < 0 {
+			line = line[:open]
+			break
+		}
+		line = line[:open] + " " + line[open+close+1:]
+	}
+	params = make(map[rune]float64)
+	line = strings.ToUpper(line)
+	for i := 0; i < len(line); {
+		letter := line[i]
+		if letter == ' ' || letter == '\t' || letter == '\r' {
+			i++
+			continue
+		}
+		if letter < 'A' || letter > 'Z' {
+			return command, params, fmt.Errorf("unexpected %q", line[i:])
+		}
+		//The number runs until the next letter or space
+		end := i + 1
+		for end < len(line) && (line[end] < 'A' || line[end] > 'Z') && line[end] != ' ' && line[end] != '\t' && line[end] != '\r' {
+			end++
+		}
+		word := line[i+1 : end]
+		i = end
+		switch {
+		case letter == 'N':
+		case command == "" && (letter == 'G' || letter == 'M' || letter == 'T'):
+			//Drop the leading zeros of commands like G01
+			number := strings.TrimLeft(word, "0")
+			if number == "" || number[0] == '.' {
+				number = "0" + number
+			}
+			command = string(letter) + number
+		default:
+			value, err := strconv.ParseFloat(word, 64)
+			if err != nil && word != "" {
+				return command, params, fmt.Errorf("invalid number %q for %c", word, letter)
+			}
+			params[rune(letter)] = value
+		}
+	}
+	return command, params, nil
+}
+
+// gcodeArc returns the points along an arc from one position to another after its start, clockwise
+// for G2 and counter-clockwise for G3, around the center given by I and J or of radius R. The height
+// changes steadily along the arc, making helices.
+func gcodeArc(from, to [3]float64, params map[rune]float64, unit float64, counterClockwise bool) ([]Vec3, error) {
+	var cx, cy float64
+	if radius, ok := params['R']; ok {
+		//The center is on the bisector of the chord, the arc being the shorter one for a positive radius
+		radius *= unit
+		dx, dy := to[0]-from[0], to[1]-from[1]
+		chord := math.Hypot(dx, dy)
+		if chord == 0 || chord > 2*math.Abs(radius)+1e-6 {
+			return nil, errors.New("arc radius too small for its ends")
+		}
+		offset := math.Sqrt(math.Max(0, radius*radius-chord*chord/4))
+		if (radius < 0) == counterClockwise {
+			offset = -offset
+		}
+		cx, cy = (from[0]+to[0])/2-dy/chord*offset, (from[1]+to[1])/2+dx/chord*offset
+	} else {
+		cx, cy = from[0]+params['I']*unit, from[1]+params['J']*unit
+	}
+	radius := math.Hypot(from[0]-cx, from[1]-cy)
+	start := math.Atan2(from[1]-cy, from[0]-cx)
+	sweep := math.Atan2(to[1]-cy, to[0]-cx) - start
+	if counterClockwise && sweep <= 1e-9 {
+		sweep += 2 * math.Pi
+	} else if !counterClockwise && sweep >= -1e-9 {
+		sweep -= 2 * math.Pi
+	}
+	segments := max(int(math.Ceil(math.Abs(sweep)/gcodeArcStep)), 1)
+	points := make([]Vec3, 0, segments)
+	for s := 1; s < segments; s++ {
+		f := float64(s) / float64(segments)
+		angle := start + sweep*f
+		points = append(points, gcodePoint([3]float64{cx + radius*math.Cos(angle), cy + radius*math.Sin(angle), from[2] + (to[2]-from[2])*f}))
+	}
+	return append(points, gcodePoint(to)), nil
+}
+
+func gcodePoint(p [3]float64) Vec3 {
+	return Vec3{float32(p[0]), float32(p[1]), float32(p[2])}
+}
+
+// Bounds returns the box around the extrusions, the travels being left out
+func (t *Toolpath) Bounds() Box {
+	bounds := EmptyBox()
+	for _, layer := range t.Layers {
+		for _, polyline := range layer.Extrusions {
+			for _, p := range polyline {
+				bounds = bounds.Extend(p)
+			}
+		}
+	}
+	return bounds
+}
+
+// Translate moves every point of the toolpath by the offset
+func (t *Toolpath) Translate(offset Vec3) {
+	for l := range t.Layers {
+		t.Layers[l].Z += offset[2]
+		for _, polylines := range [][][]Vec3{t.Layers[l].Extrusions, t.Layers[l].Travels} {
+			for _, polyline := range polylines {
+				for i := range polyline {
+					polyline[i] = polyline[i].Add(offset)
+				}
+			}
+		}
+	}
+}
+
+// Paths returns the extrusions of the layers from first to last included, followed by their travels
+// if asked
+func (t *Toolpath) Paths(first, last int, travels bool) (paths [][]Vec3) {
+	first, last = max(first, 0), min(last, len(t.Layers)-1)
+	for l := first; l <= last; l++ {
+		paths = append(paths, t.Layers[l].Extrusions...)
+	}
+	if travels {
+		for l := first; l <= last; l++ {
+			paths = append(paths, t.Layers[l].Travels...)
+		}
+	}
+	return paths
+}
+
+// Length returns the length of the extrusions of the layer
+func (l ToolpathLayer) Length() (length float32) {
+	for _, polyline := range l.Extrusions {
+		for i := 1; i < len(polyline); i++ {
+			length += polyline[i].Sub(polyline[i-1]).Length()
+		}
+	}
+	return length
+}
+
+// ProjectPaths projects polylines, like those of a toolpath, in the viewport from the chosen
+// perspective, fitting them in it as ProjectModelEdges does with a model. The lines are drawn with
+// the depth of their points, the nearest ones winning, and never with an empty value so a flat layer
+// seen from the top still shows.
+func ProjectPaths(paths [][]Vec3, viewport Viewport, perspective Perspective) [][]float32 {
+	rotation := perspective.ViewRotation()
+	bounds, world := EmptyBox(), EmptyBox()
+	for _, path := range paths {
+		for _, point := range path {
+			bounds, world = bounds.Extend(rotation.MulVec(point)), world.Extend(point)
+		}
+	}
+	mins, maxs := viewport.Frame.bounds(rotation, world, bounds.Min, bounds.Max)
+	p := fitProjection(rotation, mins, maxs, viewport)
+	if p.scale == 0 {
+		//A single point or a line along the view
+		p.scale = 1
+	}
+	matrix := p.newMatrix()
+	if len(paths) == 0 {
+		return matrix
+	}
+	depth := func(point Vec3) float32 {
+		if p.dimensions[2] == 0 {
+			return 1
+		}
+		return 0.25 + 0.75*(rotation.MulVec(point)[2]-p.mins[2])/p.dimensions[2]
+	}
+	for _, path := range paths {
+		for i := 1; i < len(path); i++ {
+			row0, col0, _ := p.cell(path[i-1])
+			row1, col1, _ := p.cell(path[i])
+			value0, value1 := depth(path[i-1]), depth(path[i])
+			bresenham(row0, col0, row1, col1, func(row, col int, t float32) {
+				if row >= 0 && row < len(matrix) && col >= 0 && col < len(matrix[row]) {
+					matrix[row][col] = max(matrix[row][col], value0+(value1-value0)*t)
+				}
+			})
+		}
+	}
+	return matrix
+}
@@ -0,0 +1,199 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+)
+
+// GraphicsProtocol is a way of showing bitmaps inline in a terminal
+type GraphicsProtocol int
+
+const (
+	GraphicsNone GraphicsProtocol = iota
+	GraphicsSixel
+	GraphicsKitty
+	GraphicsITerm2
+)
+
+// Number of gray levels in the sixel palette
+const sixelLevels = 64
+
+// Size of the base64 chunks sent with the kitty protocol
+const kittyChunkSize = 4096
+
+// ParseGraphicsProtocol converts a name (none, sixel, kitty, iterm2 or auto) to a GraphicsProtocol,
+// auto detecting it for f
+func ParseGraphicsProtocol(name string, f *os.File) (GraphicsProtocol, error) {
+	switch name {
+	case "none":
+		return GraphicsNone, nil
+	case "sixel":
+		return GraphicsSixel, nil
+	case "kitty":
+		return GraphicsKitty, nil
+	case "iterm2", "iterm":
+		return GraphicsITerm2, nil
+	case "auto":
+		return DetectGraphicsProtocol(f), nil
+	}
+	return GraphicsNone, fmt.Errorf("Unknown graphics protocol %q", name)
+}
+
+// DetectGraphicsProtocol guesses the inline image protocol supported by the terminal behind f from
+// the environment. It returns GraphicsNone when f is not a terminal, inside tmux (which doesn't pass
+// the images through) or when nothing is recognized, so characters can be drawn instead.
+func DetectGraphicsProtocol(f *os.File) GraphicsProtocol {
+	if !isTerminal(f) || os.Getenv("TMUX") != "" {
+		return GraphicsNone
+	}
+	term := strings.ToLower(os.Getenv("TERM"))
+	program := strings.ToLower(os.Getenv("TERM_PROGRAM"))
+	switch {
+	case term == "xterm-kitty", os.Getenv("KITTY_WINDOW_ID") != "", program == "ghostty":
+		return GraphicsKitty
+	case program == "iterm.app", program == "wezterm", os.Getenv("LC_TERMINAL") == "iTerm2":
+		return GraphicsITerm2
+	case strings.Contains(term, "sixel"), strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "mlterm"):
+		return GraphicsSixel
+	}
+	return GraphicsNone
+}
+
+// EncodeInlineImage writes the escape sequences showing img in a terminal with the chosen protocol
+func EncodeInlineImage(w io.Writer, img image.Image, protocol GraphicsProtocol) error {
+	switch protocol {
+	case GraphicsSixel:
+		return encodeSixel(w, img)
+	case GraphicsKitty:
+		return encodeKitty(w, img)
+	case GraphicsITerm2:
+		return encodeITerm2(w, img)
+	}
+	return errors.New("No graphics protocol to draw with")
+}
+
+// encodeKitty sends the image as a PNG with the kitty graphics protocol, split in chunks
+func encodeKitty(w io.Writer, img image.Image) error {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return err
+	}
+	data := base64.StdEncoding.EncodeToString(encoded.Bytes())
+	var buffer bytes.Buffer
+	for start := 0; start < len(data); start += kittyChunkSize {
+		end, more := start+kittyChunkSize, 1
+		if end >= len(data) {
+			end, more = len(data), 0
+		}
+		//Only the first chunk carries the format and the action (transmit and display)
+		if start == 0 {
+			fmt.Fprintf(&buffer, "\x1b_Ga=T,f=100,m=%d;%s\x1b\\", more, data[start:end])
+		} else {
+			fmt.Fprintf(&buffer, "\x1b_Gm=%d;%s\x1b\\", more, data[start:end])
+		}
+	}
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+// encodeITerm2 sends the image as a PNG with the iTerm2 inline images protocol
+func encodeITerm2(w io.Writer, img image.Image) error {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\a", encoded.Len(), base64.StdEncoding.EncodeToString(encoded.Bytes()))
+	return err
+}
+
+// encodeSixel sends the image as sixels, using a palette of grays (renders have no other colors)
+func encodeSixel(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	//Quantize every pixel to a gray level, -1 being transparent
+	levels := make([]int, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			if a < 0x8000 {
+				levels[y*width+x] = -1
+				continue
+			}
+			luma := (299*r + 587*g + 114*b) / 1000
+			levels[y*width+x] = int(luma * (sixelLevels - 1) / 0xffff)
+		}
+	}
+	var buffer bytes.Buffer
+	//The second parameter leaves the pixels that are not set with the terminal background
+	buffer.WriteString("\x1bP0;1;0q")
+	fmt.Fprintf(&buffer, "\"1;1;%d;%d", width, height)
+	for i := 0; i < sixelLevels; i++ {
+		percent := i * 100 / (sixelLevels - 1)
+		fmt.Fprintf(&buffer, "#%d;2;%d;%d;%d", i, percent, percent, percent)
+	}
+	bits := make([]byte, width)
+	//Each band is 6 pixels tall and is drawn once per level present in it
+	for top := 0; top < height; top += 6 {
+		var present [sixelLevels]bool
+		for i := top * width; i < (top+6)*width && i < len(levels); i++ {
+			if levels[i] >= 0 {
+				present[levels[i]] = true
+			}
+		}
+		first := true
+		for level := range present {
+			if !present[level] {
+				continue
+			}
+			for x := range bits {
+				bits[x] = 0
+				for dy := 0; dy < 6 && top+dy < height; dy++ {
+					if levels[(top+dy)*width+x] == level {
+						bits[x] |= 1 << uint(dy)
+					}
+				}
+			}
+			//Go back to the start of the band to overlay the next level
+			if !first {
+				buffer.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(&buffer, "#%d", level)
+			writeSixelRuns(&buffer, bits)
+		}
+		buffer.WriteByte('-')
+	}
+	buffer.WriteString("\x1b\\")
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+// writeSixelRuns writes a row of sixels compressing the repeated ones and dropping the empty tail
+func writeSixelRuns(buffer *bytes.Buffer, bits []byte) {
+	end := len(bits)
+	for end > 0 && bits[end-1] == 0 {
+		end--
+	}
+	for i := 0; i < end; {
+		run := 1
+		for i+run < end && bits[i+run] == bits[i] {
+			run++
+		}
+		sixel := bits[i] + '?'
+		if run > 3 {
+			fmt.Fprintf(buffer, "!%d%c", run, sixel)
+		} else {
+			for k := 0; k < run; k++ {
+				buffer.WriteByte(sixel)
+			}
+		}
+		i += run
+	}
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"unicode/utf8"
+)
+
+// BinaryHeader returns the 80 bytes written at the start of a binary STL, the header padded with
+// zeros. Headers read from binary files come back byte for byte.
+func (m *Model) BinaryHeader() (header [binaryHeaderSize]byte) {
+	copy(header[:], m.Header)
+	return header
+}
+
+// SetBinaryHeader sets the header from the 80 bytes of a binary STL, dropping the zeros padding it
+func (m *Model) SetBinaryHeader(header [binaryHeaderSize]byte) {
+	m.Header = string(bytes.TrimRight(header[:], "\x00"))
+}
+
+// SetHeader sets the header to the text, cut to the 80 bytes binary STL files have room for without
+// splitting a character
+func (m *Model) SetHeader(text string) {
+	if len(text) > binaryHeaderSize {
+		end := binaryHeaderSize
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		text = text[:end]
+	}
+	m.Header = text
+}
+
+// HeaderMaterial is a material declared in a binary STL header
+type HeaderMaterial struct {
+	Diffuse, Specular, Ambient color.RGBA
+}
+
+// HeaderInfo is what some exporters declare in the header of binary STL files
+type HeaderInfo struct {
+	//Color of the whole model, from "COLOR=" followed by its red, green, blue and alpha bytes
+	//(Materialise Magics). Nil if not declared.
+	Color *color.RGBA
+	//Material of the whole model, from "MATERIAL=" followed by the diffuse, specular and ambient
+	//colors (Materialise Magics). Nil if not declared.
+	Material *HeaderMaterial
+}
+
+// ParseHeader looks in the header for the colors declared by some exporters. The header is padded
+// with zeros to 80 bytes first, the zeros trimmed when reading a binary STL may be part of a color.
+func ParseHeader(header string) (info HeaderInfo) {
+	if len(header) < binaryHeaderSize {
+		header += strings.Repeat("\x00", binaryHeaderSize-len(header))
+	}
+	readColor := func(b string) color.RGBA {
+		return color.RGBA{R: b[0], G: b[1], B: b[2], A: b[3]}
+	}
+	if i := indexAndRoom(header, "COLOR=", 4); i >= 0 {
+		c := readColor(header[i:])
+		info.Color = &c
+	}
+	if i := indexAndRoom(header, "MATERIAL=", 12); i >= 0 {
+		info.Material = &HeaderMaterial{
+			Diffuse:  readColor(header[i:]),
+			Specular: readColor(header[i+4:]),
+			Ambient:  readColor(header[i+8:]),
+		}
+	}
+	return info
+}
+
+// indexAndRoom returns where the bytes after the key start, -1 if the key is missing or there
+// aren't as many bytes after it
+func indexAndRoom(header, key string, size int) int {
+	i := strings.Index(header, key)
+	if i < 0 || i+len(key)+size > len(header) {
+		return -1
+	}
+	return i + len(key)
+}
+
+// FacetColor decodes the color some exporters store in the attribute bytes of a facet, 5 bits for
+// each of red, green and blue. When the header declares a color (Materialise Magics), they are red,
+// green and blue from the low bits up, and the top bit set means the facet has the color of the whole
+// model. Otherwise (VisCAM, SolidView) they are blue, green and red, and the top bit set means the
+// color is valid. It returns false when the facet has no color of its own.
+func (info HeaderInfo) FacetColor(t *Triangle) (c color.RGBA, ok bool) {
+	attributes := t.AttrByteCount
+	valid := attributes&0x8000 != 0
+	if info.Color != nil {
+		valid = !valid
+	}
+	if !valid {
+		return c, false
+	}
+	//Spread the 5 bits over a whole byte
+	channel := func(shift uint) uint8 {
+		value := uint8(attributes >> shift & 0x1f)
+		return value<<3 | value>>2
+	}
+	c = color.RGBA{R: channel(10), G: channel(5), B: channel(0), A: 0xff}
+	if info.Color != nil {
+		c.R, c.B = c.B, c.R
+	}
+	return c, true
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"errors"
+	"math"
+)
+
+// DrainHole is a round hole through the wall of a hollowed model, letting the resin or the powder
+// inside flow out
+type DrainHole struct {
+	//Point on the outer surface where the hole starts
+	Position Vec3
+	//Direction of the hole into the model, the opposite of the normal at Position if zero
+	Direction Vec3
+	Radius    float32
+}
+
+// HollowOptions tunes HollowWith
+type HollowOptions struct {
+	//Thickness of the wall left around the cavity
+	WallThickness float32
+	//Size of the cells of the distance field the cavity is built from, half the wall thickness if 0
+	//(but never so small that the longest side of the model takes more than maxFieldCells cells)
+	CellSize float32
+	//Holes from the surface to the cavity. Cutting them rebuilds the whole surface from the distance
+	//field, at the resolution of the cells, instead of keeping the original facets.
+	DrainHoles []DrainHole
+}
+
+// Hollow adds a cavity inside the watertight model leaving walls of the given thickness, like
+// HollowWith without drain holes
+func Hollow(m *Model, wallThickness float64) error {
+	return HollowWith(m, HollowOptions{WallThickness: float32(wallThickness)})
+}
+
+// HollowWith adds a cavity inside the watertight model made of the points deeper than the wall
+// thickness, found with a distance field sampled on a grid. The cavity is added as an inner shell
+// facing inwards, so the volume of the model becomes the volume of the walls. A model too thin to
+// leave any cavity is not modified.
+func HollowWith(m *Model, opts HollowOptions) error {
+	if opts.WallThickness <= 0 {
+		return errors.New("Wall thickness must be positive")
+	}
+	if !NewTopology(m).IsWatertight() {
+		return errors.New("Model must be watertight to be hollowed")
+	}
+	bvh := NewBVH(m)
+	cell := opts.CellSize
+	if cell <= 0 {
+		cell = opts.WallThickness / 2
+	}
+	cell = fieldCell(bvh.Bounds(), cell)
+	grid := newDistanceGrid(bvh.Bounds(), cell, 2)
+	bvh.signedDistance(grid)
+	if len(opts.DrainHoles) == 0 {
+		//Inside the cavity the distance is below minus the wall thickness
+		cavity := grid.isosurface(-opts.WallThickness)
+		//It faces the walls around it, flip it to face the empty space
+		for i := range cavity {
+			t := &cavity[i]
+			t.Vertices[1], t.Vertices[2] = t.Vertices[2], t.Vertices[1]
+			t.Normal = Vec3(t.Normal).Scale(-1)
+		}
+		m.Invalidate()
+		m.Triangles = append(m.Triangles, cavity...)
+		m.NumTriangles = uint32(len(m.Triangles))
+		if m.Attributes != nil {
+			m.Attributes = growAttributes(m.Attributes, len(m.Triangles))
+		}
+		return nil
+	}
+	holes := make([]DrainHole, len(opts.DrainHoles))
+	for i, hole := range opts.DrainHoles {
+		if hole.Radius <= 0 {
+			return errors.New("Drain holes must have a positive radius")
+		}
+		if hole.Direction == (Vec3{}) {
+			if hit, ok := bvh.Nearest(hole.Position); ok {
+				hole.Direction = triangleNormal(&m.Triangles[hit.Triangle]).Scale(-1)
+			}
+		}
+		hole.Direction = hole.Direction.Normalize()
+		holes[i] = hole
+	}
+	//The walls are inside the model and not in the cavity, and the holes go from a cell outside
+	//the surface to a cell past the inner side of the wall
+	for n, distance := range grid.values {
+		i, j, k := n%grid.size[0], n/grid.size[0]%grid.size[1], n/(grid.size[0]*grid.size[1])
+		p := grid.point(i, j, k)
+		wall := float32(math.Max(float64(distance), float64(-distance-opts.WallThickness)))
+		for _, hole := range holes {
+			start := hole.Position.Sub(hole.Direction.Scale(cell))
+			end := hole.Position.Add(hole.Direction.Scale(opts.WallThickness + cell))
+			if inHole := hole.Radius - segmentDistance(p, start, end); inHole > wall {
+				wall = inHole
+			}
+		}
+		grid.values[n] = wall
+	}
+	m.Invalidate()
+	m.Triangles = grid.isosurface(0)
+	m.NumTriangles = uint32(len(m.Triangles))
+	m.ClearAttributes()
+	return nil
+}
+
+// segmentDistance returns the distance from p to the segment from a to b
+func segmentDistance(p, a, b Vec3) float32 {
+	ab := b.Sub(a)
+	t := float32(0)
+	if length := ab.Dot(ab); length > 0 {
+		t = float32(math.Max(0, math.Min(1, float64(p.Sub(a).Dot(ab)/length))))
+	}
+	return p.Sub(a.Add(ab.Scale(t))).Length()
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// hullFace is a face of a convex hull being built, with its outward plane
+type hullFace struct {
+	vertices [3]int
+	normal   [3]float64
+	offset   float64
+}
+
+// ConvexHull returns the smallest convex solid containing all the points, as a watertight model
+// facing outwards. It fails when the points don't span a volume.
+func ConvexHull(points []Vec3) (*Model, error) {
+	return ConvexHullWith(points, DefaultTolerances)
+}
+
+// ConvexHullWith is ConvexHull taking the points closer to a face than tolerances.Relative times the
+// size of their bounds as lying on it
+func ConvexHullWith(points []Vec3, tolerances Tolerances) (*Model, error) {
+	//Work in float64 and without repeated points so the visibility tests are reliable
+	var unique [][3]float64
+	seen := make(map[Vec3]bool)
+	for _, p := range points {
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, [3]float64{float64(p[0]), float64(p[1]), float64(p[2])})
+		}
+	}
+	if len(unique) < 4 {
+		return nil, errors.New("Convex hull needs at least 4 distinct points")
+	}
+	bounds := EmptyBox()
+	for _, p := range points {
+		bounds = bounds.Extend(p)
+	}
+	//The points come from float32 coordinates, closer than their rounding to a face they are on it
+	epsilon := float64(tolerances.Epsilon(bounds))
+	newFace := func(a, b, c int) hullFace {
+		normal := cross64(sub64(unique[b], unique[a]), sub64(unique[c], unique[a]))
+		length := math.Sqrt(dot64(normal, normal))
+		for k := range normal {
+			normal[k] /= length
+		}
+		return hullFace{vertices: [3]int{a, b, c}, normal: normal, offset: dot64(normal, unique[a])}
+	}
+	above := func(f hullFace, p [3]float64) float64 {
+		return dot64(f.normal, p) - f.offset
+	}
+	start, ok := hullTetrahedron(unique, epsilon)
+	if !ok {
+		return nil, errors.New("Points of a convex hull can't be all on a plane")
+	}
+	//Orient the faces of the first tetrahedron away from its center
+	var center [3]float64
+	for _, v := range start {
+		for k := range center {
+			center[k] += unique[v][k] / 4
+		}
+	}
+	var faces []hullFace
+	for _, corners := range [4][3]int{{0, 1, 2}, {0, 3, 1}, {0, 2, 3}, {1, 3, 2}} {
+		face := newFace(start[corners[0]], start[corners[1]], start[corners[2]])
+		if above(face, center) > 0 {
+			face = newFace(start[corners[0]], start[corners[2]], start[corners[1]])
+		}
+		faces = append(faces, face)
+	}
+	//Add the farthest points from the center first, the hull grows faster and more of the others are
+	//found inside it right away
+	order := make([]int, len(unique))
+	distances := make([]float64, len(unique))
+	for i := range order {
+		order[i] = i
+		distances[i] = dot64(sub64(unique[i], center), sub64(unique[i], center))
+	}
+	sort.SliceStable(order, func(i, j int) bool { return distances[order[i]] > distances[order[j]] })
+	for _, p := range order {
+		if p == start[0] || p == start[1] || p == start[2] || p == start[3] {
+			continue
+		}
+		seen := false
+		for _, face := range faces {
+			if above(face, unique[p]) > epsilon {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			continue
+		}
+		//The faces seen from the point go, the edges around them are joined to the point
+		var kept, seenFrom []hullFace
+		visible := make(map[[2]int]bool)
+		for _, face := range faces {
+			if above(face, unique[p]) <= epsilon {
+				kept = append(kept, face)
+				continue
+			}
+			seenFrom = append(seenFrom, face)
+			for j := range face.vertices {
+				visible[[2]int{face.vertices[j], face.vertices[(j+1)%3]}] = true
+			}
+		}
+		for _, face := range seenFrom {
+			for j := range face.vertices {
+				a, b := face.vertices[j], face.vertices[(j+1)%3]
+				if !visible[[2]int{b, a}] {
+					kept = append(kept, newFace(a, b, p))
+				}
+			}
+		}
+		faces = kept
+	}
+	hull := &Model{Header: "Convex hull", Triangles: make([]Triangle, len(faces))}
+	for i, face := range faces {
+		t := &hull.Triangles[i]
+		for j, v := range face.vertices {
+			t.Vertices[j] = [3]float32{float32(unique[v][0]), float32(unique[v][1]), float32(unique[v][2])}
+		}
+		t.Normal = [3]float32{float32(face.normal[0]), float32(face.normal[1]), float32(face.normal[2])}
+	}
+	hull.NumTriangles = uint32(len(hull.Triangles))
+	return hull, nil
+}
+
+// hullTetrahedron picks four points far apart to start a convex hull from: the lowest along X, the
+// farthest from it, the farthest from the line through both and the farthest from their plane
+func hullTetrahedron(points [][3]float64, epsilon float64) (start [4]int, ok bool) {
+	farthest := func(distance func(p [3]float64) float64) (best int, bestDistance float64) {
+		for i, p := range points {
+			if d := distance(p); d > bestDistance {
+				best, bestDistance = i, d
+			}
+		}
+		return best, bestDistance
+	}
+	for i, p := range points {
+		if p[0] < points[start[0]][0] {
+			start[0] = i
+		}
+	}
+	a := points[start[0]]
+	var d float64
+	start[1], d = farthest(func(p [3]float64) float64 { return math.Sqrt(dot64(sub64(p, a), sub64(p, a))) })
+	if d <= epsilon {
+		return start, false
+	}
+	b, ab := points[start[1]], d
+	start[2], d = farthest(func(p [3]float64) float64 {
+		c := cross64(sub64(b, a), sub64(p, a))
+		return math.Sqrt(dot64(c, c)) / ab
+	})
+	if d <= epsilon {
+		return start, false
+	}
+	normal := cross64(sub64(b, a), sub64(points[start[2]], a))
+	length := math.Sqrt(dot64(normal, normal))
+	start[3], d = farthest(func(p [3]float64) float64 { return math.Abs(dot64(normal, sub64(p, a))) / length })
+	return start, d > epsilon
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ImageFormat is the encoding used when exporting a render
+type ImageFormat int
+
+const (
+	ImagePNG ImageFormat = iota
+	ImageJPEG
+)
+
+// ImageFormatFromPath picks the image format from the extension of a file name
+func ImageFormatFromPath(path string) (ImageFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return ImagePNG, nil
+	case ".jpg", ".jpeg":
+		return ImageJPEG, nil
+	}
+	return ImagePNG, errors.New("Image format must be png or jpeg")
+}
+
+// ImageViewport returns a viewport of width x height square pixels, or width x width when height is 0
+func ImageViewport(width, height int) Viewport {
+	if height <= 0 {
+		height = width
+	}
+	return Viewport{Width: width, Height: height, CellAspect: 1}
+}
+
+// DrawMatrixImage paints a matrix as a gray image, one pixel per cell and brighter for higher
+// values. Empty cells are painted with the background.
+func DrawMatrixImage(matrix [][]float32, background color.Color) *image.RGBA {
+	return DrawMatrixImageHighlighted(matrix, nil, background)
+}
+
+// DrawMatrixImageHighlighted paints a matrix like DrawMatrixImage, in red for the cells set in the
+// mask (which may be nil)
+func DrawMatrixImageHighlighted(matrix [][]float32, mask [][]bool, background color.Color) *image.RGBA {
+	width := 0
+	if len(matrix) > 0 {
+		width = len(matrix[0])
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, len(matrix)))
+	for i := range matrix {
+		for j := range matrix[i] {
+			if matrix[i][j] <= 0 {
+				img.Set(j, i, background)
+				continue
+			}
+			level := uint8(grayIntensity(matrix[i][j])*255 + 0.5)
+			if highlighted(mask, i, j) {
+				img.Set(j, i, color.RGBA{level, level / 4, level / 4, 255})
+				continue
+			}
+			img.Set(j, i, color.RGBA{level, level, level, 255})
+		}
+	}
+	return img
+}
+
+// MaxSupersample is the largest factor renders are supersampled by, each one taking its square in memory
+const MaxSupersample = 8
+
+// Downsample shrinks an image rendered factor times larger in each direction, averaging each
+// factor x factor block of pixels into one, which smooths the jagged edges of the triangles
+// (supersampling). Partially covered pixels at the edges of the model blend with the transparent
+// background, as the pixels of image.RGBA have their alpha premultiplied.
+func Downsample(img *image.RGBA, factor int) *image.RGBA {
+	if factor <= 1 {
+		return img
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx()/factor, bounds.Dy()/factor
+	small := image.NewRGBA(image.Rect(0, 0, width, height))
+	samples := uint32(factor * factor)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var sum [4]uint32
+			for dy := 0; dy < factor; dy++ {
+				offset := img.PixOffset(bounds.Min.X+x*factor, bounds.Min.Y+y*factor+dy)
+				for dx := 0; dx < factor; dx++ {
+					for c := range sum {
+						sum[c] += uint32(img.Pix[offset+4*dx+c])
+					}
+				}
+			}
+			offset := small.PixOffset(x, y)
+			for c := range sum {
+				small.Pix[offset+c] = uint8((sum[c] + samples/2) / samples)
+			}
+		}
+	}
+	return small
+}
+
+// EncodeImage writes the image to w in the chosen format
+func EncodeImage(w io.Writer, img image.Image, format ImageFormat) error {
+	if format == ImageJPEG {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+	}
+	return png.Encode(w, img)
+}
+
+// DrawMatrixDepth16 converts a matrix to a 16 bit grayscale depth map, one pixel per cell, where
+// the value goes from black for the farthest point (and empty cells) to white for the closest one
+func DrawMatrixDepth16(matrix [][]float32) *image.Gray16 {
+	width := 0
+	if len(matrix) > 0 {
+		width = len(matrix[0])
+	}
+	img := image.NewGray16(image.Rect(0, 0, width, len(matrix)))
+	for i := range matrix {
+		for j := range matrix[i] {
+			value := matrix[i][j]
+			if value <= 0 {
+				continue
+			}
+			if value > 1 {
+				value = 1
+			}
+			img.SetGray16(j, i, color.Gray16{uint16(value*65535 + 0.5)})
+		}
+	}
+	return img
+}
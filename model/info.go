@@ -0,0 +1,65 @@
+package model
+
+import "fmt"
+
+// Info is a summary of the size and the soundness of a model
+type Info struct {
+	Header           string  `json:"header"`
+	Name             string  `json:"name,omitempty"`
+	Triangles        int     `json:"triangles"`
+	Vertices         int     `json:"vertices"`
+	Edges            int     `json:"edges"`
+	Shells           int     `json:"shells"`
+	Mins             Vec3    `json:"mins"`
+	Maxs             Vec3    `json:"maxs"`
+	Dimensions       Vec3    `json:"dimensions"`
+	SurfaceArea      float32 `json:"surfaceArea"`
+	Volume           float32 `json:"volume"`
+	Watertight       bool    `json:"watertight"`
+	BoundaryEdges    int     `json:"boundaryEdges"`
+	NonManifoldEdges int     `json:"nonManifoldEdges"`
+	FlippedEdges     int     `json:"flippedEdges"`
+	DegenerateFacets int     `json:"degenerateFacets"`
+	//Facets needing supports when printed upright (see FindOverhangs)
+	OverhangFacets int     `json:"overhangFacets"`
+	OverhangArea   float32 `json:"overhangArea"`
+}
+
+// Inspect gathers the Info of a model
+func Inspect(m *Model) (info Info) {
+	topology := NewTopology(m)
+	info.Header, info.Name = m.Header, m.Name
+	info.Triangles = len(m.Triangles)
+	info.Vertices = len(topology.Vertices)
+	info.Edges = topology.NumEdges()
+	info.Shells = len(topology.Shells())
+	if len(m.Triangles) > 0 {
+		bounds := m.Bounds()
+		info.Mins, info.Maxs = bounds.Min, bounds.Max
+		info.Dimensions = m.Dimensions()
+	}
+	info.SurfaceArea = m.SurfaceArea()
+	info.Volume = m.Volume()
+	info.Watertight = topology.IsWatertight()
+	info.BoundaryEdges = topology.BoundaryEdges()
+	info.NonManifoldEdges = topology.NonManifoldEdges()
+	info.FlippedEdges = topology.InconsistentEdges()
+	info.DegenerateFacets = len(topology.DegenerateFaces())
+	overhangs := FindOverhangs(m, OverhangOptions{})
+	info.OverhangFacets, info.OverhangArea = len(overhangs.Facets), overhangs.Area
+	return info
+}
+
+// String prints the info in the same style as the Model
+func (info Info) String() string {
+	name := ""
+	if info.Name != "" {
+		name = "Name: " + info.Name + "\n"
+	}
+	return name + fmt.Sprintf("Header: %v\nTriangles: %v\nVertices: %v\nEdges: %v\nShells: %v\nDimensions: %v\nMins: %v\nMaxs: %v\n"+
+		"Surface area: %v\nVolume: %v\nWatertight: %v\nBoundary edges: %v\nNon-manifold edges: %v\nFlipped edges: %v\nDegenerate facets: %v\n"+
+		"Overhang facets: %v\nOverhang area: %v\n",
+		info.Header, info.Triangles, info.Vertices, info.Edges, info.Shells, info.Dimensions, info.Mins, info.Maxs,
+		info.SurfaceArea, info.Volume, info.Watertight, info.BoundaryEdges, info.NonManifoldEdges, info.FlippedEdges, info.DegenerateFacets,
+		info.OverhangFacets, info.OverhangArea)
+}
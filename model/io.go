@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteTo encodes the model as a binary STL like WriteBinarySTL, implementing io.WriterTo
+func (m *Model) WriteTo(w io.Writer) (n int64, err error) {
+	counter := &countingWriter{w: w}
+	err = WriteBinarySTL(counter, m)
+	return counter.n, err
+}
+
+// ReadFrom replaces the model with the binary STL read from r, implementing io.ReaderFrom. It stops
+// after the triangles announced by the header, leaving anything after them in r, and reuses the room
+// of the triangles of the model like DecodeInto. The triangles are read as they come rather than
+// allocated up front for the count of the header, which doesn't have to be trusted.
+func (m *Model) ReadFrom(r io.Reader) (n int64, err error) {
+	m.Reset()
+	header := make([]byte, binaryHeaderSize+4)
+	read, err := io.ReadFull(r, header)
+	n += int64(read)
+	if err != nil {
+		//Even an empty reader is missing the header, not at the end of a file
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+	var headerBytes [binaryHeaderSize]byte
+	copy(headerBytes[:], header)
+	m.SetBinaryHeader(headerBytes)
+	records, err := readRecords(r, binary.LittleEndian.Uint32(header[binaryHeaderSize:]), func(record []byte) {
+		var t Triangle
+		decodeTriangle(record, &t, binary.LittleEndian)
+		m.Triangles = append(m.Triangles, t)
+	})
+	m.NumTriangles = uint32(len(m.Triangles))
+	return n + records, err
+}
+
+// readRecords reads count triangle records of a binary STL from r, calling fn with each of them, and
+// returns how many bytes it read. Several records are read at once but never past the last one, so r
+// is left right after them. Running out of records before count is an io.ErrUnexpectedEOF.
+func readRecords(r io.Reader, count uint32, fn func(record []byte)) (n int64, err error) {
+	const chunkRecords = 1 << 10
+	chunk := make([]byte, binaryTriangleSize*min(count, chunkRecords))
+	for left := count; left > 0; {
+		records := min(left, chunkRecords)
+		read, err := io.ReadFull(r, chunk[:binaryTriangleSize*records])
+		n += int64(read)
+		for i := 0; i+binaryTriangleSize <= read; i += binaryTriangleSize {
+			fn(chunk[i : i+binaryTriangleSize])
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, err
+		}
+		left -= records
+	}
+	return n, nil
+}
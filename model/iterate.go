@@ -0,0 +1,29 @@
+package model
+
+// Each calls fn with every triangle of the model and its index, in order. The triangles must not be
+// changed through it, use Map for that.
+func (m *Model) Each(fn func(i int, t *Triangle)) {
+	for i := range m.Triangles {
+		fn(i, &m.Triangles[i])
+	}
+}
+
+// Map replaces every triangle of the model in place with the one returned by fn
+func (m *Model) Map(fn func(t Triangle) Triangle) {
+	m.Invalidate()
+	for i := range m.Triangles {
+		m.Triangles[i] = fn(m.Triangles[i])
+	}
+}
+
+// Filter returns a new model with a copy of the triangles for which keep returns true, in order,
+// keeping the header and the name
+func (m *Model) Filter(keep func(i int, t *Triangle) bool) *Model {
+	var indices []int
+	for i := range m.Triangles {
+		if keep(i, &m.Triangles[i]) {
+			indices = append(indices, i)
+		}
+	}
+	return m.Subset(indices)
+}
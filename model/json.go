@@ -0,0 +1,192 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+)
+
+// JSONOptions tunes WriteJSON
+type JSONOptions struct {
+	//Significant digits of the coordinates and normals, as many as needed to read back exactly the
+	//same values if 0. Fewer digits make smaller files of rounded coordinates.
+	Precision int
+	//Put every triangle on its own line
+	Indent bool
+}
+
+// jsonTriangle is the JSON form of a Triangle, see WriteJSON
+type jsonTriangle struct {
+	Normal     [3]float32    `json:"normal"`
+	Vertices   [3][3]float32 `json:"vertices"`
+	Attributes uint16        `json:"attributes,omitempty"`
+	jsonAttributes
+}
+
+// jsonAttributes is the JSON form of the Attributes of a triangle, added to its object
+type jsonAttributes struct {
+	Color    *[4]uint8         `json:"color,omitempty"`
+	Material int               `json:"material,omitempty"`
+	Region   string            `json:"region,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// jsonModel is the JSON form of a Model, see WriteJSON
+type jsonModel struct {
+	Header    string         `json:"header"`
+	Name      string         `json:"name,omitempty"`
+	Triangles []jsonTriangle `json:"triangles"`
+}
+
+// WriteJSON encodes the model as a JSON object:
+//
+//	{
+//	  "header": "text",
+//	  "name": "solid name",
+//	  "triangles": [
+//	    {"normal": [x, y, z], "vertices": [[x, y, z], [x, y, z], [x, y, z]], "attributes": 0,
+//	     "color": [r, g, b, a], "material": 1, "region": "text", "tags": {"key": "value"}}
+//	  ]
+//	}
+//
+// The name (the solid name of ASCII STL files), the attributes (the attribute byte count of binary
+// STL files) and the color, material, region and tags of the Attributes of the triangles are left out
+// when empty. JSON has no NaN nor infinite numbers, models with them can't be encoded.
+func WriteJSON(w io.Writer, m *Model, opts JSONOptions) error {
+	buffered := bufio.NewWriter(w)
+	header, _ := json.Marshal(m.Header)
+	buffered.WriteString(`{"header":`)
+	buffered.Write(header)
+	if m.Name != "" {
+		name, _ := json.Marshal(m.Name)
+		buffered.WriteString(`,"name":`)
+		buffered.Write(name)
+	}
+	buffered.WriteString(`,"triangles":[`)
+	var line []byte
+	for i := range m.Triangles {
+		if i > 0 {
+			buffered.WriteByte(',')
+		}
+		if opts.Indent {
+			buffered.WriteString("\n  ")
+		}
+		var err error
+		if line, err = appendTriangleJSON(line[:0], &m.Triangles[i], opts.Precision); err != nil {
+			return err
+		}
+		line = appendAttributesJSON(line, m.TriangleAttributes(i))
+		buffered.Write(line)
+	}
+	if opts.Indent && len(m.Triangles) > 0 {
+		buffered.WriteByte('\n')
+	}
+	buffered.WriteString("]}\n")
+	return buffered.Flush()
+}
+
+// CreateFromJSON reads a model written by WriteJSON
+func CreateFromJSON(r io.Reader) (m Model, err error) {
+	err = json.NewDecoder(r).Decode(&m)
+	return m, err
+}
+
+// MarshalJSON encodes the model with WriteJSON, keeping all the digits
+func (m Model) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	err := WriteJSON(&buffer, &m, JSONOptions{})
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), err
+}
+
+// UnmarshalJSON decodes a model encoded by MarshalJSON or WriteJSON
+func (m *Model) UnmarshalJSON(data []byte) error {
+	var decoded jsonModel
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	m.Invalidate()
+	m.Header, m.Name, m.Triangles, m.Attributes = decoded.Header, decoded.Name, make([]Triangle, len(decoded.Triangles)), nil
+	for i, t := range decoded.Triangles {
+		m.Triangles[i] = Triangle{Normal: t.Normal, Vertices: t.Vertices, AttrByteCount: t.Attributes}
+		if a := t.jsonAttributes; a.Color != nil || a.Material != 0 || a.Region != "" || len(a.Tags) > 0 {
+			attributes := Attributes{Material: a.Material, Region: a.Region, Tags: a.Tags}
+			if a.Color != nil {
+				attributes.Color = color.RGBA{a.Color[0], a.Color[1], a.Color[2], a.Color[3]}
+			}
+			m.SetTriangleAttributes(i, attributes)
+		}
+	}
+	m.NumTriangles = uint32(len(m.Triangles))
+	return nil
+}
+
+// appendAttributesJSON adds the attributes to the JSON object of their triangle ending b, leaving out
+// the empty ones
+func appendAttributesJSON(b []byte, a Attributes) []byte {
+	encoded := jsonAttributes{Material: a.Material, Region: a.Region, Tags: a.Tags}
+	if a.Color.A != 0 {
+		encoded.Color = &[4]uint8{a.Color.R, a.Color.G, a.Color.B, a.Color.A}
+	}
+	fields, _ := json.Marshal(encoded)
+	if len(fields) <= 2 {
+		return b
+	}
+	b = append(b[:len(b)-1], ',')
+	return append(b, fields[1:]...)
+}
+
+// MarshalJSON encodes the triangle as in WriteJSON, keeping all the digits
+func (t Triangle) MarshalJSON() ([]byte, error) {
+	return appendTriangleJSON(nil, &t, 0)
+}
+
+// UnmarshalJSON decodes a triangle encoded by MarshalJSON
+func (t *Triangle) UnmarshalJSON(data []byte) error {
+	var decoded jsonTriangle
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*t = Triangle{Normal: decoded.Normal, Vertices: decoded.Vertices, AttrByteCount: decoded.Attributes}
+	return nil
+}
+
+// appendTriangleJSON appends the JSON object of a triangle with the given significant digits
+func appendTriangleJSON(b []byte, t *Triangle, precision int) ([]byte, error) {
+	if precision <= 0 {
+		precision = -1
+	}
+	var err error
+	appendVector := func(b []byte, v [3]float32) []byte {
+		b = append(b, '[')
+		for k, f := range v {
+			if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+				err = errors.New("NaN and infinite coordinates can't be encoded as JSON")
+			}
+			if k > 0 {
+				b = append(b, ',')
+			}
+			b = strconv.AppendFloat(b, float64(f), 'g', precision, 32)
+		}
+		return append(b, ']')
+	}
+	b = append(b, `{"normal":`...)
+	b = appendVector(b, t.Normal)
+	b = append(b, `,"vertices":[`...)
+	for j, v := range t.Vertices {
+		if j > 0 {
+			b = append(b, ',')
+		}
+		b = appendVector(b, v)
+	}
+	b = append(b, ']')
+	if t.AttrByteCount != 0 {
+		b = append(b, `,"attributes":`...)
+		b = strconv.AppendUint(b, uint64(t.AttrByteCount), 10)
+	}
+	return append(b, '}'), err
+}
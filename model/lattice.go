@@ -0,0 +1,144 @@
+package model
+
+import (
+	"errors"
+	"math"
+)
+
+// LatticePattern is the repeating structure InfillWith fills a model with
+type LatticePattern int
+
+const (
+	//Triply periodic minimal surface thickened into a wall, strong in every direction and without
+	//flat overhangs, so it prints without supports
+	GyroidLattice LatticePattern = iota
+	//Square struts along the three axes meeting at the corners of cubic cells
+	GridLattice
+)
+
+// LatticeOptions tunes InfillWith
+type LatticeOptions struct {
+	Pattern LatticePattern
+	//Fraction of the inside of the model filled by the lattice, between 0 and 1
+	Density float32
+	//Size of the cube the pattern repeats in, 10 if 0
+	Period float32
+	//Thickness of the solid wall kept around the lattice, none if 0
+	WallThickness float32
+	//Size of the cells of the distance field the surface is built from, half the thickness of the
+	//lattice and at most an eighth of the period if 0 (but never so small that the longest side of the model takes more than maxFieldCells cells)
+	CellSize float32
+}
+
+// Samples along each side of a period when finding the thickness giving a density
+const latticeSamples = 24
+
+// Infill replaces the inside of the watertight model with a gyroid lattice of the given density,
+// like InfillWith with a wall of 1
+func Infill(m *Model, density float64) error {
+	return InfillWith(m, LatticeOptions{Density: float32(density), WallThickness: 1})
+}
+
+// InfillWith replaces the watertight model with a wall following its surface and a lattice filling
+// the inside, merged in a single printable surface rebuilt from a distance field. The lattice is as
+// thick as needed to fill the density of the inside, measured over whole periods.
+func InfillWith(m *Model, opts LatticeOptions) error {
+	if opts.Density <= 0 || opts.Density >= 1 {
+		return errors.New("Density must be between 0 and 1")
+	}
+	if opts.Period < 0 || opts.WallThickness < 0 || opts.CellSize < 0 {
+		return errors.New("Period, wall thickness and cell size can't be negative")
+	}
+	if opts.Pattern != GyroidLattice && opts.Pattern != GridLattice {
+		return errors.New("Unknown lattice pattern")
+	}
+	if !NewTopology(m).IsWatertight() {
+		return errors.New("Model must be watertight to be filled")
+	}
+	period := opts.Period
+	if period == 0 {
+		period = 10
+	}
+	lattice, scale := latticeField(opts.Pattern)
+	thickness := latticeThickness(lattice, opts.Density)
+	bvh := NewBVH(m)
+	cell := opts.CellSize
+	if cell == 0 {
+		//Two cells across the walls or struts of the lattice
+		cell = min(thickness*scale*period, period/8)
+	}
+	cell = fieldCell(bvh.Bounds(), cell)
+	grid := newDistanceGrid(bvh.Bounds(), cell, 1)
+	bvh.signedDistance(grid)
+	//Solid inside the model where it is either in the wall or in the lattice, with the lattice
+	//scaled to be roughly a distance so it blends with the wall
+	for n, distance := range grid.values {
+		i, j, k := n%grid.size[0], n/grid.size[0]%grid.size[1], n/(grid.size[0]*grid.size[1])
+		structure := (lattice(grid.point(i, j, k).Scale(1/period)) - thickness) * scale * period
+		if opts.WallThickness > 0 {
+			structure = float32(math.Min(float64(structure), float64(-distance-opts.WallThickness)))
+		}
+		grid.values[n] = float32(math.Max(float64(distance), float64(structure)))
+	}
+	triangles := grid.isosurface(0)
+	if len(triangles) == 0 {
+		return errors.New("Lattice is too thin for the cells of the distance field")
+	}
+	m.Invalidate()
+	m.Triangles = triangles
+	m.NumTriangles = uint32(len(m.Triangles))
+	m.ClearAttributes()
+	return nil
+}
+
+// latticeField returns the field of a pattern at a point measured in periods, solid where it is below
+// the thickness, and how much a change of it moves the surface in periods
+func latticeField(pattern LatticePattern) (field func(p Vec3) float32, scale float32) {
+	if pattern == GridLattice {
+		//Distance along the other two axes to the nearest strut of each axis, the same for every
+		//point of a square around it
+		return func(p Vec3) float32 {
+			var d [3]float64
+			for k := range d {
+				d[k] = math.Abs(float64(p[k]) - math.Round(float64(p[k])))
+			}
+			return float32(math.Min(math.Max(d[0], d[1]), math.Min(math.Max(d[1], d[2]), math.Max(d[0], d[2]))))
+		}, 1
+	}
+	return func(p Vec3) float32 {
+		x, y, z := 2*math.Pi*float64(p[0]), 2*math.Pi*float64(p[1]), 2*math.Pi*float64(p[2])
+		return float32(math.Abs(math.Sin(x)*math.Cos(y) + math.Sin(y)*math.Cos(z) + math.Sin(z)*math.Cos(x)))
+	}, 1 / (2 * math.Pi)
+}
+
+// latticeThickness returns the value of the field below which the given fraction of a period is
+// solid, bisecting over samples of a period
+func latticeThickness(field func(p Vec3) float32, density float32) float32 {
+	values := make([]float32, 0, latticeSamples*latticeSamples*latticeSamples)
+	highest := float32(0)
+	for k := 0; k < latticeSamples; k++ {
+		for j := 0; j < latticeSamples; j++ {
+			for i := 0; i < latticeSamples; i++ {
+				value := field(Vec3{float32(i) + 0.5, float32(j) + 0.5, float32(k) + 0.5}.Scale(1. / latticeSamples))
+				values = append(values, value)
+				highest = max(highest, value)
+			}
+		}
+	}
+	low, high := float32(0), highest
+	for step := 0; step < 32; step++ {
+		middle := (low + high) / 2
+		solid := 0
+		for _, value := range values {
+			if value < middle {
+				solid++
+			}
+		}
+		if float32(solid) < density*float32(len(values)) {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2
+}
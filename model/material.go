@@ -0,0 +1,136 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaterialOptions describes how a part is printed for EstimateMaterial
+type MaterialOptions struct {
+	//Height of each layer
+	LayerHeight float32
+	//Thickness of the solid walls, floors and roofs around the infill
+	WallThickness float32
+	//Percentage of the inside of the part that is filled (0 to 100)
+	Infill float32
+	//Percentage of the space below the overhangs filled by the supports (0 to 100)
+	SupportDensity float32
+	//Overhangs needing supports, see FindOverhangs (the build direction is always +Z)
+	OverhangAngle float64
+	//Tolerances of the probes measuring the space below the overhangs, DefaultTolerances for the
+	//fields left at 0
+	Tolerances Tolerances
+}
+
+// DefaultMaterialOptions matches common settings for FDM printers
+var DefaultMaterialOptions = MaterialOptions{
+	LayerHeight:    0.2,
+	WallThickness:  0.8,
+	Infill:         20,
+	SupportDensity: 15,
+	OverhangAngle:  DefaultOverhangAngle,
+}
+
+// MaterialEstimate is the volume of material needed to print a part
+type MaterialEstimate struct {
+	Layers int `json:"layers"`
+	//Volume of the solid part as sliced
+	PartVolume float32 `json:"partVolume"`
+	//Material in the walls, floors and roofs, and in the infill
+	ShellVolume  float32 `json:"shellVolume"`
+	InfillVolume float32 `json:"infillVolume"`
+	//Space below the overhangs down to the part or the build plate, and the material filling it
+	SupportSpace  float32 `json:"supportSpace"`
+	SupportVolume float32 `json:"supportVolume"`
+	//Material for the part and the supports
+	TotalVolume float32 `json:"totalVolume"`
+}
+
+// String prints the estimate in the same style as the Info
+func (e MaterialEstimate) String() string {
+	return fmt.Sprintf("Layers: %v\nPart volume: %v\nShell material: %v\nInfill material: %v\nSupport space: %v\nSupport material: %v\nTotal material: %v\n",
+		e.Layers, e.PartVolume, e.ShellVolume, e.InfillVolume, e.SupportSpace, e.SupportVolume, e.TotalVolume)
+}
+
+// EstimateMaterial slices the model to estimate the material needed to print it. In every layer the
+// band along the contours as wide as the walls is solid, and so is the area not covered by the layers
+// within the wall thickness above and below it (floors and roofs), the rest being filled with the
+// infill percentage. Supports fill the space straight below the overhangs.
+func EstimateMaterial(m *Model, opts MaterialOptions) (e MaterialEstimate) {
+	if opts.LayerHeight <= 0 {
+		return e
+	}
+	layers := Slice(m, opts.LayerHeight)
+	e.Layers = len(layers)
+	//Layers of floor and roof
+	skin := int(math.Ceil(float64(opts.WallThickness / opts.LayerHeight)))
+	areaAt := func(i int) float32 {
+		if i < 0 || i >= len(layers) {
+			return 0
+		}
+		return layers[i].Area
+	}
+	for i, layer := range layers {
+		if layer.Area <= 0 {
+			continue
+		}
+		//The part of the layer that may be exposed within the skin layers above and below
+		covered := layer.Area
+		for j := i - skin; j <= i+skin; j++ {
+			if area := areaAt(j); area < covered {
+				covered = area
+			}
+		}
+		solid := layer.Perimeter*opts.WallThickness + (layer.Area - covered)
+		if solid > layer.Area {
+			solid = layer.Area
+		}
+		e.PartVolume += layer.Area * opts.LayerHeight
+		e.ShellVolume += solid * opts.LayerHeight
+		e.InfillVolume += (layer.Area - solid) * opts.Infill / 100 * opts.LayerHeight
+	}
+	e.SupportSpace = NewBVH(m).supportSpace(opts.OverhangAngle, Vec3{0, 0, 1}, opts.Tolerances)
+	e.SupportVolume = e.SupportSpace * opts.SupportDensity / 100
+	e.TotalVolume = e.ShellVolume + e.InfillVolume + e.SupportVolume
+	return e
+}
+
+// supportSpace adds up the columns below the overhangs when building towards up, from each facet down
+// to the first surface under it or the build plate, probing the height at the centroid and halfway
+// to each vertex, ignoring the hits closer than the probe tolerance
+func (bvh *BVH) supportSpace(maxAngle float64, up Vec3, tolerances Tolerances) (space float32) {
+	m := bvh.Model()
+	up = up.Normalize()
+	overhangs := FindOverhangs(m, OverhangOptions{MaxAngle: maxAngle, Up: up})
+	if len(overhangs.Facets) == 0 {
+		return 0
+	}
+	plate := float32(math.MaxFloat32)
+	for i := range m.Triangles {
+		for _, v := range m.Triangles[i].Vertices {
+			if height := up.Dot(v); height < plate {
+				plate = height
+			}
+		}
+	}
+	epsilon := bvh.Bounds().Size().Length() * tolerances.withDefaults().Probe
+	down := up.Scale(-1)
+	for _, i := range overhangs.Facets {
+		t := &m.Triangles[i]
+		a, b, c := Vec3(t.Vertices[0]), Vec3(t.Vertices[1]), Vec3(t.Vertices[2])
+		centroid := a.Add(b).Add(c).Scale(1.0 / 3)
+		probes := [4]Vec3{centroid, centroid.Add(a).Scale(0.5), centroid.Add(b).Scale(0.5), centroid.Add(c).Scale(0.5)}
+		height := float32(0)
+		for _, probe := range probes {
+			column := up.Dot(probe) - plate
+			if distance, ok := bvh.wallDistance(Ray{Origin: probe, Direction: down}, i, epsilon); ok && distance < column {
+				column = distance
+			}
+			height += column / float32(len(probes))
+		}
+		//Area of the facet seen from below
+		projected := triangleArea(t) * abs32(triangleNormal(t).Dot(up))
+		space += projected * height
+	}
+	return space
+}
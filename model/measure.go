@@ -0,0 +1,91 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// SurfaceArea returns the total area of the triangles of the model
+func SurfaceArea(m *Model) float32 {
+	return float32(parallelSum(len(m.Triangles), func(i int) float64 {
+		return float64(triangleArea(&m.Triangles[i]))
+	}))
+}
+
+// Volume returns the volume enclosed by the model, adding the signed tetrahedra between the origin
+// and every triangle. It is only meaningful for watertight models and is negative when the
+// triangles are wound inside out.
+func Volume(m *Model) float32 {
+	volume := parallelSum(len(m.Triangles), func(i int) float64 {
+		a, b, c := Vec3(m.Triangles[i].Vertices[0]), Vec3(m.Triangles[i].Vertices[1]), Vec3(m.Triangles[i].Vertices[2])
+		return float64(a.Dot(b.Cross(c)))
+	})
+	return float32(volume / 6)
+}
+
+// Distance is the straight line between two points
+type Distance struct {
+	From Vec3 `json:"from"`
+	To   Vec3 `json:"to"`
+	//Change along each axis from From to To
+	Delta  Vec3    `json:"delta"`
+	Length float32 `json:"length"`
+}
+
+// String prints the distance in the same style as the Info
+func (d Distance) String() string {
+	return fmt.Sprintf("Distance: %v\nFrom: %v\nTo: %v\nDelta: %v\n", d.Length, d.From, d.To, d.Delta)
+}
+
+// MeasureDistance measures the straight line from one point to another
+func MeasureDistance(from, to Vec3) Distance {
+	delta := to.Sub(from)
+	return Distance{From: from, To: to, Delta: delta, Length: delta.Length()}
+}
+
+// FacetAngle returns the angle in degrees between the normals of the triangles at indices a and b,
+// 0 when they face the same way. Across an edge they share, the faces meet at 180 minus this angle.
+func FacetAngle(m *Model, a, b int) (float64, error) {
+	if a < 0 || b < 0 || a >= len(m.Triangles) || b >= len(m.Triangles) {
+		return 0, fmt.Errorf("Facets must be between 0 and %d", len(m.Triangles)-1)
+	}
+	cos := triangleNormal(&m.Triangles[a]).Dot(triangleNormal(&m.Triangles[b]))
+	return degrees(math.Acos(math.Max(-1, math.Min(1, float64(cos))))), nil
+}
+
+// Extent is how far a model spans along a direction
+type Extent struct {
+	//Unit direction it is measured along
+	Direction Vec3 `json:"direction"`
+	//Lowest and highest position of the vertices along it, and the span between them
+	Min    float32 `json:"min"`
+	Max    float32 `json:"max"`
+	Length float32 `json:"length"`
+}
+
+// String prints the extent in the same style as the Info
+func (e Extent) String() string {
+	return fmt.Sprintf("Extent along %v: %v\nFrom: %v\nTo: %v\n", e.Direction, e.Length, e.Min, e.Max)
+}
+
+// MeasureExtent measures how far the model spans along a direction, which doesn't need to be
+// normalized. Along an axis it is the size of the bounding box.
+func MeasureExtent(m *Model, direction Vec3) (e Extent, err error) {
+	if direction.Length() == 0 {
+		return e, errors.New("The direction can't be zero")
+	}
+	e.Direction = direction.Normalize()
+	if len(m.Triangles) == 0 {
+		return e, nil
+	}
+	e.Min, e.Max = float32(math.Inf(1)), float32(math.Inf(-1))
+	for i := range m.Triangles {
+		for _, v := range m.Triangles[i].Vertices {
+			position := e.Direction.Dot(v)
+			e.Min, e.Max = min(e.Min, position), max(e.Max, position)
+		}
+	}
+	e.Length = e.Max - e.Min
+	return e, nil
+}
@@ -2,7 +2,6 @@ package model
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -19,14 +18,26 @@ type Triangle struct {
 }
 
 type Model struct {
-	Header       string
+	Header string
+	//Name of the solid in ASCII STL files
+	Name string
+	//Number of triangles, kept in step by the functions of this package, see Append and SyncCount
 	NumTriangles uint32
 	Triangles    []Triangle
+	//Attributes of the triangles, in the same order, or nil when none has any. Cloning, subsets,
+	//crops, cuts, scenes, transformations, subdivision, remeshing and the removal of facets keep them
+	//with their triangles, the new facets taking those of the facet they come from. The surfaces
+	//rebuilt from a distance field (offsets, lattices and hollows with drain holes) drop them, and the
+	//cavity of other hollows gets none.
+	Attributes []Attributes
+	//Properties computed from the triangles, see Invalidate
+	cache *modelCache
 }
 
 //Stringer method
 func (m *Model) String() string {
-	mins, maxs := getMinsMaxs(m)
+	bounds := m.Bounds()
+	mins, maxs := [3]float32(bounds.Min), [3]float32(bounds.Max)
 	dimensions := [3]float32{maxs[0] - mins[0], maxs[1] - mins[1], maxs[2] - mins[2]}
 	return fmt.Sprintf("Header: %v\nTriangles: %v\nDimensions: %v\nMins: %v\nMaxs: %v\n", m.Header, m.NumTriangles, dimensions, mins, maxs)
 }
@@ -58,37 +69,141 @@ func (p ProjectFrom) GetAxisForProjection() (forX int, forY int, forValue int) {
 	return forX, forY, forValue
 }
 
-//Project the model in a matrixSize x matrixSize matrix from the chosen perspective
-func ProjectModelVertices(m *Model, matrixSize int, projectFrom ProjectFrom) [][]float32 {
+//Rotation picking the axis of the projection, implements Perspective
+func (p ProjectFrom) ViewRotation() Mat3 {
+	forX, forY, forValue := p.GetAxisForProjection()
+	var rotation Mat3
+	rotation[0][forX], rotation[1][forY], rotation[2][forValue] = 1, 1, 1
+	return rotation
+}
+
+//Mapping of the model coordinates to a matrix from a perspective
+type projection struct {
+	//Rotation to the view coordinates: vertical, horizontal and value axis
+	rotation   Mat3
+	mins       [3]float32
+	dimensions [3]float32
+	scale      float32
+	viewport   Viewport
+	//Vertical span of the matrix in horizontal cell units
+	height float32
+}
+
+//Prepare the projection of the model in the viewport from the chosen perspective
+func newProjection(m *Model, viewport Viewport, perspective Perspective) (p projection) {
 	//Define the perspective
-	projectToX, projectToY, projectToValue := projectFrom.GetAxisForProjection()
-	//Get the mins and the dimensions
-	mins, maxs := getMinsMaxs(m)
-	dimensions := [3]float32{maxs[0] - mins[0], maxs[1] - mins[1], maxs[2] - mins[2]}
-	//Adjust the scale based on the model dimensions
-	scale := float32(1)
-	if dimensions[projectToX] > dimensions[projectToY] {
-		scale = dimensions[projectToX] / float32(matrixSize)
+	rotation := perspective.ViewRotation()
+	//Get the mins and the dimensions in view coordinates
+	mins, maxs := getViewMinsMaxs(m, rotation)
+	if viewport.Frame.Steady && len(m.Triangles) > 0 {
+		worldMins, worldMaxs := getMinsMaxs(m)
+		mins, maxs = viewport.Frame.bounds(rotation, Box{worldMins, worldMaxs}, mins, maxs)
+	}
+	return fitProjection(rotation, mins, maxs, viewport)
+}
+
+//Prepare the projection of everything between the mins and the maxs in view coordinates
+func fitProjection(rotation Mat3, mins, maxs [3]float32, viewport Viewport) (p projection) {
+	p.rotation = rotation
+	p.viewport = viewport
+	p.height = float32(viewport.Height-1) * viewport.aspect()
+	p.mins = mins
+	p.dimensions = [3]float32{maxs[0] - mins[0], maxs[1] - mins[1], maxs[2] - mins[2]}
+	//Adjust the scale based on the model dimensions so it fits both ways
+	p.scale = float32(1)
+	verticalScale, horizontalScale := p.dimensions[0]/p.height, p.dimensions[1]/float32(viewport.Width-1)
+	if verticalScale > horizontalScale {
+		p.scale = verticalScale
 	} else {
-		scale = dimensions[projectToY] / float32(matrixSize)
+		p.scale = horizontalScale
+	}
+	if !viewport.Frame.fills() {
+		p.frame(viewport.Frame, mins, maxs)
+	}
+	return p
+}
+
+//Center the model inside the margin of the frame, zoomed and panned
+func (p *projection) frame(f Frame, mins, maxs [3]float32) {
+	width := float32(p.viewport.Width - 1)
+	fill := 1 - 2*f.Margin
+	p.scale = max(p.dimensions[0]/(p.height*fill), p.dimensions[1]/(width*fill))
+	if f.Scale > 0 {
+		p.scale = f.Scale
 	}
-	//Initialize the output matrix (Y is half the size to compensate for terminal line height)
-	matrix := make([][]float32, (matrixSize/2)+1)
+	if f.Zoom > 0 {
+		p.scale /= f.Zoom
+	}
+	if p.scale == 0 || math.IsNaN(float64(p.scale)) || math.IsInf(float64(p.scale), 0) {
+		//A single point, or a margin leaving no room
+		p.scale = 1
+	}
+	//Put the center of the model at the center of the matrix moved by the pan
+	p.mins[0] = (mins[0]+maxs[0])/2 - (0.5+f.Pan[1])*p.height*p.scale
+	p.mins[1] = (mins[1]+maxs[1])/2 - (0.5+f.Pan[0])*width*p.scale
+}
+
+//Initialize the output matrix
+func (p projection) newMatrix() [][]float32 {
+	matrix := make([][]float32, p.viewport.Height)
 	for i := range matrix {
-		matrix[i] = make([]float32, matrixSize+1)
+		matrix[i] = make([]float32, p.viewport.Width)
+	}
+	return matrix
+}
+
+//Adjust the coordinates by moving them to the positive space and scaling
+func (p projection) adjust(vertex [3]float32) (adjustedX, adjustedY, value float32) {
+	view := p.rotation.MulVec(vertex)
+	adjustedX, adjustedY = (view[0]-p.mins[0])/p.scale, (view[1]-p.mins[1])/p.scale
+	value = (view[2] - p.mins[2]) / p.dimensions[2]
+	return adjustedX, adjustedY, value
+}
+
+//Find the matrix cell of a vertex (rows go down, so the vertical axis is flipped) and its value
+func (p projection) cell(vertex [3]float32) (row, col int, value float32) {
+	adjustedX, adjustedY, value := p.adjust(vertex)
+	if !p.viewport.Frame.fills() {
+		//The frame may leave vertices out of the matrix, keep them there
+		row = int(math.Floor(float64((p.height - float32(math.Floor(float64(adjustedX)))) / p.viewport.aspect())))
+		return row, int(math.Floor(float64(adjustedY))), value
+	}
+	row, col = int((p.height-float32(int(adjustedX)))/p.viewport.aspect()), int(adjustedY)
+	//Guard against rounding at the borders
+	if row >= p.viewport.Height {
+		row = p.viewport.Height - 1
+	}
+	if col >= p.viewport.Width {
+		col = p.viewport.Width - 1
+	}
+	return row, col, value
+}
+
+//Mark a vertex in the matrix, keeping the highest value of each cell
+func (p projection) plotVertex(matrix [][]float32, vertex [3]float32) {
+	row, col, newValue := p.cell(vertex)
+	if row < 0 || col < 0 || row >= len(matrix) || col >= len(matrix[row]) {
+		return
 	}
+	if newValue > matrix[row][col] {
+		matrix[row][col] = newValue
+	}
+}
+
+//Project the model in a matrixSize x matrixSize matrix from the chosen perspective
+func ProjectModelVertices(m *Model, matrixSize int, perspective Perspective) [][]float32 {
+	return ProjectVertices(m, TerminalViewport(matrixSize), perspective)
+}
+
+//Project the vertices of the model in the viewport from the chosen perspective
+func ProjectVertices(m *Model, viewport Viewport, perspective Perspective) [][]float32 {
+	p := newProjection(m, viewport, perspective)
+	matrix := p.newMatrix()
 	//For each Triangle
 	for j := range m.Triangles {
 		//For each vertex
 		for k := range m.Triangles[j].Vertices {
-			//Adjust the coordinates by moving them to the positive space and scaling
-			adjustedX, adjustedY := (m.Triangles[j].Vertices[k][projectToX]-mins[projectToX])/scale, (m.Triangles[j].Vertices[k][projectToY]-mins[projectToY])/scale
-			matrixX, matrixY := int(adjustedX), int(adjustedY)
-			//Mark the vertex in the matrix
-			newValue := (m.Triangles[j].Vertices[k][projectToValue] - mins[projectToValue]) / dimensions[projectToValue]
-			if newValue > matrix[(matrixSize-matrixX)/2][matrixY] {
-				matrix[(matrixSize-matrixX)/2][matrixY] = newValue
-			}
+			p.plotVertex(matrix, m.Triangles[j].Vertices[k])
 		}
 	}
 	return matrix
@@ -96,73 +211,43 @@ func ProjectModelVertices(m *Model, matrixSize int, projectFrom ProjectFrom) [][
 
 //Draw a matrix with different characters for the value axis
 func DrawMatrix(matrix [][]float32) string {
-	//Buffer for the output
-	var buffer bytes.Buffer
-	for i := range matrix {
-		for j := range matrix[i] {
-			//Paint each point where a vertex was found
-			switch adjVal := matrix[i][j] * 4; {
-			case adjVal > 3:
-				buffer.WriteString("▓")
-			case adjVal > 1.5:
-				buffer.WriteString("▒")
-			case adjVal > 0:
-				buffer.WriteString("░")
-			default:
-				buffer.WriteString(" ")
-			}
-		}
-		//New row
-		buffer.WriteString("\n")
-	}
-	//fmt.Printf("%v\n", matrix)
-	return buffer.String()
+	return DrawMatrixPalette(matrix, BlockPalette)
+}
+
+//Write what DrawMatrix draws a row at a time, without building the whole drawing in memory
+func RenderMatrix(w io.Writer, matrix [][]float32) error {
+	return RenderMatrixPalette(w, matrix, BlockPalette)
 }
 
 func CreateFromByteSlice(byteSlice []byte) (m Model, err error) {
-	//Read the Header
-	m.Header = strings.Trim(string(byteSlice[:80]), "\x00")
-	//Read the number of Triangles
-	m.NumTriangles = binary.LittleEndian.Uint32(byteSlice[80:84])
-	//Read the Triangles
-	m.Triangles = make([]Triangle, m.NumTriangles)
-	buf := bytes.NewReader(byteSlice[84:])
-	err = binary.Read(buf, binary.LittleEndian, &m.Triangles)
+	//Check there is room for the Header and the Triangles it announces before allocating them
+	count, err := binaryCount(byteSlice)
 	if err != nil {
 		return m, err
 	}
+	decodeBinarySTL(&m, byteSlice, count, binary.LittleEndian)
 	return m, nil
 }
 
 func CreateFromBinarySTL(r io.Reader) (m Model, err error) {
-	//Read the Header and Number of Triangles
-	header := make([]byte, 84)
-	_, err = io.ReadFull(r, header)
-	if err != nil {
-		return m, err
-	}
-	m.Header = strings.Trim(string(header[:80]), "\x00")
-	m.NumTriangles = binary.LittleEndian.Uint32(header[80:84])
-
-	//Allocate space for the triangles
-	m.Triangles = make([]Triangle, m.NumTriangles)
-	err = binary.Read(r, binary.LittleEndian, &m.Triangles)
-	if err != nil {
-		return m, err
-	}
-	return m, nil
+	//Read the Triangles as they come, the count of the Header can't be trusted to allocate them
+	_, err = m.ReadFrom(r)
+	return m, err
 }
 
 func CreateFromASCIISTL(r *bufio.Reader) (m Model, err error) {
-	// Function to treat each line. receives the reader ,the expected starting string, the parts splitters and the number of expected parts after splitting
-	readAndTreatLine := func(r *bufio.Reader, mustStartWith string, partSplitters string, expectedPartsLength int) (lineParts []string, err error) {
-		//Read a line
-		line, err := r.ReadString('\n')
-		if err != nil {
-			return lineParts, err
-		}
-		//Trim tabs, spaces and new line
-		line = strings.Trim(line, " \t\n\r")
+	return decodeASCIISTL(r, m)
+}
+
+//Read an ASCII STL appending the triangles to the ones of m
+func decodeASCIISTL(r *bufio.Reader, m Model) (Model, error) {
+	// Function to read a line, trimmed of tabs, spaces and new line
+	readLine := func(r *bufio.Reader) (line string, err error) {
+		line, err = r.ReadString('\n')
+		return strings.Trim(line, " \t\n\r"), err
+	}
+	// Function to treat each line. receives the line ,the expected starting string, the parts splitters and the number of expected parts after splitting
+	treatLine := func(line string, mustStartWith string, partSplitters string, expectedPartsLength int) (lineParts []string, err error) {
 		//Check if size is at least the same as param
 		if len(line) < len(mustStartWith) {
 			return lineParts, errors.New("Line shorter than mustStartWith.")
@@ -179,17 +264,39 @@ func CreateFromASCIISTL(r *bufio.Reader) (m Model, err error) {
 		//Return the line
 		return lineParts, nil
 	}
+	// Function to read and treat a line
+	readAndTreatLine := func(r *bufio.Reader, mustStartWith string, partSplitters string, expectedPartsLength int) (lineParts []string, err error) {
+		line, err := readLine(r)
+		if err != nil {
+			return lineParts, err
+		}
+		return treatLine(line, mustStartWith, partSplitters, expectedPartsLength)
+	}
 	//Read the first line
 	Header, err := r.ReadString('\n')
 	if err != nil {
 		return m, err
 	}
-	//Create the Header with the original solid name
-	m.Header = fmt.Sprintf("Imported from ASCII STL by stl2ascii - %v", strings.Trim(string(Header[5:]), " \n"))
+	//Keep the solid name apart from the Header
+	m.Header = "Imported from ASCII STL by stl2ascii"
+	if len(Header) >= 5 {
+		m.Name = strings.Trim(string(Header[5:]), " \t\r\n")
+	}
 	for {
 		var aTriangle Triangle
-		//Read the normal
-		normalParts, err := readAndTreatLine(r, "facet normal ", " ", 3)
+		//Read the normal, or the end of the solid
+		line, err := readLine(r)
+		if strings.HasPrefix(line, "endsolid") {
+			//Check it closes the same solid
+			if name := strings.Trim(line[len("endsolid"):], " \t"); name != "" && name != m.Name {
+				return m, fmt.Errorf("Solid %q ends with endsolid %q", m.Name, name)
+			}
+			break
+		}
+		if line == "" && err != nil {
+			break
+		}
+		normalParts, err := treatLine(line, "facet normal ", " ", 3)
 		if err != nil {
 			break
 		}
@@ -242,23 +349,43 @@ func getDimensions(m *Model) [3]float32 {
 	return [3]float32{maxs[0] - mins[0], maxs[1] - mins[1], maxs[2] - mins[2]}
 }
 
+//Get the mins and the maxs arrays of the vertices once rotated to view coordinates
+func getViewMinsMaxs(m *Model, rotation Mat3) (mins [3]float32, maxs [3]float32) {
+	bounds := EmptyBox()
+	for i := range m.Triangles {
+		for j := range m.Triangles[i].Vertices {
+			bounds = bounds.Extend(rotation.MulVec(m.Triangles[i].Vertices[j]))
+		}
+	}
+	return bounds.Min, bounds.Max
+}
+
 //Get the mins and the maxs arrays
 func getMinsMaxs(m *Model) (mins [3]float32, maxs [3]float32) {
+	//Split big models between goroutines
+	if len(m.Triangles) >= parallelThreshold {
+		return parallelMinsMaxs(m)
+	}
+	return trianglesMinsMaxs(m.Triangles)
+}
+
+// trianglesMinsMaxs returns the smallest and largest coordinates of the triangles along each axis
+func trianglesMinsMaxs(triangles []Triangle) (mins [3]float32, maxs [3]float32) {
 	//Initialize arrays for min x y z and max x y z
 	mins = [3]float32{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
 	maxs = [3]float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
 	//Run through the Triangles
-	for i := range m.Triangles {
+	for i := range triangles {
 		//Each vertice
-		for j := range m.Triangles[i].Vertices {
+		for j := range triangles[i].Vertices {
 			//Each coordinate
-			for k := range m.Triangles[i].Vertices[j] {
+			for k := range triangles[i].Vertices[j] {
 				//Update min and max
-				if m.Triangles[i].Vertices[j][k] < mins[k] {
-					mins[k] = m.Triangles[i].Vertices[j][k]
+				if triangles[i].Vertices[j][k] < mins[k] {
+					mins[k] = triangles[i].Vertices[j][k]
 				}
-				if m.Triangles[i].Vertices[j][k] > maxs[k] {
-					maxs[k] = m.Triangles[i].Vertices[j][k]
+				if triangles[i].Vertices[j][k] > maxs[k] {
+					maxs[k] = triangles[i].Vertices[j][k]
 				}
 			}
 		}
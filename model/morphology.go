@@ -0,0 +1,126 @@
+package model
+
+// Offsets of the six neighbors sharing a face with a voxel
+var voxelNeighbors = [6][3]int{{-1, 0, 0}, {1, 0, 0}, {0, -1, 0}, {0, 1, 0}, {0, 0, -1}, {0, 0, 1}}
+
+// Dilate fills every voxel within radius voxels of a full one, the distance being measured between
+// their centers, closing gaps and growing the solid by the radius. The grid grows by the radius on
+// every side so nothing is cut at its borders.
+func (g *VoxelGrid) Dilate(radius int) {
+	if radius <= 0 {
+		return
+	}
+	g.grow(radius)
+	g.stamp(radius, true)
+}
+
+// Erode empties every voxel within radius voxels of an empty one, or of the outside of the grid,
+// removing the parts thinner than twice the radius and shrinking the solid by it
+func (g *VoxelGrid) Erode(radius int) {
+	if radius <= 0 {
+		return
+	}
+	g.stamp(radius, false)
+}
+
+// Open erodes and then dilates the solid by radius, removing the spikes and the walls too thin for
+// the erosion while keeping the size of the rest
+func (g *VoxelGrid) Open(radius int) {
+	g.Erode(radius)
+	g.Dilate(radius)
+}
+
+// Close dilates and then erodes the solid by radius, filling the gaps and the holes too narrow for
+// the dilation while keeping the size of the rest
+func (g *VoxelGrid) Close(radius int) {
+	g.Dilate(radius)
+	g.Erode(radius)
+}
+
+// FillCavities fills the empty voxels that can't be reached from the outside of the grid going from
+// an empty voxel to a neighbor sharing a face, the closed cavities inside the solid, and returns how
+// many were filled
+func (g *VoxelGrid) FillCavities() (filled int) {
+	reached := make([]bool, len(g.full))
+	var queue [][3]int
+	visit := func(i, j, k int) {
+		if !g.inside(i, j, k) {
+			return
+		}
+		if n := g.index(i, j, k); !g.full[n] && !reached[n] {
+			reached[n] = true
+			queue = append(queue, [3]int{i, j, k})
+		}
+	}
+	//Start from every voxel on the sides of the grid
+	for k := 0; k < g.Dimensions[2]; k++ {
+		for j := 0; j < g.Dimensions[1]; j++ {
+			for i := 0; i < g.Dimensions[0]; i++ {
+				if i == 0 || j == 0 || k == 0 || i == g.Dimensions[0]-1 || j == g.Dimensions[1]-1 || k == g.Dimensions[2]-1 {
+					visit(i, j, k)
+				}
+			}
+		}
+	}
+	for len(queue) > 0 {
+		v := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for _, offset := range voxelNeighbors {
+			visit(v[0]+offset[0], v[1]+offset[1], v[2]+offset[2])
+		}
+	}
+	for n := range g.full {
+		if !g.full[n] && !reached[n] {
+			g.full[n] = true
+			filled++
+		}
+	}
+	return filled
+}
+
+// grow adds margin empty voxels on every side of the grid
+func (g *VoxelGrid) grow(margin int) {
+	grown := NewVoxelGrid(g.Origin.Sub(Vec3{1, 1, 1}.Scale(float32(margin)*g.Size)), g.Size,
+		[3]int{g.Dimensions[0] + 2*margin, g.Dimensions[1] + 2*margin, g.Dimensions[2] + 2*margin})
+	g.Each(func(i, j, k int) {
+		grown.Set(i+margin, j+margin, k+margin, true)
+	})
+	*g = *grown
+}
+
+// stamp sets to value every voxel within radius of a voxel that already has it and a neighbor that
+// doesn't, the voxels out of the grid being empty. Only those voxels on the border can reach the
+// others, so the ball is stamped around them alone.
+func (g *VoxelGrid) stamp(radius int, value bool) {
+	seen := make(map[[3]int]bool)
+	var border [][3]int
+	for k := 0; k < g.Dimensions[2]; k++ {
+		for j := 0; j < g.Dimensions[1]; j++ {
+			for i := 0; i < g.Dimensions[0]; i++ {
+				if g.full[g.index(i, j, k)] == value {
+					continue
+				}
+				for _, offset := range voxelNeighbors {
+					neighbor := [3]int{i + offset[0], j + offset[1], k + offset[2]}
+					if g.Get(neighbor[0], neighbor[1], neighbor[2]) == value && !seen[neighbor] {
+						seen[neighbor] = true
+						border = append(border, neighbor)
+					}
+				}
+			}
+		}
+	}
+	squared := radius * radius
+	for _, center := range border {
+		for k := center[2] - radius; k <= center[2]+radius; k++ {
+			for j := center[1] - radius; j <= center[1]+radius; j++ {
+				for i := center[0] - radius; i <= center[0]+radius; i++ {
+					di, dj, dk := i-center[0], j-center[1], k-center[2]
+					if di*di+dj*dj+dk*dk <= squared && g.inside(i, j, k) {
+						g.full[g.index(i, j, k)] = value
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CreateFromOBJ reads the faces of a Wavefront OBJ file, splitting polygons in triangle fans.
+// Texture coordinates, normals, groups and materials are ignored.
+func CreateFromOBJ(r io.Reader) (m Model, err error) {
+	m.Header = "Imported from OBJ by stl2ascii"
+	var vertices []Vec3
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return m, fmt.Errorf("Vertex with less than 3 coordinates on line %d", line)
+			}
+			var v Vec3
+			for i := range v {
+				parsed, err := strconv.ParseFloat(fields[i+1], 32)
+				if err != nil {
+					return m, err
+				}
+				v[i] = float32(parsed)
+			}
+			vertices = append(vertices, v)
+		case "f":
+			if len(fields) < 4 {
+				return m, fmt.Errorf("Face with less than 3 vertices on line %d", line)
+			}
+			face := make([]Vec3, len(fields)-1)
+			for i := range face {
+				index, err := objIndex(fields[i+1], len(vertices))
+				if err != nil {
+					return m, fmt.Errorf("%v on line %d", err, line)
+				}
+				face[i] = vertices[index]
+			}
+			for i := 1; i+1 < len(face); i++ {
+				t := Triangle{Vertices: [3][3]float32{face[0], face[i], face[i+1]}}
+				t.Normal = face[i].Sub(face[0]).Cross(face[i+1].Sub(face[0])).Normalize()
+				m.Triangles = append(m.Triangles, t)
+				m.NumTriangles++
+			}
+		}
+	}
+	return m, scanner.Err()
+}
+
+// objIndex converts a face vertex reference (v, v/vt, v//vn or v/vt/vn, 1 based or negative from the
+// end) to an index in the vertices read so far
+func objIndex(reference string, count int) (int, error) {
+	if slash := strings.IndexByte(reference, '/'); slash >= 0 {
+		reference = reference[:slash]
+	}
+	index, err := strconv.Atoi(reference)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 {
+		index += count
+	} else {
+		index--
+	}
+	if index < 0 || index >= count {
+		return 0, errors.New("Face vertex out of range")
+	}
+	return index, nil
+}
+
+// WriteOBJ encodes the model as a Wavefront OBJ, sharing the vertices between faces
+func WriteOBJ(w io.Writer, m *Model) error {
+	return WriteOBJWith(w, m, ASCIIOptions{})
+}
+
+// WriteOBJWith encodes the model as a Wavefront OBJ like WriteOBJ, with the coordinates written as the
+// options say
+func WriteOBJWith(w io.Writer, m *Model, opts ASCIIOptions) error {
+	if err := opts.check(); err != nil {
+		return err
+	}
+	topology := NewTopology(m)
+	buffered := bufio.NewWriter(w)
+	buffered.WriteString("# " + solidName(m) + "\n")
+	var line []byte
+	for _, v := range topology.Vertices {
+		line = append(opts.appendCoordinates(append(line[:0], "v "...), v), '\n')
+		buffered.Write(line)
+	}
+	for _, face := range topology.Faces {
+		fmt.Fprintf(buffered, "f %d %d %d\n", face[0]+1, face[1]+1, face[2]+1)
+	}
+	return buffered.Flush()
+}
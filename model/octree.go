@@ -0,0 +1,341 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Voxels is a solid made of the full cubes of a regular grid, stored whole by VoxelGrid or sparsely
+// by VoxelOctree
+type Voxels interface {
+	//Get tells if the voxel i, j, k is full, those out of the grid are empty
+	Get(i, j, k int) bool
+	//Set fills or empties the voxel i, j, k, which must be in the grid
+	Set(i, j, k int, full bool)
+	//Sample tells if the point is in a full voxel
+	Sample(p Vec3) bool
+	//Each calls visit with every full voxel
+	Each(visit func(i, j, k int))
+	//Count returns the number of full voxels
+	Count() int
+	//Center returns the position of the center of the voxel i, j, k
+	Center(i, j, k int) Vec3
+	//Locate returns the voxel containing the point, which may be out of the grid
+	Locate(p Vec3) (i, j, k int)
+	//Volume returns the volume of the full voxels
+	Volume() float32
+	//Model rebuilds the surface of the full voxels as a watertight model
+	Model() *Model
+}
+
+// Side of the cubes of an octree filled at once while voxelizing, a row of voxels at a time
+const octreeBlock = 8
+
+// VoxelOctree holds voxels like VoxelGrid in an octree, where a cube of voxels all full or all empty
+// takes a single node whatever its size. The memory follows the area of the surface of the solid
+// instead of the volume of its bounds, so parts can be voxelized much finer.
+type VoxelOctree struct {
+	//Corner of the first voxel, the one with the lowest coordinates
+	Origin Vec3
+	//Side of the voxels
+	Size float32
+	//Number of voxels along each side of the cube covered, a power of two
+	Side  int
+	nodes []octreeNode
+	//Blocks of eight nodes no longer used, by the index of their first node
+	free []int32
+}
+
+// octreeNode is a cube of the octree, split in eight children or all full or empty
+type octreeNode struct {
+	//Index of the first of the eight children, numbered x + 2y + 4z, 0 for a leaf since the root is
+	//nobody's child
+	children int32
+	//Whether the voxels of a leaf are full
+	full bool
+}
+
+// NewVoxelOctree returns an empty octree of at least side voxels along each axis
+func NewVoxelOctree(origin Vec3, size float32, side int) *VoxelOctree {
+	power := 1
+	for power < side {
+		power *= 2
+	}
+	return &VoxelOctree{Origin: origin, Size: size, Side: power, nodes: []octreeNode{{}}}
+}
+
+// VoxelizeOctree fills the voxels whose center is inside the watertight model like Voxelize, only
+// splitting the cubes crossed by the surface
+func VoxelizeOctree(m *Model, size float32) (*VoxelOctree, error) {
+	if size <= 0 {
+		return nil, errors.New("Voxel size must be positive")
+	}
+	if len(m.Triangles) == 0 {
+		return nil, errors.New("Model has no triangles")
+	}
+	if !NewTopology(m).IsWatertight() {
+		return nil, errors.New("Model must be watertight to be voxelized")
+	}
+	bvh := NewBVH(m)
+	bounds := bvh.Bounds()
+	extent := bounds.Size()
+	side := 0
+	for k := 0; k < 3; k++ {
+		side = max(side, int(math.Ceil(float64(extent[k]/size)))+2)
+	}
+	o := NewVoxelOctree(bounds.Min.Sub(Vec3{size, size, size}), size, side)
+	//Rows of voxels are cast slightly off the centers so they don't run along the faces of axis aligned
+	//models, like in Voxelize
+	skew := Vec3{0, 0.000137, 0.000291}.Scale(size)
+	var build func(node int, corner [3]int, span int)
+	build = func(node int, corner [3]int, span int) {
+		low := o.Origin.Add(Vec3{float32(corner[0]), float32(corner[1]), float32(corner[2])}.Scale(size))
+		cube := Box{Min: low, Max: low.Add(Vec3{1, 1, 1}.Scale(float32(span) * size))}
+		//Cubes away from the surface are all inside or all outside
+		if len(bvh.QueryBox(cube)) == 0 {
+			o.nodes[node].full = bvh.Contains(cube.Center())
+			return
+		}
+		if span > octreeBlock {
+			children := o.split(node)
+			half := span / 2
+			for c, offset := range cellCorners {
+				build(int(children)+c, [3]int{corner[0] + offset[0]*half, corner[1] + offset[1]*half, corner[2] + offset[2]*half}, half)
+			}
+			o.merge(node)
+			return
+		}
+		//Small cubes crossed by the surface are filled a row at a time by the parity of the crossings
+		full := make([]bool, span*span*span)
+		for k := 0; k < span; k++ {
+			for j := 0; j < span; j++ {
+				start := o.Center(corner[0], corner[1]+j, corner[2]+k).Add(skew)
+				hits := bvh.RaycastAll(Ray{Origin: start, Direction: Vec3{1, 0, 0}})
+				crossed := 0
+				for i := 0; i < span; i++ {
+					for crossed < len(hits) && hits[crossed].Distance < float32(i)*size {
+						crossed++
+					}
+					full[(k*span+j)*span+i] = (len(hits)-crossed)%2 == 1
+				}
+			}
+		}
+		o.fill(node, [3]int{}, span, func(i, j, k int) bool { return full[(k*span+j)*span+i] })
+	}
+	build(0, [3]int{}, o.Side)
+	return o, nil
+}
+
+// fill builds the cube below the node from the voxels given relative to its lowest one, merging the
+// parts all full or all empty
+func (o *VoxelOctree) fill(node int, corner [3]int, span int, full func(i, j, k int) bool) {
+	if span == 1 {
+		o.nodes[node].full = full(corner[0], corner[1], corner[2])
+		return
+	}
+	children := o.split(node)
+	half := span / 2
+	for c, offset := range cellCorners {
+		o.fill(int(children)+c, [3]int{corner[0] + offset[0]*half, corner[1] + offset[1]*half, corner[2] + offset[2]*half}, half, full)
+	}
+	o.merge(node)
+}
+
+// split turns the leaf into eight leaves like it and returns the index of the first one
+func (o *VoxelOctree) split(node int) int32 {
+	var children int32
+	if len(o.free) > 0 {
+		children, o.free = o.free[len(o.free)-1], o.free[:len(o.free)-1]
+	} else {
+		children = int32(len(o.nodes))
+		o.nodes = append(o.nodes, make([]octreeNode, 8)...)
+	}
+	for c := int32(0); c < 8; c++ {
+		o.nodes[children+c] = octreeNode{full: o.nodes[node].full}
+	}
+	o.nodes[node].children = children
+	return children
+}
+
+// merge turns the node back into a leaf when its children are leaves all full or all empty
+func (o *VoxelOctree) merge(node int) {
+	children := o.nodes[node].children
+	first := o.nodes[children]
+	for c := int32(0); c < 8; c++ {
+		if child := o.nodes[children+c]; child.children != 0 || child.full != first.full {
+			return
+		}
+	}
+	o.nodes[node] = octreeNode{full: first.full}
+	o.free = append(o.free, children)
+}
+
+// inside checks if i, j, k is a voxel of the octree
+func (o *VoxelOctree) inside(i, j, k int) bool {
+	return i >= 0 && j >= 0 && k >= 0 && i < o.Side && j < o.Side && k < o.Side
+}
+
+// Get implements Voxels
+func (o *VoxelOctree) Get(i, j, k int) bool {
+	if !o.inside(i, j, k) {
+		return false
+	}
+	node := 0
+	for half := o.Side / 2; o.nodes[node].children != 0; half /= 2 {
+		node = int(o.nodes[node].children) + octreeChild(i, j, k, half)
+	}
+	return o.nodes[node].full
+}
+
+// octreeChild returns the child of a node containing the voxel, half being the span of the children
+func octreeChild(i, j, k, half int) int {
+	return (i&half)/half + 2*((j&half)/half) + 4*((k&half)/half)
+}
+
+// Set implements Voxels
+func (o *VoxelOctree) Set(i, j, k int, full bool) {
+	if !o.inside(i, j, k) {
+		panic("Voxel out of the octree")
+	}
+	//Split the leaves down to the voxel, then merge back what became uniform
+	var path []int
+	node := 0
+	for half := o.Side / 2; half >= 1; half /= 2 {
+		if o.nodes[node].children == 0 {
+			if o.nodes[node].full == full {
+				return
+			}
+			o.split(node)
+		}
+		path = append(path, node)
+		node = int(o.nodes[node].children) + octreeChild(i, j, k, half)
+	}
+	o.nodes[node].full = full
+	for p := len(path) - 1; p >= 0; p-- {
+		o.merge(path[p])
+	}
+}
+
+// Center implements Voxels
+func (o *VoxelOctree) Center(i, j, k int) Vec3 {
+	return o.Origin.Add(Vec3{float32(i) + 0.5, float32(j) + 0.5, float32(k) + 0.5}.Scale(o.Size))
+}
+
+// Locate implements Voxels
+func (o *VoxelOctree) Locate(p Vec3) (i, j, k int) {
+	cell := p.Sub(o.Origin).Scale(1 / o.Size)
+	return int(math.Floor(float64(cell[0]))), int(math.Floor(float64(cell[1]))), int(math.Floor(float64(cell[2])))
+}
+
+// Sample implements Voxels
+func (o *VoxelOctree) Sample(p Vec3) bool {
+	return o.Get(o.Locate(p))
+}
+
+// eachLeaf calls visit with every full leaf, by its lowest voxel and its span, going through the
+// children in order
+func (o *VoxelOctree) eachLeaf(visit func(corner [3]int, span int)) {
+	var walk func(node int, corner [3]int, span int)
+	walk = func(node int, corner [3]int, span int) {
+		if o.nodes[node].children == 0 {
+			if o.nodes[node].full {
+				visit(corner, span)
+			}
+			return
+		}
+		half := span / 2
+		for c, offset := range cellCorners {
+			walk(int(o.nodes[node].children)+c, [3]int{corner[0] + offset[0]*half, corner[1] + offset[1]*half, corner[2] + offset[2]*half}, half)
+		}
+	}
+	walk(0, [3]int{}, o.Side)
+}
+
+// Each implements Voxels, going through the cubes of the octree in order and through the voxels of
+// each one along X, then Y, then Z
+func (o *VoxelOctree) Each(visit func(i, j, k int)) {
+	o.eachLeaf(func(corner [3]int, span int) {
+		for k := corner[2]; k < corner[2]+span; k++ {
+			for j := corner[1]; j < corner[1]+span; j++ {
+				for i := corner[0]; i < corner[0]+span; i++ {
+					visit(i, j, k)
+				}
+			}
+		}
+	})
+}
+
+// Count implements Voxels
+func (o *VoxelOctree) Count() (count int) {
+	o.eachLeaf(func(_ [3]int, span int) {
+		count += span * span * span
+	})
+	return count
+}
+
+// Volume implements Voxels
+func (o *VoxelOctree) Volume() float32 {
+	return float32(o.Count()) * o.Size * o.Size * o.Size
+}
+
+// Model implements Voxels, rebuilding the surface like VoxelGrid.Model. Only the cells around the
+// voxels next to an empty one are looked at, those on the sides of the cubes of the octree.
+func (o *VoxelOctree) Model() *Model {
+	//Cells of the surface by their lowest corner, the center of a voxel
+	cells := make(map[[3]int]bool)
+	border := func(i, j, k int) {
+		for _, offset := range voxelNeighbors {
+			if !o.Get(i+offset[0], j+offset[1], k+offset[2]) {
+				for _, corner := range cellCorners {
+					cells[[3]int{i - corner[0], j - corner[1], k - corner[2]}] = true
+				}
+				return
+			}
+		}
+	}
+	o.eachLeaf(func(corner [3]int, span int) {
+		last := span - 1
+		for k := 0; k < span; k++ {
+			for j := 0; j < span; j++ {
+				for i := 0; i < span; i++ {
+					if i == 0 || j == 0 || k == 0 || i == last || j == last || k == last {
+						border(corner[0]+i, corner[1]+j, corner[2]+k)
+					} else if j > 0 && j < last && k > 0 && k < last {
+						//Jump over the inside of the row
+						i = last - 1
+					}
+				}
+			}
+		}
+	})
+	sorted := make([][3]int, 0, len(cells))
+	for cell := range cells {
+		sorted = append(sorted, cell)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		return sorted[a][2] < sorted[b][2] || sorted[a][2] == sorted[b][2] &&
+			(sorted[a][1] < sorted[b][1] || sorted[a][1] == sorted[b][1] && sorted[a][0] < sorted[b][0])
+	})
+	//Samples are numbered like in a grid with a row of empty voxels around the octree
+	rows := o.Side + 2
+	var triangles []Triangle
+	for _, cell := range sorted {
+		var indices [8]int
+		var points [8]Vec3
+		var values [8]float32
+		for c, offset := range cellCorners {
+			i, j, k := cell[0]+offset[0], cell[1]+offset[1], cell[2]+offset[2]
+			indices[c] = ((k+1)*rows+j+1)*rows + i + 1
+			points[c] = o.Center(i, j, k)
+			values[c] = 1
+			if o.Get(i, j, k) {
+				values[c] = -1
+			}
+		}
+		for _, tetrahedron := range cellTetrahedra {
+			triangles = polygonizeTetrahedron(triangles, tetrahedron, indices, points, values, 0)
+		}
+	}
+	return &Model{Header: "Voxels", Triangles: triangles, NumTriangles: uint32(len(triangles))}
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"errors"
+	"math"
+)
+
+// OffsetMethod chooses how Offset moves the surface
+type OffsetMethod int
+
+const (
+	//Vertex normals for distances under 1% of the diagonal of the bounding box, the distance
+	//field otherwise
+	OffsetAuto OffsetMethod = iota
+	//Move every vertex along the average normal of the facets around it, keeping the facets. Fast and
+	//exact for small distances, but the surface folds over itself where the distance is larger than
+	//the details of the model.
+	OffsetVertexNormals
+	//Rebuild the surface at the distance from a distance field sampled on a grid, never folding over
+	//itself but only as detailed as the cells. The model must be watertight.
+	OffsetDistanceField
+)
+
+// OffsetOptions tunes OffsetWith
+type OffsetOptions struct {
+	//How far the surface moves outwards, negative to shrink it
+	Distance float32
+	Method   OffsetMethod
+	//Size of the cells of the distance field, half the distance if 0 (but never so small that the
+	//longest side of the model takes more than maxFieldCells cells)
+	CellSize float32
+}
+
+// Offset grows the surface of the model outwards by distance, or shrinks it when negative, like
+// OffsetWith choosing the method from the distance
+func Offset(m *Model, distance float64) error {
+	return OffsetWith(m, OffsetOptions{Distance: float32(distance)})
+}
+
+// OffsetWith moves the surface of the model along its outside normals by the distance, in place
+func OffsetWith(m *Model, opts OffsetOptions) error {
+	if opts.Distance == 0 || len(m.Triangles) == 0 {
+		return nil
+	}
+	method := opts.Method
+	if method == OffsetAuto {
+		method = OffsetDistanceField
+		mins, maxs := getMinsMaxs(m)
+		if abs32(opts.Distance) < Vec3(maxs).Sub(mins).Length()/100 {
+			method = OffsetVertexNormals
+		}
+	}
+	switch method {
+	case OffsetVertexNormals:
+		offsetVertices(m, opts.Distance)
+		return nil
+	case OffsetDistanceField:
+		return offsetField(m, opts)
+	}
+	return errors.New("Unknown offset method")
+}
+
+// offsetVertices moves every vertex along the normals of the facets around it weighted by their
+// angle at the vertex, which doesn't depend on how the faces are triangulated
+func offsetVertices(m *Model, distance float32) {
+	m.Invalidate()
+	topology := NewTopology(m)
+	normals := make([]Vec3, len(topology.Vertices))
+	for i, face := range topology.Faces {
+		normal := triangleNormal(&m.Triangles[i])
+		for j := range face {
+			v := topology.Vertices[face[j]]
+			a := topology.Vertices[face[(j+1)%3]].Sub(v)
+			b := topology.Vertices[face[(j+2)%3]].Sub(v)
+			if a.Length() == 0 || b.Length() == 0 {
+				continue
+			}
+			cos := float64(a.Normalize().Dot(b.Normalize()))
+			angle := float32(math.Acos(math.Max(-1, math.Min(1, cos))))
+			normals[face[j]] = normals[face[j]].Add(normal.Scale(angle))
+		}
+	}
+	for i, face := range topology.Faces {
+		t := &m.Triangles[i]
+		for j := range face {
+			t.Vertices[j] = topology.Vertices[face[j]].Add(normals[face[j]].Normalize().Scale(distance))
+		}
+		t.Normal = triangleNormal(t)
+	}
+}
+
+// offsetField rebuilds the surface of the watertight model where its distance field crosses the distance
+func offsetField(m *Model, opts OffsetOptions) error {
+	if !NewTopology(m).IsWatertight() {
+		return errors.New("Model must be watertight to be offset with a distance field")
+	}
+	bvh := NewBVH(m)
+	cell := opts.CellSize
+	if cell <= 0 {
+		cell = abs32(opts.Distance) / 2
+	}
+	cell = fieldCell(bvh.Bounds(), cell)
+	//Room for the grown surface
+	padding := 2
+	if opts.Distance > 0 {
+		padding += int(math.Ceil(float64(opts.Distance / cell)))
+	}
+	grid := newDistanceGrid(bvh.Bounds(), cell, padding)
+	bvh.signedDistance(grid)
+	m.Invalidate()
+	m.Triangles = grid.isosurface(opts.Distance)
+	m.NumTriangles = uint32(len(m.Triangles))
+	m.ClearAttributes()
+	return nil
+}
@@ -0,0 +1,281 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// OrientationWeights sets how much each measure counts in the score of an orientation. The measures
+// are relative to the size of the model so the weights don't depend on it.
+type OrientationWeights struct {
+	//Overhang area over the surface area
+	Overhang float32
+	//Support space over the volume of the bounding box
+	Support float32
+	//Build height over the diagonal of the bounding box
+	Height float32
+	//Area resting on the build plate over the surface area, subtracted from the score
+	Contact float32
+}
+
+// DefaultOrientationWeights cares the most about supports, then about a stable base and a short build
+var DefaultOrientationWeights = OrientationWeights{
+	Overhang: 1,
+	Support:  1,
+	Height:   0.25,
+	Contact:  0.5,
+}
+
+// OrientationOptions tunes OptimizeOrientation
+type OrientationOptions struct {
+	//Directions tried as the build direction, spread evenly over the sphere, 100 if 0. The axes and
+	//the opposite of the normals of the largest flat faces (to rest them on the plate) are always tried.
+	Samples int
+	//DefaultOrientationWeights if zero
+	Weights OrientationWeights
+	//Overhangs needing supports, see FindOverhangs
+	OverhangAngle float64
+	//Candidates evaluated at the same time, runtime.NumCPU() if 0
+	Workers int
+	//Tolerances telling directions and flat faces apart, and probing the support space,
+	//DefaultTolerances for the fields left at 0
+	Tolerances Tolerances
+}
+
+// Orientation is a candidate way of placing a model on the build plate
+type Orientation struct {
+	//Direction of the model that ends up pointing towards +Z
+	Up Vec3 `json:"up"`
+	//Rotation taking Up to +Z, to apply with Transform(m, Affine{Linear: Rotation})
+	Rotation     Mat3    `json:"rotation"`
+	OverhangArea float32 `json:"overhangArea"`
+	//Space below the overhangs down to the part or the build plate
+	SupportSpace float32 `json:"supportSpace"`
+	Height       float32 `json:"height"`
+	//Area of the facets resting on the build plate
+	ContactArea float32 `json:"contactArea"`
+	//Lower is better
+	Score float32 `json:"score"`
+}
+
+// String prints the orientation in the same style as the Info
+func (o Orientation) String() string {
+	return fmt.Sprintf("Up: %v\nRotation: %v\nOverhang area: %v\nSupport space: %v\nHeight: %v\nContact area: %v\nScore: %v\n",
+		o.Up, o.Rotation, o.OverhangArea, o.SupportSpace, o.Height, o.ContactArea, o.Score)
+}
+
+// OrientationReport is the result of OptimizeOrientation
+type OrientationReport struct {
+	Best Orientation `json:"best"`
+	//Every orientation tried, from the best to the worst
+	Candidates []Orientation `json:"candidates"`
+}
+
+// largestFaces is how many of the largest flat faces are tried resting on the plate
+const largestFaces = 8
+
+// OptimizeOrientation tries placing the model with every sampled direction pointing up, scoring the
+// overhang area, the support space, the build height and the area resting on the build plate, and
+// returns the candidates sorted by score. The model is not modified.
+func OptimizeOrientation(m *Model, opts OrientationOptions) (r OrientationReport) {
+	samples := opts.Samples
+	if samples <= 0 {
+		samples = 100
+	}
+	weights := opts.Weights
+	if weights == (OrientationWeights{}) {
+		weights = DefaultOrientationWeights
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	directions := orientationDirections(m, samples, opts.Tolerances)
+	r.Candidates = make([]Orientation, len(directions))
+	if len(m.Triangles) == 0 {
+		for i, up := range directions {
+			r.Candidates[i] = Orientation{Up: up, Rotation: rotationBetween(up, Vec3{0, 0, 1})}
+		}
+		r.Best = r.Candidates[0]
+		return r
+	}
+	bvh := NewBVH(m)
+	area := SurfaceArea(m)
+	size := bvh.Bounds().Size()
+	diagonal := size.Length()
+	boxVolume := size[0] * size[1] * size[2]
+	//Flat models have no volume, measure the supports against a cube as big instead
+	if boxVolume <= 0 {
+		boxVolume = diagonal * diagonal * diagonal
+	}
+	jobs := make(chan int)
+	var wait sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for i := range jobs {
+				o := bvh.orientation(directions[i], opts.OverhangAngle, opts.Tolerances)
+				o.Score = weights.Overhang*o.OverhangArea/area + weights.Support*o.SupportSpace/boxVolume +
+					weights.Height*o.Height/diagonal - weights.Contact*o.ContactArea/area
+				r.Candidates[i] = o
+			}
+		}()
+	}
+	for i := range directions {
+		jobs <- i
+	}
+	close(jobs)
+	wait.Wait()
+	//Stable so ties keep the axes, tried first
+	sort.SliceStable(r.Candidates, func(i, j int) bool {
+		return r.Candidates[i].Score < r.Candidates[j].Score
+	})
+	r.Best = r.Candidates[0]
+	return r
+}
+
+// orientation measures the model built towards up without rotating it
+func (bvh *BVH) orientation(up Vec3, overhangAngle float64, tolerances Tolerances) (o Orientation) {
+	m := bvh.Model()
+	o.Up = up
+	o.Rotation = rotationBetween(up, Vec3{0, 0, 1})
+	o.OverhangArea = FindOverhangs(m, OverhangOptions{MaxAngle: overhangAngle, Up: up}).Area
+	o.SupportSpace = bvh.supportSpace(overhangAngle, up, tolerances)
+	lowest, highest := float32(math.MaxFloat32), float32(-math.MaxFloat32)
+	for i := range m.Triangles {
+		for _, v := range m.Triangles[i].Vertices {
+			height := up.Dot(v)
+			lowest = float32(math.Min(float64(lowest), float64(height)))
+			highest = float32(math.Max(float64(highest), float64(height)))
+		}
+	}
+	o.Height = highest - lowest
+	//Same tolerance as FindOverhangs for the facets on the plate
+	tolerance := o.Height * 0.0001
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		facing := triangleNormal(t).Dot(up)
+		if facing >= 0 {
+			continue
+		}
+		onPlate := true
+		for _, v := range t.Vertices {
+			if up.Dot(v)-lowest > tolerance {
+				onPlate = false
+				break
+			}
+		}
+		if onPlate {
+			o.ContactArea += triangleArea(t) * -facing
+		}
+	}
+	return o
+}
+
+// orientationDirections returns the axes, the opposite of the normals of the largest flat faces and
+// samples directions spread over the sphere (a Fibonacci lattice), leaving out the repeated ones
+func orientationDirections(m *Model, samples int, tolerances Tolerances) (directions []Vec3) {
+	add := func(d Vec3) {
+		if d.Length() == 0 {
+			return
+		}
+		d = d.Normalize()
+		for _, e := range directions {
+			if tolerances.SameDirection(d, e) {
+				return
+			}
+		}
+		directions = append(directions, d)
+	}
+	for _, axis := range []Vec3{{0, 0, 1}, {0, 0, -1}, {1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}} {
+		add(axis)
+	}
+	//Facets facing the same way, the flat faces of the model however they are triangulated
+	type face struct {
+		normal Vec3
+		area   float32
+	}
+	faces := make(map[[3]int64]*face)
+	grid := toleranceGrid{cell: tolerances.withDefaults().NormalCell}
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		normal := triangleNormal(t)
+		key := grid.key(normal)
+		if faces[key] == nil {
+			faces[key] = &face{normal: normal}
+		}
+		faces[key].area += triangleArea(t)
+	}
+	largest := make([]*face, 0, len(faces))
+	for _, f := range faces {
+		largest = append(largest, f)
+	}
+	sort.Slice(largest, func(i, j int) bool {
+		if largest[i].area != largest[j].area {
+			return largest[i].area > largest[j].area
+		}
+		return lessFloat32s(largest[i].normal[:], largest[j].normal[:])
+	})
+	for k := 0; k < len(largest) && k < largestFaces; k++ {
+		add(largest[k].normal.Scale(-1))
+	}
+	golden := math.Pi * (3 - math.Sqrt(5))
+	for i := 0; i < samples; i++ {
+		z := 1 - (2*float64(i)+1)/float64(samples)
+		radius := math.Sqrt(1 - z*z)
+		angle := golden * float64(i)
+		add(Vec3{float32(radius * math.Cos(angle)), float32(radius * math.Sin(angle)), float32(z)})
+	}
+	return directions
+}
+
+// rotationBetween returns the rotation taking the unit vector from to the unit vector to along the
+// shortest arc (half a turn around an axis perpendicular to both when they are opposite)
+func rotationBetween(from, to Vec3) Mat3 {
+	cos := from.Dot(to)
+	if cos < -0.9999 {
+		//Any axis perpendicular to from works
+		axis := from.Cross(Vec3{1, 0, 0})
+		if axis.Length() < 0.1 {
+			axis = from.Cross(Vec3{0, 1, 0})
+		}
+		axis = axis.Normalize()
+		var r Mat3
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				r[i][j] = 2 * axis[i] * axis[j]
+				//No negative zeros
+				if r[i][j] == 0 {
+					r[i][j] = 0
+				}
+			}
+			r[i][i]--
+		}
+		return r
+	}
+	//Rodrigues' formula: I + [v]x + [v]x^2 / (1 + cos), v being the cross product
+	v := from.Cross(to)
+	cross := Mat3{{0, -v[2], v[1]}, {v[2], 0, -v[0]}, {-v[1], v[0], 0}}
+	square := cross.Mul(cross)
+	r := Identity()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i][j] += cross[i][j] + square[i][j]/(1+cos)
+		}
+	}
+	return r
+}
+
+// lessFloat32s compares two slices of the same length lexicographically
+func lessFloat32s(a, b []float32) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package model
+
+import "math"
+
+// DefaultOverhangAngle is the steepest overhang most printers manage without supports, in degrees
+// from the vertical
+const DefaultOverhangAngle = 45
+
+// OverhangOptions tunes FindOverhangs
+type OverhangOptions struct {
+	//Largest angle from the vertical a downward facing facet can have without supports, in degrees.
+	//DefaultOverhangAngle if 0.
+	MaxAngle float64
+	//Direction the part is built towards, +Z if zero
+	Up Vec3
+	//Facets closer than this to the lowest point of the model rest on the build plate and never need
+	//supports, 0.0001 times the height of the model if 0
+	PlateTolerance float32
+}
+
+// Overhangs lists the facets that need supports
+type Overhangs struct {
+	Facets []int
+	//Total area of those facets
+	Area float32
+}
+
+// FindOverhangs finds the facets facing down more steeply than the options allow, leaving out the ones
+// resting on the build plate
+func FindOverhangs(m *Model, opts OverhangOptions) (o Overhangs) {
+	maxAngle := opts.MaxAngle
+	if maxAngle <= 0 {
+		maxAngle = DefaultOverhangAngle
+	}
+	up := opts.Up
+	if up == (Vec3{}) {
+		up = Vec3{0, 0, 1}
+	}
+	up = up.Normalize()
+	//A facet overhangs when its normal is closer to straight down than 90 - maxAngle degrees
+	threshold := float32(math.Cos(radians(90 - maxAngle)))
+	//Height of each vertex along the build direction, to find the plate
+	lowest, highest := float32(math.MaxFloat32), float32(-math.MaxFloat32)
+	for i := range m.Triangles {
+		for _, v := range m.Triangles[i].Vertices {
+			height := up.Dot(v)
+			lowest = float32(math.Min(float64(lowest), float64(height)))
+			highest = float32(math.Max(float64(highest), float64(height)))
+		}
+	}
+	tolerance := opts.PlateTolerance
+	if tolerance <= 0 {
+		tolerance = (highest - lowest) * 0.0001
+	}
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		if -triangleNormal(t).Dot(up) <= threshold {
+			continue
+		}
+		onPlate := true
+		for _, v := range t.Vertices {
+			if up.Dot(v)-lowest > tolerance {
+				onPlate = false
+				break
+			}
+		}
+		if onPlate {
+			continue
+		}
+		o.Facets = append(o.Facets, i)
+		o.Area += triangleArea(t)
+	}
+	return o
+}
+
+// HighlightFacets returns a mask of the cells of a projection of the model where one of the facets
+// is the visible surface, to draw them with DrawMatrixPaletteHighlighted, DrawMatrixColorHighlighted
+// or DrawMatrixImageHighlighted over a render of the same viewport and perspective
+func HighlightFacets(m *Model, viewport Viewport, perspective Perspective, opts RenderOptions, facets []int) [][]bool {
+	selected := make(map[int]bool, len(facets))
+	for _, f := range facets {
+		selected[f] = true
+	}
+	buffer := RasterizeDepthBuffer(m, viewport, perspective, opts)
+	mask := make([][]bool, len(buffer.Triangles))
+	for i := range buffer.Triangles {
+		mask[i] = make([]bool, len(buffer.Triangles[i]))
+		for j, t := range buffer.Triangles[i] {
+			mask[i][j] = t >= 0 && selected[t]
+		}
+	}
+	return mask
+}
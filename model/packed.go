@@ -0,0 +1,182 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Size of a triangle in a binary STL: normal, vertices and attribute byte count
+const binaryTriangleSize = 50
+
+// PackedModel holds the triangles of a model as separate arrays of numbers instead of a slice of
+// Triangle. Loops going over one of them, like the positions to compute the bounds, read only what
+// they need and in order. Pack and Unpack convert from and to a Model.
+type PackedModel struct {
+	Header string
+	Name   string
+	//The three vertices of every triangle one after the other, x, y and z each: 9 numbers per triangle
+	Positions []float32
+	//The normal of every triangle, x, y and z: 3 numbers per triangle
+	Normals []float32
+	//Attribute byte count of every triangle
+	Attributes []uint16
+}
+
+// Pack copies the triangles of the model to a PackedModel
+func Pack(m *Model) (p PackedModel) {
+	p = PackedModel{
+		Header:     m.Header,
+		Name:       m.Name,
+		Positions:  make([]float32, 0, 9*len(m.Triangles)),
+		Normals:    make([]float32, 0, 3*len(m.Triangles)),
+		Attributes: make([]uint16, 0, len(m.Triangles)),
+	}
+	for i := range m.Triangles {
+		p.append(&m.Triangles[i])
+	}
+	return p
+}
+
+// Unpack copies the triangles back to a Model
+func (p *PackedModel) Unpack() (m Model) {
+	m = Model{Header: p.Header, Name: p.Name, Triangles: make([]Triangle, p.Len())}
+	for i := range m.Triangles {
+		m.Triangles[i] = p.Triangle(i)
+	}
+	m.NumTriangles = uint32(len(m.Triangles))
+	return m
+}
+
+// append adds a triangle at the end of the arrays
+func (p *PackedModel) append(t *Triangle) {
+	p.Normals = append(p.Normals, t.Normal[:]...)
+	for j := range t.Vertices {
+		p.Positions = append(p.Positions, t.Vertices[j][:]...)
+	}
+	p.Attributes = append(p.Attributes, t.AttrByteCount)
+}
+
+// Len returns the number of triangles
+func (p *PackedModel) Len() int {
+	return len(p.Attributes)
+}
+
+// Triangle returns the triangle i
+func (p *PackedModel) Triangle(i int) (t Triangle) {
+	copy(t.Normal[:], p.Normals[3*i:3*i+3])
+	for j := range t.Vertices {
+		copy(t.Vertices[j][:], p.Positions[9*i+3*j:9*i+3*j+3])
+	}
+	t.AttrByteCount = p.Attributes[i]
+	return t
+}
+
+// Vertex returns the vertex j of the triangle i
+func (p *PackedModel) Vertex(i, j int) Vec3 {
+	start := 9*i + 3*j
+	return Vec3{p.Positions[start], p.Positions[start+1], p.Positions[start+2]}
+}
+
+// Bounds returns the box around the triangles, empty when there are none
+func (p *PackedModel) Bounds() Box {
+	bounds := EmptyBox()
+	positions := p.Positions
+	for i := 0; i+2 < len(positions); i += 3 {
+		x, y, z := positions[i], positions[i+1], positions[i+2]
+		bounds.Min[0], bounds.Max[0] = min(bounds.Min[0], x), max(bounds.Max[0], x)
+		bounds.Min[1], bounds.Max[1] = min(bounds.Min[1], y), max(bounds.Max[1], y)
+		bounds.Min[2], bounds.Max[2] = min(bounds.Min[2], z), max(bounds.Max[2], z)
+	}
+	return bounds
+}
+
+// SurfaceArea returns the total area of the triangles, like the SurfaceArea function for a Model
+func (p *PackedModel) SurfaceArea() float32 {
+	var area float64
+	for i := 0; i < p.Len(); i++ {
+		a := p.Vertex(i, 0)
+		area += float64(p.Vertex(i, 1).Sub(a).Cross(p.Vertex(i, 2).Sub(a)).Length() / 2)
+	}
+	return float32(area)
+}
+
+// Volume returns the volume enclosed by the triangles, like the Volume function for a Model
+func (p *PackedModel) Volume() float32 {
+	var volume float64
+	for i := 0; i < p.Len(); i++ {
+		volume += float64(p.Vertex(i, 0).Dot(p.Vertex(i, 1).Cross(p.Vertex(i, 2))))
+	}
+	return float32(volume / 6)
+}
+
+// CreatePackedFromBinarySTL reads a binary STL straight into a PackedModel, without going through a
+// slice of Triangle, and leaves anything after the triangles in r. The count in the header is not
+// trusted: room is only reserved up front for the triangles r can still hold when it is an io.Seeker,
+// otherwise the arrays grow as they are read.
+func CreatePackedFromBinarySTL(r io.Reader) (p PackedModel, err error) {
+	header := make([]byte, binaryHeaderSize+4)
+	if _, err = io.ReadFull(r, header); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return p, err
+	}
+	p.Header = string(bytes.TrimRight(header[:binaryHeaderSize], "\x00"))
+	count := binary.LittleEndian.Uint32(header[binaryHeaderSize:])
+	reserved := availableRecords(r, int(count))
+	p.Positions = make([]float32, 0, 9*reserved)
+	p.Normals = make([]float32, 0, 3*reserved)
+	p.Attributes = make([]uint16, 0, reserved)
+	_, err = readRecords(r, count, func(record []byte) {
+		for k := 0; k < 12; k++ {
+			value := math.Float32frombits(binary.LittleEndian.Uint32(record[4*k:]))
+			if k < 3 {
+				p.Normals = append(p.Normals, value)
+			} else {
+				p.Positions = append(p.Positions, value)
+			}
+		}
+		p.Attributes = append(p.Attributes, binary.LittleEndian.Uint16(record[48:]))
+	})
+	if err == io.ErrUnexpectedEOF {
+		err = errors.New("Truncated binary STL")
+	}
+	return p, err
+}
+
+// availableRecords returns how many of the count triangle records announced are left in r, as far as
+// it can tell without reading: none when r is not an io.Seeker
+func availableRecords(r io.Reader, count int) int {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return 0
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if _, errBack := seeker.Seek(current, io.SeekStart); err != nil || errBack != nil {
+		return 0
+	}
+	return int(min(int64(count), max(end-current, 0)/binaryTriangleSize))
+}
+
+// CreatePackedFromSTLBytes reads an ASCII or binary STL from memory into a PackedModel, ASCII files
+// going through a Model first
+func CreatePackedFromSTLBytes(data []byte) (p PackedModel, err error) {
+	if bytes.HasPrefix(data, []byte("solid")) {
+		m, err := CreateFromASCIISTL(bufio.NewReader(bytes.NewReader(data)))
+		if len(m.Triangles) > 0 {
+			return Pack(&m), err
+		}
+	}
+	if len(data) < binaryHeaderSize+4 || uint64(len(data)-binaryHeaderSize-4) < binaryTriangleSize*uint64(binary.LittleEndian.Uint32(data[binaryHeaderSize:])) {
+		return p, errors.New("Truncated binary STL")
+	}
+	return CreatePackedFromBinarySTL(bytes.NewReader(data))
+}
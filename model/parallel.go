@@ -0,0 +1,74 @@
+package model
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Models with fewer triangles than this are scanned by a single goroutine, starting more costs more
+// than it saves
+const parallelThreshold = 1 << 16
+
+// Triangles in each chunk of a parallel scan. The chunks don't depend on the number of CPUs, so
+// neither do the sums added chunk by chunk.
+const parallelChunk = 1 << 14
+
+// parallelChunks splits the n triangles in chunks and calls work for each of them from as many
+// goroutines as GOMAXPROCS, or for all of them at once as chunk 0 below parallelThreshold. It returns
+// the number of chunks.
+func parallelChunks(n int, work func(chunk, start, end int)) (chunks int) {
+	if n < parallelThreshold {
+		work(0, 0, n)
+		return 1
+	}
+	chunks = (n + parallelChunk - 1) / parallelChunk
+	workers := min(runtime.GOMAXPROCS(0), chunks)
+	var next int64 = -1
+	var wait sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for {
+				chunk := int(atomic.AddInt64(&next, 1))
+				if chunk >= chunks {
+					return
+				}
+				work(chunk, chunk*parallelChunk, min((chunk+1)*parallelChunk, n))
+			}
+		}()
+	}
+	wait.Wait()
+	return chunks
+}
+
+// parallelSum adds term(i) for the n triangles with parallelChunks, in the same order every time
+func parallelSum(n int, term func(i int) float64) (sum float64) {
+	partials := make([]float64, (n+parallelChunk-1)/parallelChunk+1)
+	chunks := parallelChunks(n, func(chunk, start, end int) {
+		var partial float64
+		for i := start; i < end; i++ {
+			partial += term(i)
+		}
+		partials[chunk] = partial
+	})
+	for _, partial := range partials[:chunks] {
+		sum += partial
+	}
+	return sum
+}
+
+// parallelMinsMaxs computes what getMinsMaxs does for big models with parallelChunks
+func parallelMinsMaxs(m *Model) (mins [3]float32, maxs [3]float32) {
+	boxes := make([]Box, (len(m.Triangles)+parallelChunk-1)/parallelChunk+1)
+	chunks := parallelChunks(len(m.Triangles), func(chunk, start, end int) {
+		mins, maxs := trianglesMinsMaxs(m.Triangles[start:end])
+		boxes[chunk] = Box{Min: mins, Max: maxs}
+	})
+	bounds := EmptyBox()
+	for _, box := range boxes[:chunks] {
+		bounds = bounds.Union(box)
+	}
+	return bounds.Min, bounds.Max
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// benchmarkModel returns a model of n random triangles in the unit cube
+func benchmarkModel(n int) *Model {
+	random := rand.New(rand.NewSource(1))
+	m := &Model{Triangles: make([]Triangle, n), NumTriangles: uint32(n)}
+	for i := range m.Triangles {
+		for j := range m.Triangles[i].Vertices {
+			m.Triangles[i].Vertices[j] = [3]float32{random.Float32(), random.Float32(), random.Float32()}
+		}
+	}
+	return m
+}
+
+// benchmarkScan runs scan over models below and above parallelThreshold, dropping the cache before
+// every run so the triangles are scanned each time
+func benchmarkScan(b *testing.B, scan func(m *Model)) {
+	for _, n := range []int{parallelThreshold / 4, parallelThreshold - 1, 4 * parallelThreshold} {
+		m := benchmarkModel(n)
+		b.Run(fmt.Sprintf("triangles=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.Invalidate()
+				scan(m)
+			}
+		})
+	}
+}
+
+func BenchmarkBounds(b *testing.B) {
+	benchmarkScan(b, func(m *Model) { m.Bounds() })
+}
+
+func BenchmarkSurfaceArea(b *testing.B) {
+	benchmarkScan(b, func(m *Model) { m.SurfaceArea() })
+}
+
+func BenchmarkVolume(b *testing.B) {
+	benchmarkScan(b, func(m *Model) { m.Volume() })
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// STLSummary describes a binary STL from its header and a sample of its triangles, without reading
+// the whole file
+type STLSummary struct {
+	Header string `json:"header"`
+	//Triangles announced by the header
+	NumTriangles uint32 `json:"numTriangles"`
+	//Triangles read to find the bounds, spread evenly over the file
+	Sampled int `json:"sampled"`
+	//Box around the sampled triangles, inside the real one and the same when every triangle is sampled,
+	//nil when none is
+	Bounds *Box `json:"bounds,omitempty"`
+}
+
+// String prints the summary in the same style as the Model
+func (s STLSummary) String() string {
+	text := fmt.Sprintf("Header: %v\nTriangles: %v\nSampled: %v\n", s.Header, s.NumTriangles, s.Sampled)
+	if s.Bounds != nil {
+		text += fmt.Sprintf("Dimensions (sampled): %v\n", s.Bounds.Size())
+	}
+	return text
+}
+
+// readBinaryCount reads the triangle count of a binary STL, checking that the size of the data has room
+// for them when it is known (not negative)
+func readBinaryCount(r io.ReaderAt, size int64) (header [binaryHeaderSize]byte, count uint32, err error) {
+	var start [binaryHeaderSize + 4]byte
+	//ReaderAt may return io.EOF along with the whole range when it ends the data, only a short read fails
+	if n, err := r.ReadAt(start[:], 0); n < len(start) {
+		if err == io.EOF {
+			err = errors.New("Truncated binary STL")
+		}
+		return header, 0, err
+	}
+	copy(header[:], start[:])
+	count = binary.LittleEndian.Uint32(start[binaryHeaderSize:])
+	if size >= 0 && (size < int64(len(start)) || uint64(size-int64(len(start))) < binaryTriangleSize*uint64(count)) {
+		if bytes.HasPrefix(start[:], []byte("solid")) {
+			return header, 0, errors.New("Only binary STL files can be read in parts")
+		}
+		return header, 0, errors.New("Truncated binary STL")
+	}
+	return header, count, nil
+}
+
+// ReadTriangleRange reads count triangles of a binary STL starting with the triangle at index from,
+// without reading the rest of the file. The range must be within the triangles of the header.
+func ReadTriangleRange(r io.ReaderAt, from, count int) ([]Triangle, error) {
+	if from < 0 || count < 0 {
+		return nil, errors.New("Triangle range can't be negative")
+	}
+	_, total, err := readBinaryCount(r, -1)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(from)+uint64(count) > uint64(total) {
+		return nil, fmt.Errorf("Triangles %d to %d are past the %d of the file", from, from+count, total)
+	}
+	data := make([]byte, binaryTriangleSize*count)
+	if n, err := r.ReadAt(data, binaryHeaderSize+4+binaryTriangleSize*int64(from)); n < len(data) {
+		if err == io.EOF {
+			err = errors.New("Truncated binary STL")
+		}
+		return nil, err
+	}
+	triangles := make([]Triangle, count)
+	for i := range triangles {
+		decodeTriangle(data[binaryTriangleSize*i:], &triangles[i], binary.LittleEndian)
+	}
+	return triangles, nil
+}
+
+// ReadSTLSummary reads the header and the triangle count of a binary STL of the given size in bytes
+// (negative if unknown), and the bounds of up to samples triangles spread over the file (none if 0).
+// ASCII files are refused, they can't be read in parts.
+func ReadSTLSummary(r io.ReaderAt, size int64, samples int) (s STLSummary, err error) {
+	header, count, err := readBinaryCount(r, size)
+	if err != nil {
+		return s, err
+	}
+	s.Header, s.NumTriangles = string(bytes.TrimRight(header[:], "\x00")), count
+	if samples > int(count) {
+		samples = int(count)
+	}
+	var record [binaryTriangleSize]byte
+	var t Triangle
+	bounds := EmptyBox()
+	for i := 0; i < samples; i++ {
+		index := int64(i) * int64(count) / int64(samples)
+		if n, err := r.ReadAt(record[:], binaryHeaderSize+4+binaryTriangleSize*index); n < len(record) {
+			if err == io.EOF {
+				err = errors.New("Truncated binary STL")
+			}
+			return s, err
+		}
+		decodeTriangle(record[:], &t, binary.LittleEndian)
+		for _, v := range t.Vertices {
+			bounds = bounds.Extend(v)
+		}
+	}
+	s.Sampled = samples
+	if samples > 0 {
+		s.Bounds = &bounds
+	}
+	return s, nil
+}
+
+// ReadSTLSummaryFile opens a binary STL file and summarizes it with ReadSTLSummary
+func ReadSTLSummaryFile(path string, samples int) (STLSummary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return STLSummary{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return STLSummary{}, err
+	}
+	return ReadSTLSummary(file, info.Size(), samples)
+}
@@ -0,0 +1,219 @@
+package model
+
+import (
+	"errors"
+	"sort"
+)
+
+// PolygonArea returns the signed area of the polygon, positive when it is counter-clockwise
+func PolygonArea(polygon []Vec2) float32 {
+	var area float64
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		area += float64(a[0])*float64(b[1]) - float64(b[0])*float64(a[1])
+	}
+	return float32(area / 2)
+}
+
+// CleanPolygon drops repeated consecutive points, including a closing point equal to the first one
+func CleanPolygon(polygon []Vec2) []Vec2 {
+	cleaned := make([]Vec2, 0, len(polygon))
+	for _, p := range polygon {
+		if len(cleaned) == 0 || cleaned[len(cleaned)-1] != p {
+			cleaned = append(cleaned, p)
+		}
+	}
+	for len(cleaned) > 1 && cleaned[0] == cleaned[len(cleaned)-1] {
+		cleaned = cleaned[:len(cleaned)-1]
+	}
+	return cleaned
+}
+
+// Triangulate splits a simple polygon in triangles by ear clipping. The returned triangles index the
+// polygon points and are counter-clockwise, whatever the orientation of the polygon.
+func Triangulate(polygon []Vec2) (triangles [][3]int, err error) {
+	if len(polygon) < 3 {
+		return triangles, errors.New("A polygon needs at least 3 points")
+	}
+	//Work on a counter-clockwise list of the remaining points
+	remaining := make([]int, len(polygon))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	if PolygonArea(polygon) < 0 {
+		for i, j := 0, len(remaining)-1; i < j; i, j = i+1, j-1 {
+			remaining[i], remaining[j] = remaining[j], remaining[i]
+		}
+	}
+	triangles = make([][3]int, 0, len(polygon)-2)
+	for len(remaining) > 3 {
+		//Prefer real ears, flat ones (collinear points) are only clipped when nothing else is left
+		ear, flatEar := -1, -1
+		for i := range remaining {
+			prev, cur, next := remaining[(i+len(remaining)-1)%len(remaining)], remaining[i], remaining[(i+1)%len(remaining)]
+			if isEar(polygon, remaining, prev, cur, next) {
+				if polygon[cur].Sub(polygon[prev]).Cross(polygon[next].Sub(polygon[prev])) != 0 {
+					ear = i
+					break
+				}
+				if flatEar < 0 {
+					flatEar = i
+				}
+			}
+		}
+		if ear < 0 {
+			ear = flatEar
+		}
+		if ear < 0 {
+			//Only collinear points are left or the polygon intersects itself
+			return triangles, errors.New("Polygon can't be triangulated, it may be self intersecting")
+		}
+		prev, next := remaining[(ear+len(remaining)-1)%len(remaining)], remaining[(ear+1)%len(remaining)]
+		//Skip the triangle if the ear is just a collinear point
+		if polygon[remaining[ear]].Sub(polygon[prev]).Cross(polygon[next].Sub(polygon[prev])) != 0 {
+			triangles = append(triangles, [3]int{prev, remaining[ear], next})
+		}
+		remaining = append(remaining[:ear], remaining[ear+1:]...)
+	}
+	if polygon[remaining[1]].Sub(polygon[remaining[0]]).Cross(polygon[remaining[2]].Sub(polygon[remaining[0]])) != 0 {
+		triangles = append(triangles, [3]int{remaining[0], remaining[1], remaining[2]})
+	}
+	return triangles, nil
+}
+
+// TriangulateWithHoles splits a polygon with holes in triangles, bridging every hole to the outside
+// of the polygon with a pair of edges and clipping the ears of the result. The returned triangles are
+// counter-clockwise and index the points of the outer polygon followed by the points of each hole in
+// order. The holes must be inside the polygon and not touch each other.
+func TriangulateWithHoles(outer []Vec2, holes [][]Vec2) (triangles [][3]int, err error) {
+	//The merged polygon as indices of the points, counter-clockwise with clockwise holes
+	var points []Vec2
+	merged := make([]int, len(outer))
+	for i, p := range outer {
+		points = append(points, p)
+		merged[i] = i
+	}
+	if PolygonArea(outer) < 0 {
+		reverseInts(merged)
+	}
+	loops := make([][]int, len(holes))
+	for h, hole := range holes {
+		loops[h] = make([]int, len(hole))
+		for i, p := range hole {
+			loops[h][i] = len(points)
+			points = append(points, p)
+		}
+		if PolygonArea(hole) > 0 {
+			reverseInts(loops[h])
+		}
+	}
+	//Rightmost point of every hole, bridging first the holes closer to the right
+	rightmost := func(loop []int) (best int) {
+		for i := range loop {
+			if points[loop[i]][0] > points[loop[best]][0] {
+				best = i
+			}
+		}
+		return best
+	}
+	sort.Slice(loops, func(a, b int) bool {
+		return points[loops[a][rightmost(loops[a])]][0] > points[loops[b][rightmost(loops[b])]][0]
+	})
+	for h, loop := range loops {
+		start := rightmost(loop)
+		m := points[loop[start]]
+		//Edges a bridge must not cross: the merged polygon and the holes left
+		var edges [][2]int
+		for _, l := range append([][]int{merged}, loops[h:]...) {
+			for i := range l {
+				edges = append(edges, [2]int{l[i], l[(i+1)%len(l)]})
+			}
+		}
+		//The closest point of the merged polygon it can be bridged to
+		candidates := make([]int, len(merged))
+		for i := range candidates {
+			candidates[i] = i
+		}
+		distance := func(i int) float32 {
+			d := points[merged[i]].Sub(m)
+			return d[0]*d[0] + d[1]*d[1]
+		}
+		sort.Slice(candidates, func(a, b int) bool { return distance(candidates[a]) < distance(candidates[b]) })
+		bridge := -1
+		for _, i := range candidates {
+			if bridgeVisible(points, edges, loop[start], merged[i]) {
+				bridge = i
+				break
+			}
+		}
+		if bridge < 0 {
+			return triangles, errors.New("Hole can't be bridged, it may be outside the polygon")
+		}
+		//Go into the hole and around it, coming back to the same points
+		var joined []int
+		joined = append(joined, merged[:bridge+1]...)
+		for i := 0; i <= len(loop); i++ {
+			joined = append(joined, loop[(start+i)%len(loop)])
+		}
+		joined = append(joined, merged[bridge:]...)
+		merged = joined
+	}
+	polygon := make([]Vec2, len(merged))
+	for i, p := range merged {
+		polygon[i] = points[p]
+	}
+	clipped, err := Triangulate(polygon)
+	for _, t := range clipped {
+		triangles = append(triangles, [3]int{merged[t[0]], merged[t[1]], merged[t[2]]})
+	}
+	return triangles, err
+}
+
+// bridgeVisible checks if the segment between the points a and b crosses none of the edges, other
+// than at their ends
+func bridgeVisible(points []Vec2, edges [][2]int, a, b int) bool {
+	p, q := points[a], points[b]
+	for _, edge := range edges {
+		r, s := points[edge[0]], points[edge[1]]
+		if r == p || r == q || s == p || s == q {
+			continue
+		}
+		d1, d2 := q.Sub(p).Cross(r.Sub(p)), q.Sub(p).Cross(s.Sub(p))
+		d3, d4 := s.Sub(r).Cross(p.Sub(r)), s.Sub(r).Cross(q.Sub(r))
+		if ((d1 > 0) != (d2 > 0) || d1 == 0 || d2 == 0) && ((d3 > 0) != (d4 > 0) || d3 == 0 || d4 == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// reverseInts reverses a slice in place
+func reverseInts(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// isEar checks if the vertex cur can be clipped: convex (or flat) and with no other point inside
+func isEar(polygon []Vec2, remaining []int, prev, cur, next int) bool {
+	a, b, c := polygon[prev], polygon[cur], polygon[next]
+	if b.Sub(a).Cross(c.Sub(b)) < 0 {
+		//Reflex vertex
+		return false
+	}
+	for _, i := range remaining {
+		//Points repeated by the bridges of TriangulateWithHoles don't block the ear
+		if i == prev || i == cur || i == next || polygon[i] == a || polygon[i] == b || polygon[i] == c {
+			continue
+		}
+		if pointInTriangle2D(polygon[i], a, b, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// pointInTriangle2D checks if p is inside or on the counter-clockwise triangle abc
+func pointInTriangle2D(p, a, b, c Vec2) bool {
+	return b.Sub(a).Cross(p.Sub(a)) >= 0 && c.Sub(b).Cross(p.Sub(b)) >= 0 && a.Sub(c).Cross(p.Sub(c)) >= 0
+}
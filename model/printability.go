@@ -0,0 +1,195 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity tells how much an issue found by CheckPrintability gets in the way of printing
+type Severity int
+
+const (
+	//Nothing to worry about
+	SeverityOK Severity = iota
+	//The part prints, but maybe not as modeled
+	SeverityWarning
+	//The part won't print, or the slicer will have to guess
+	SeverityError
+)
+
+// String returns the name of the severity, as used in the JSON
+func (s Severity) String() string {
+	switch s {
+	case SeverityOK:
+		return "ok"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText writes the severity by name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// PrinterProfile describes the printer checked against by CheckPrintability, with the model in millimeters
+type PrinterProfile struct {
+	//Size of the space the printer can build in
+	BuildVolume Vec3
+	//Width of a printed line, nothing narrower prints
+	NozzleDiameter float32
+	//Thinnest wall printed reliably, twice the nozzle diameter if 0
+	MinWallThickness float32
+}
+
+// DefaultPrinterProfile matches a common desktop FDM printer
+var DefaultPrinterProfile = PrinterProfile{
+	BuildVolume:      Vec3{220, 220, 250},
+	NozzleDiameter:   0.4,
+	MinWallThickness: 0.8,
+}
+
+// PrintabilityIssue is the result of one of the checks of CheckPrintability
+type PrintabilityIssue struct {
+	//Name of the check: watertight, manifold, orientation, degenerate, thin-walls, tiny-features or build-volume
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	//Facets causing the issue, when it can be pinned down to some
+	Facets []int `json:"facets,omitempty"`
+}
+
+// PrintabilityReport gathers the result of every check, in the order they are made
+type PrintabilityReport struct {
+	//No check found an error
+	Printable bool                `json:"printable"`
+	Issues    []PrintabilityIssue `json:"issues"`
+}
+
+// Worst returns the highest severity among the issues
+func (r PrintabilityReport) Worst() (worst Severity) {
+	for _, issue := range r.Issues {
+		if issue.Severity > worst {
+			worst = issue.Severity
+		}
+	}
+	return worst
+}
+
+// String prints one line per check
+func (r PrintabilityReport) String() string {
+	var b strings.Builder
+	for _, issue := range r.Issues {
+		fmt.Fprintf(&b, "%v: %v: %v\n", issue.Check, issue.Severity, issue.Message)
+	}
+	fmt.Fprintf(&b, "Printable: %v\n", r.Printable)
+	return b.String()
+}
+
+// CheckPrintability runs the checks that tell if a model can be printed with a printer: the numbers
+// are finite, the surface is closed, manifold and consistently oriented, without degenerate facets,
+// walls thinner than the profile allows or pieces smaller than the nozzle, and it fits in the build
+// volume (as it is, or turned around the axes). Every check appears in the report, with SeverityOK when it passed.
+func CheckPrintability(m *Model, profile PrinterProfile) (r PrintabilityReport) {
+	minWall := profile.MinWallThickness
+	if minWall <= 0 {
+		minWall = 2 * profile.NozzleDiameter
+	}
+	topology := NewTopology(m)
+	add := func(check string, severity Severity, facets []int, format string, args ...interface{}) {
+		r.Issues = append(r.Issues, PrintabilityIssue{Check: check, Severity: severity, Message: fmt.Sprintf(format, args...), Facets: facets})
+	}
+
+	if nonFinite := NonFiniteFacets(m); len(nonFinite) > 0 {
+		add("finite", SeverityError, nonFinite, "%v facets have NaN or infinite numbers", len(nonFinite))
+	} else {
+		add("finite", SeverityOK, nil, "Every number is finite")
+	}
+	if len(m.Triangles) == 0 {
+		add("watertight", SeverityError, nil, "The model has no facets")
+	} else if boundary := topology.BoundaryEdges(); boundary > 0 {
+		add("watertight", SeverityError, nil, "%v edges border holes in the surface", boundary)
+	} else {
+		add("watertight", SeverityOK, nil, "The surface is closed")
+	}
+	if nonManifold := topology.NonManifoldEdges(); nonManifold > 0 {
+		add("manifold", SeverityError, nil, "%v edges are shared by more than two facets", nonManifold)
+	} else {
+		add("manifold", SeverityOK, nil, "Every edge is shared by at most two facets")
+	}
+	if flipped := topology.InconsistentEdges(); flipped > 0 {
+		add("orientation", SeverityError, nil, "%v edges are between facets facing opposite ways", flipped)
+	} else {
+		add("orientation", SeverityOK, nil, "The facets are consistently oriented")
+	}
+	if degenerate := topology.DegenerateFaces(); len(degenerate) > 0 {
+		add("degenerate", SeverityWarning, degenerate, "%v facets have no area", len(degenerate))
+	} else {
+		add("degenerate", SeverityOK, nil, "Every facet has an area")
+	}
+
+	thinWalls := FindThinWalls(m, minWall)
+	switch {
+	case len(thinWalls.Facets) == 0:
+		add("thin-walls", SeverityOK, nil, "No wall is thinner than %v", minWall)
+	case thinWalls.Thinnest < profile.NozzleDiameter:
+		add("thin-walls", SeverityError, thinWalls.Facets, "%v facets (area %v) are on walls thinner than %v, down to %v, below the nozzle diameter %v",
+			len(thinWalls.Facets), thinWalls.Area, minWall, thinWalls.Thinnest, profile.NozzleDiameter)
+	default:
+		add("thin-walls", SeverityWarning, thinWalls.Facets, "%v facets (area %v) are on walls thinner than %v, down to %v",
+			len(thinWalls.Facets), thinWalls.Area, minWall, thinWalls.Thinnest)
+	}
+
+	//Pieces that fit in the nozzle are either lost or printed as a blob
+	var tiny []int
+	pieces := 0
+	for _, shell := range topology.Shells() {
+		bounds := EmptyBox()
+		for _, f := range shell {
+			bounds = bounds.Union(m.Triangles[f].Bounds())
+		}
+		size := bounds.Size()
+		if size[0] < profile.NozzleDiameter && size[1] < profile.NozzleDiameter && size[2] < profile.NozzleDiameter {
+			tiny = append(tiny, shell...)
+			pieces++
+		}
+	}
+	if pieces > 0 {
+		sort.Ints(tiny)
+		add("tiny-features", SeverityWarning, tiny, "%v pieces are smaller than the nozzle diameter %v", pieces, profile.NozzleDiameter)
+	} else {
+		add("tiny-features", SeverityOK, nil, "No piece is smaller than the nozzle diameter %v", profile.NozzleDiameter)
+	}
+
+	var dimensions Vec3
+	if len(m.Triangles) > 0 {
+		mins, maxs := getMinsMaxs(m)
+		dimensions = Vec3(maxs).Sub(mins)
+	}
+	switch {
+	case fitsIn(dimensions, profile.BuildVolume):
+		add("build-volume", SeverityOK, nil, "The model (%v) fits in the build volume %v", dimensions, profile.BuildVolume)
+	case fitsIn(sortedVec3(dimensions), sortedVec3(profile.BuildVolume)):
+		add("build-volume", SeverityWarning, nil, "The model (%v) only fits in the build volume %v turned around", dimensions, profile.BuildVolume)
+	default:
+		add("build-volume", SeverityError, nil, "The model (%v) doesn't fit in the build volume %v", dimensions, profile.BuildVolume)
+	}
+
+	r.Printable = r.Worst() < SeverityError
+	return r
+}
+
+// fitsIn checks if a box of some size fits in another one without turning it
+func fitsIn(size, space Vec3) bool {
+	return size[0] <= space[0] && size[1] <= space[1] && size[2] <= space[2]
+}
+
+// sortedVec3 returns the coordinates of v in increasing order
+func sortedVec3(v Vec3) Vec3 {
+	sort.Slice(v[:], func(i, j int) bool { return v[i] < v[j] })
+	return v
+}
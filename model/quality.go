@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// qualityBins is the number of bins of the histograms of QualityStats
+const qualityBins = 10
+
+// Needles have an edge shorter than this fraction of their longest one, and slivers are the other
+// triangles with an AspectRatio above qualitySliverRatio
+const (
+	qualityNeedleRatio = 0.1
+	qualitySliverRatio = 10
+)
+
+// Histogram counts values in bins of equal width from Min to Max
+type Histogram struct {
+	Min    float32 `json:"min"`
+	Max    float32 `json:"max"`
+	Counts []int   `json:"counts"`
+}
+
+// newHistogram spreads the values in qualityBins bins between the smallest and the biggest of them
+func newHistogram(values []float32) (h Histogram) {
+	if len(values) == 0 {
+		return h
+	}
+	h.Min, h.Max = values[0], values[0]
+	for _, v := range values {
+		h.Min, h.Max = float32(math.Min(float64(h.Min), float64(v))), float32(math.Max(float64(h.Max), float64(v)))
+	}
+	h.Counts = make([]int, qualityBins)
+	for _, v := range values {
+		bin := 0
+		if h.Max > h.Min {
+			bin = int(float32(qualityBins) * (v - h.Min) / (h.Max - h.Min))
+		}
+		if bin >= qualityBins {
+			bin = qualityBins - 1
+		}
+		h.Counts[bin]++
+	}
+	return h
+}
+
+// String prints the bins one per line with their range and count
+func (h Histogram) String() string {
+	var b strings.Builder
+	width := (h.Max - h.Min) / float32(len(h.Counts))
+	for i, count := range h.Counts {
+		fmt.Fprintf(&b, "  %v - %v: %v\n", h.Min+width*float32(i), h.Min+width*float32(i+1), count)
+	}
+	return b.String()
+}
+
+// QualityStats describes the shape of the triangles of a model. The aspect ratio and the smallest
+// angle leave out the triangles without area.
+type QualityStats struct {
+	Area        Histogram `json:"area"`
+	EdgeLength  Histogram `json:"edgeLength"`
+	AspectRatio Histogram `json:"aspectRatio"`
+	MinAngle    Histogram `json:"minAngle"`
+	//Thin triangles with an edge much shorter than the others
+	Needles int `json:"needles"`
+	//Thin triangles with an angle close to 180 degrees and no short edge
+	Slivers int `json:"slivers"`
+}
+
+// Stats measures the QualityStats of the triangles of the model
+func (m *Model) Stats() (s QualityStats) {
+	areas := make([]float32, 0, len(m.Triangles))
+	lengths := make([]float32, 0, 3*len(m.Triangles))
+	var ratios, angles []float32
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		area := t.Area()
+		areas = append(areas, area)
+		edges := t.edgeLengths()
+		lengths = append(lengths, edges[:]...)
+		if area == 0 {
+			continue
+		}
+		ratio := t.AspectRatio()
+		ratios = append(ratios, ratio)
+		angles = append(angles, float32(t.MinAngle()))
+		shortest := math.Min(float64(edges[0]), math.Min(float64(edges[1]), float64(edges[2])))
+		longest := math.Max(float64(edges[0]), math.Max(float64(edges[1]), float64(edges[2])))
+		switch {
+		case shortest < qualityNeedleRatio*longest:
+			s.Needles++
+		case ratio > qualitySliverRatio:
+			s.Slivers++
+		}
+	}
+	s.Area, s.EdgeLength = newHistogram(areas), newHistogram(lengths)
+	s.AspectRatio, s.MinAngle = newHistogram(ratios), newHistogram(angles)
+	return s
+}
+
+// String prints the stats in the same style as the Model
+func (s QualityStats) String() string {
+	return fmt.Sprintf("Needles: %v\nSlivers: %v\nArea:\n%vEdge length:\n%vAspect ratio:\n%vMin angle:\n%v",
+		s.Needles, s.Slivers, s.Area, s.EdgeLength, s.AspectRatio, s.MinAngle)
+}
@@ -0,0 +1,167 @@
+package model
+
+import "math"
+
+// Quaternion is a rotation as a unit quaternion W + Xi + Yj + Zk. Unlike Euler angles it has no
+// gimbal lock, and composing many of them doesn't drift away from a rotation once normalized.
+type Quaternion struct {
+	W, X, Y, Z float64
+}
+
+// IdentityQuaternion returns the rotation leaving everything in place
+func IdentityQuaternion() Quaternion {
+	return Quaternion{W: 1}
+}
+
+// QuaternionFromAxisAngle returns the rotation of angle radians around the axis (counter-clockwise
+// looking from where it points), the identity if the axis is zero
+func QuaternionFromAxisAngle(axis Vec3, angle float64) Quaternion {
+	axis = axis.Normalize()
+	if axis == (Vec3{}) {
+		return IdentityQuaternion()
+	}
+	sin := math.Sin(angle / 2)
+	return Quaternion{W: math.Cos(angle / 2), X: float64(axis[0]) * sin, Y: float64(axis[1]) * sin, Z: float64(axis[2]) * sin}
+}
+
+// QuaternionFromEuler returns the rotation of x radians around the X axis, then y around Y and then
+// z around Z, the same as RotateX(x).Then(RotateY(y)).Then(RotateZ(z))
+func QuaternionFromEuler(x, y, z float64) Quaternion {
+	return QuaternionFromAxisAngle(Vec3{0, 0, 1}, z).
+		Mul(QuaternionFromAxisAngle(Vec3{0, 1, 0}, y)).
+		Mul(QuaternionFromAxisAngle(Vec3{1, 0, 0}, x))
+}
+
+// QuaternionFromMatrix returns the rotation of a rotation matrix, such as the Linear part of the
+// rotations of this package
+func QuaternionFromMatrix(m Mat3) Quaternion {
+	var a [3][3]float64
+	for i := range m {
+		for j := range m[i] {
+			a[i][j] = float64(m[i][j])
+		}
+	}
+	//Divide by the largest of the four components to stay away from the cancellations of the others
+	var q Quaternion
+	switch trace := a[0][0] + a[1][1] + a[2][2]; {
+	case trace > 0:
+		s := 2 * math.Sqrt(trace+1)
+		q = Quaternion{W: s / 4, X: (a[2][1] - a[1][2]) / s, Y: (a[0][2] - a[2][0]) / s, Z: (a[1][0] - a[0][1]) / s}
+	case a[0][0] > a[1][1] && a[0][0] > a[2][2]:
+		s := 2 * math.Sqrt(1+a[0][0]-a[1][1]-a[2][2])
+		q = Quaternion{W: (a[2][1] - a[1][2]) / s, X: s / 4, Y: (a[0][1] + a[1][0]) / s, Z: (a[0][2] + a[2][0]) / s}
+	case a[1][1] > a[2][2]:
+		s := 2 * math.Sqrt(1+a[1][1]-a[0][0]-a[2][2])
+		q = Quaternion{W: (a[0][2] - a[2][0]) / s, X: (a[0][1] + a[1][0]) / s, Y: s / 4, Z: (a[1][2] + a[2][1]) / s}
+	default:
+		s := 2 * math.Sqrt(1+a[2][2]-a[0][0]-a[1][1])
+		q = Quaternion{W: (a[1][0] - a[0][1]) / s, X: (a[0][2] + a[2][0]) / s, Y: (a[1][2] + a[2][1]) / s, Z: s / 4}
+	}
+	return q.Normalize()
+}
+
+// Mul returns the rotation r followed by q
+func (q Quaternion) Mul(r Quaternion) Quaternion {
+	return Quaternion{
+		W: q.W*r.W - q.X*r.X - q.Y*r.Y - q.Z*r.Z,
+		X: q.W*r.X + q.X*r.W + q.Y*r.Z - q.Z*r.Y,
+		Y: q.W*r.Y - q.X*r.Z + q.Y*r.W + q.Z*r.X,
+		Z: q.W*r.Z + q.X*r.Y - q.Y*r.X + q.Z*r.W,
+	}
+}
+
+// Conjugate returns the opposite rotation
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+}
+
+// Normalize returns the quaternion scaled to unit length, the identity if it is zero
+func (q Quaternion) Normalize() Quaternion {
+	length := math.Sqrt(q.W*q.W + q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	if length == 0 {
+		return IdentityQuaternion()
+	}
+	return Quaternion{W: q.W / length, X: q.X / length, Y: q.Y / length, Z: q.Z / length}
+}
+
+// AxisAngle returns the axis and the angle in radians between 0 and pi of the rotation, the Z axis
+// for the identity
+func (q Quaternion) AxisAngle() (axis Vec3, angle float64) {
+	q = q.Normalize()
+	//The same rotation has two quaternions, take the one with the shortest angle
+	if q.W < 0 {
+		q = Quaternion{W: -q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+	}
+	sin := math.Sqrt(q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	if sin == 0 {
+		return Vec3{0, 0, 1}, 0
+	}
+	return Vec3{float32(q.X / sin), float32(q.Y / sin), float32(q.Z / sin)}, 2 * math.Atan2(sin, q.W)
+}
+
+// Euler returns the angles in radians around X, Y and Z of QuaternionFromEuler giving this rotation,
+// with y between -pi/2 and pi/2. When y is at either end only x + z or x - z is known, and x is 0.
+func (q Quaternion) Euler() (x, y, z float64) {
+	m := q.matrix()
+	sinY := math.Max(-1, math.Min(1, -m[2][0]))
+	y = math.Asin(sinY)
+	if math.Abs(sinY) < 1-1e-9 {
+		return math.Atan2(m[2][1], m[2][2]), y, math.Atan2(m[1][0], m[0][0])
+	}
+	return 0, y, math.Atan2(-m[0][1], m[1][1])
+}
+
+// Matrix returns the rotation matrix of the quaternion
+func (q Quaternion) Matrix() (m Mat3) {
+	a := q.matrix()
+	for i := range a {
+		for j := range a[i] {
+			m[i][j] = float32(a[i][j])
+		}
+	}
+	return m
+}
+
+// matrix returns the rotation matrix of the quaternion in float64
+func (q Quaternion) matrix() [3][3]float64 {
+	q = q.Normalize()
+	w, x, y, z := q.W, q.X, q.Y, q.Z
+	return [3][3]float64{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}
+}
+
+// Affine returns the rotation around the origin as a transformation
+func (q Quaternion) Affine() Affine {
+	return Affine{Linear: q.Matrix()}
+}
+
+// Rotate returns v rotated
+func (q Quaternion) Rotate(v Vec3) Vec3 {
+	return q.Matrix().MulVec(v)
+}
+
+// Slerp returns the rotation a fraction t of the way from q to r along the shortest arc
+func (q Quaternion) Slerp(r Quaternion, t float64) Quaternion {
+	q, r = q.Normalize(), r.Normalize()
+	cos := q.W*r.W + q.X*r.X + q.Y*r.Y + q.Z*r.Z
+	if cos < 0 {
+		r, cos = Quaternion{W: -r.W, X: -r.X, Y: -r.Y, Z: -r.Z}, -cos
+	}
+	from, to := 1-t, t
+	//Nearly the same rotation, interpolating linearly avoids dividing by a tiny sine
+	if cos < 1-1e-9 {
+		angle := math.Acos(cos)
+		from, to = math.Sin((1-t)*angle)/math.Sin(angle), math.Sin(t*angle)/math.Sin(angle)
+	}
+	return Quaternion{
+		W: from*q.W + to*r.W, X: from*q.X + to*r.X, Y: from*q.Y + to*r.Y, Z: from*q.Z + to*r.Z,
+	}.Normalize()
+}
+
+// RotateQuat rotates the model around the origin in place
+func (m *Model) RotateQuat(q Quaternion) {
+	Transform(m, q.Affine())
+}
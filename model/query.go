@@ -0,0 +1,39 @@
+package model
+
+// Slightly skewed directions so the parity rays rarely run along edges or faces of axis aligned models
+var containsRayDirections = [3]Vec3{
+	{0.5773, 0.5774, 0.5775},
+	{-0.6402, 0.3201, 0.6983},
+	{0.2113, -0.8452, -0.4907},
+}
+
+// ClosestPoint returns the point on the surface of m closest to p
+func ClosestPoint(m *Model, p Vec3) (hit NearestHit, ok bool) {
+	return NewBVH(m).Nearest(p)
+}
+
+// Contains checks if p is inside the watertight model m
+func Contains(m *Model, p Vec3) bool {
+	return NewBVH(m).Contains(p)
+}
+
+// ClosestPoint returns the point on the surface of the model closest to p
+func (b *BVH) ClosestPoint(p Vec3) (hit NearestHit, ok bool) {
+	return b.Nearest(p)
+}
+
+// Contains checks if p is inside the model using ray parity. The model is expected to be watertight,
+// a small number of holes is tolerated by voting over several rays.
+func (b *BVH) Contains(p Vec3) bool {
+	if !b.Bounds().Contains(p) {
+		return false
+	}
+	inside := 0
+	for _, direction := range containsRayDirections {
+		//An odd number of crossings means the point is inside
+		if len(b.RaycastAll(Ray{Origin: p, Direction: direction}))%2 == 1 {
+			inside++
+		}
+	}
+	return inside*2 > len(containsRayDirections)
+}
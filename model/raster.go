@@ -0,0 +1,258 @@
+package model
+
+import (
+	"io"
+	"math"
+	"sort"
+)
+
+// RenderOptions tunes how triangles are rasterized
+type RenderOptions struct {
+	//Skip the triangles facing away from the viewer
+	CullBackFaces bool
+}
+
+// DepthBuffer holds, for every cell of a projection, the depth of the closest surface (from 0 for the
+// far end of the model to 1 for the near end) and the index of the triangle it belongs to (-1 if empty)
+type DepthBuffer struct {
+	Depth     [][]float32
+	Triangles [][]int
+}
+
+// newDepthBuffer creates an empty buffer with the size of the projection matrix
+func (p projection) newDepthBuffer() DepthBuffer {
+	buffer := DepthBuffer{Depth: p.newMatrix()}
+	buffer.Triangles = make([][]int, len(buffer.Depth))
+	for i := range buffer.Triangles {
+		buffer.Triangles[i] = make([]int, len(buffer.Depth[i]))
+		for j := range buffer.Triangles[i] {
+			buffer.Triangles[i][j] = -1
+		}
+	}
+	return buffer
+}
+
+// set stores the surface in the cell if it is closer than what is already there
+func (b *DepthBuffer) set(row, col int, value float32, triangle int) {
+	if b.Triangles[row][col] < 0 || value > b.Depth[row][col] {
+		b.Depth[row][col] = value
+		b.Triangles[row][col] = triangle
+	}
+}
+
+// RasterizeModelTriangles projects the model in the viewport from the chosen perspective like
+// ProjectVertices, but fills every triangle instead of only marking its vertices
+func RasterizeModelTriangles(m *Model, viewport Viewport, perspective Perspective, opts RenderOptions) [][]float32 {
+	return RasterizeDepthBuffer(m, viewport, perspective, opts).Depth
+}
+
+// RasterizeDepthBuffer fills the triangles of the model into a depth buffer so only the surfaces
+// closest to the viewer remain visible
+func RasterizeDepthBuffer(m *Model, viewport Viewport, perspective Perspective, opts RenderOptions) DepthBuffer {
+	return newProjection(m, viewport, perspective).rasterize(m, opts)
+}
+
+// rasterize fills the triangles of a model in a depth buffer with this projection, which may be
+// fitted to more than that model
+func (p projection) rasterize(m *Model, opts RenderOptions) DepthBuffer {
+	buffer := p.newDepthBuffer()
+	for j := range m.Triangles {
+		if opts.CullBackFaces && !p.facesViewer(&m.Triangles[j]) {
+			continue
+		}
+		p.fillTriangle(&buffer, j, &m.Triangles[j])
+		//Mark the vertices too so triangles smaller than a cell don't vanish
+		for k := range m.Triangles[j].Vertices {
+			p.plotDepthVertex(&buffer, j, m.Triangles[j].Vertices[k])
+		}
+	}
+	return buffer
+}
+
+// rasterBand is the number of rows RenderDepth and RenderShaded rasterize at a time, a multiple of the
+// 4 rows of a braille character so every band can be drawn on its own
+const rasterBand = 64
+
+// rasterizeBands fills the triangles of a model like rasterize, but only band rows of the buffer at a
+// time, so the whole buffer never is in memory. emit is called with every band from the top, once all
+// the triangles crossing it are in, and the buffer it gets is reused for the next band. The cells are
+// the same as those of rasterize.
+func (p projection) rasterizeBands(m *Model, opts RenderOptions, band int, emit func(buffer DepthBuffer) error) error {
+	height, width := p.viewport.Height, p.viewport.Width
+	if height <= 0 {
+		return nil
+	}
+	//The triangles with cells starting in every band, and the last row they reach
+	starts := make([][]int, (height+band-1)/band)
+	screens := make([][3]screenPoint, len(m.Triangles))
+	lastRows := make([]int, len(m.Triangles))
+	for j := range m.Triangles {
+		t := &m.Triangles[j]
+		if opts.CullBackFaces && !p.facesViewer(t) {
+			continue
+		}
+		screens[j] = [3]screenPoint{p.toScreen(t.Vertices[0]), p.toScreen(t.Vertices[1]), p.toScreen(t.Vertices[2])}
+		first, last := height, -1
+		if a, b, c := screens[j][0], screens[j][1], screens[j][2]; edgeFunction(a, b, c.col, c.row) != 0 && width > 0 {
+			first, last = clampIndex(minOf3(a.row, b.row, c.row), height), clampIndex(maxOf3(a.row, b.row, c.row), height)
+		}
+		for k := range t.Vertices {
+			if row, col, value := p.cell(t.Vertices[k]); row >= 0 && col >= 0 && row < height && col < width && !math.IsNaN(float64(value)) {
+				first, last = min(first, row), max(last, row)
+			}
+		}
+		if first <= last {
+			starts[first/band] = append(starts[first/band], j)
+			lastRows[j] = last
+		}
+	}
+	rows := min(band, height)
+	buffer := DepthBuffer{Depth: make([][]float32, rows), Triangles: make([][]int, rows)}
+	for i := range buffer.Depth {
+		buffer.Depth[i], buffer.Triangles[i] = make([]float32, width), make([]int, width)
+	}
+	var active []int
+	for n := range starts {
+		top := n * band
+		view := DepthBuffer{Depth: buffer.Depth[:min(band, height-top)], Triangles: buffer.Triangles[:min(band, height-top)]}
+		for i := range view.Depth {
+			for j := range view.Depth[i] {
+				view.Depth[i][j], view.Triangles[i][j] = 0, -1
+			}
+		}
+		//Keep the triangles still crossing the band, filled in the order of the model like rasterize
+		kept := active[:0]
+		for _, j := range active {
+			if lastRows[j] >= top {
+				kept = append(kept, j)
+			}
+		}
+		active = append(kept, starts[n]...)
+		sort.Ints(active)
+		bottom := top + len(view.Depth) - 1
+		for _, j := range active {
+			a, b, c := screens[j][0], screens[j][1], screens[j][2]
+			rasterizeTriangleRows(top, bottom, height, width, a, b, c, func(row, col int, value float32) {
+				view.set(row-top, col, value, j)
+			})
+			for _, vertex := range m.Triangles[j].Vertices {
+				if row, col, value := p.cell(vertex); row >= top && row <= bottom && col >= 0 && col < width && !math.IsNaN(float64(value)) {
+					view.set(row-top, col, value, j)
+				}
+			}
+		}
+		if err := emit(view); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderDepth writes what write draws of the matrix of RasterizeModelTriangles, rasterizing and writing
+// it a band of rows at a time so very large renders never hold the whole matrix. write must draw every
+// band on its own, like the MatrixWriter functions of this package.
+func RenderDepth(w io.Writer, m *Model, viewport Viewport, perspective Perspective, opts RenderOptions, write MatrixWriter) error {
+	return newProjection(m, viewport, perspective).rasterizeBands(m, opts, rasterBand, func(buffer DepthBuffer) error {
+		return write(w, buffer.Depth)
+	})
+}
+
+// RenderShaded writes what write draws of the matrix of RasterizeShaded a band of rows at a time, like
+// RenderDepth
+func RenderShaded(w io.Writer, m *Model, viewport Viewport, perspective Perspective, opts RenderOptions, light Lighting, write MatrixWriter) error {
+	return newProjection(m, viewport, perspective).rasterizeBands(m, opts, rasterBand, func(buffer DepthBuffer) error {
+		return write(w, ShadeDepthBuffer(m, buffer, perspective, light))
+	})
+}
+
+// facesViewer checks if the front of the triangle (counter-clockwise side) looks at the viewer,
+// falling back to the stored normal for triangles without area
+func (p projection) facesViewer(t *Triangle) bool {
+	//The viewer looks from the high end of the value axis
+	return p.rotation.MulVec(triangleNormal(t))[2] >= 0
+}
+
+// plotDepthVertex marks a single vertex in the depth buffer, using the same cell as plotVertex
+func (p projection) plotDepthVertex(buffer *DepthBuffer, triangle int, vertex [3]float32) {
+	row, col, value := p.cell(vertex)
+	if row < 0 || col < 0 || row >= len(buffer.Depth) || col >= len(buffer.Depth[row]) || math.IsNaN(float64(value)) {
+		return
+	}
+	buffer.set(row, col, value, triangle)
+}
+
+// screenPoint is a vertex in matrix space: column, row and the value for the cell
+type screenPoint struct {
+	col, row, value float32
+}
+
+// toScreen maps a vertex to continuous matrix coordinates
+func (p projection) toScreen(vertex [3]float32) screenPoint {
+	adjustedX, adjustedY, value := p.adjust(vertex)
+	return screenPoint{col: adjustedY, row: (p.height - adjustedX) / p.viewport.aspect(), value: value}
+}
+
+// fillTriangle stores every cell whose center is covered by the triangle with the interpolated depth
+func (p projection) fillTriangle(buffer *DepthBuffer, index int, t *Triangle) {
+	a, b, c := p.toScreen(t.Vertices[0]), p.toScreen(t.Vertices[1]), p.toScreen(t.Vertices[2])
+	rasterizeTriangle(buffer.Depth, a, b, c, func(row, col int, value float32) {
+		buffer.set(row, col, value, index)
+	})
+}
+
+// rasterizeTriangle calls plot for every cell of the matrix whose center is inside the triangle abc,
+// with the value interpolated from the vertices
+func rasterizeTriangle(matrix [][]float32, a, b, c screenPoint, plot func(row, col int, value float32)) {
+	if len(matrix) == 0 {
+		return
+	}
+	rasterizeTriangleRows(0, len(matrix)-1, len(matrix), len(matrix[0]), a, b, c, plot)
+}
+
+// rasterizeTriangleRows is rasterizeTriangle for a matrix of height rows and width columns, only
+// plotting the cells of the rows from first to last
+func rasterizeTriangleRows(first, last, height, width int, a, b, c screenPoint, plot func(row, col int, value float32)) {
+	//Twice the signed area, used to normalize the barycentric coordinates
+	area := edgeFunction(a, b, c.col, c.row)
+	if area == 0 || math.IsNaN(float64(area)) {
+		return
+	}
+	//Only check the cells in the bounding box of the triangle
+	minRow, maxRow := max(clampIndex(minOf3(a.row, b.row, c.row), height), first), min(clampIndex(maxOf3(a.row, b.row, c.row), height), last)
+	minCol, maxCol := clampIndex(minOf3(a.col, b.col, c.col), width), clampIndex(maxOf3(a.col, b.col, c.col), width)
+	for row := minRow; row <= maxRow; row++ {
+		for col := minCol; col <= maxCol; col++ {
+			x, y := float32(col)+0.5, float32(row)+0.5
+			//Barycentric weights of the cell center
+			wa, wb, wc := edgeFunction(b, c, x, y)/area, edgeFunction(c, a, x, y)/area, edgeFunction(a, b, x, y)/area
+			if wa < 0 || wb < 0 || wc < 0 {
+				continue
+			}
+			plot(row, col, wa*a.value+wb*b.value+wc*c.value)
+		}
+	}
+}
+
+// edgeFunction returns twice the signed area of the triangle formed by the edge ab and the point (x, y)
+func edgeFunction(a, b screenPoint, x, y float32) float32 {
+	return (b.col-a.col)*(y-a.row) - (b.row-a.row)*(x-a.col)
+}
+
+// clampIndex converts a coordinate to an index inside [0, length)
+func clampIndex(v float32, length int) int {
+	if v < 0 {
+		return 0
+	}
+	if int(v) >= length {
+		return length - 1
+	}
+	return int(v)
+}
+
+func minOf3(a, b, c float32) float32 {
+	return float32(math.Min(float64(a), math.Min(float64(b), float64(c))))
+}
+
+func maxOf3(a, b, c float32) float32 {
+	return float32(math.Max(float64(a), math.Max(float64(b), float64(c))))
+}
@@ -0,0 +1,455 @@
+package model
+
+import "errors"
+
+// RemeshOptions tunes RemeshWith
+type RemeshOptions struct {
+	//Length the edges are brought towards, the average length of the edges of the model if 0
+	TargetEdge float32
+	//Rounds of splits, collapses, flips and smoothing, 5 if 0
+	Iterations int
+	//Edges where the surface bends more than this angle in degrees stay in place (see FeatureEdges),
+	//45 if 0. The borders of holes always stay in place.
+	FeatureAngle float64
+}
+
+// Remesh retriangulates the surface of the model towards edges of the given length, like RemeshWith
+// with the default iterations and feature angle
+func Remesh(m *Model, targetEdge float64) error {
+	return RemeshWith(m, RemeshOptions{TargetEdge: float32(targetEdge)})
+}
+
+// RemeshWith retriangulates the surface of the model in place so its edges get close to the target
+// length and its triangles close to equilateral. Every round splits the edges longer than 4/3 of the
+// target, collapses the ones shorter than 4/5 of it, flips edges so most vertices have six neighbours
+// and slides the vertices along the surface towards the middle of their neighbours, projecting them
+// back onto the original surface. Feature edges and the borders of holes are kept, their vertices
+// never move. Every new triangle keeps the attribute bytes and the Attributes of the facet it comes
+// from.
+func RemeshWith(m *Model, opts RemeshOptions) error {
+	if opts.TargetEdge < 0 || opts.Iterations < 0 || opts.FeatureAngle < 0 {
+		return errors.New("Target edge, iterations and feature angle can't be negative")
+	}
+	if len(m.Triangles) == 0 {
+		return nil
+	}
+	iterations, featureAngle := opts.Iterations, opts.FeatureAngle
+	if iterations == 0 {
+		iterations = 5
+	}
+	if featureAngle == 0 {
+		featureAngle = 45
+	}
+	mesh := newEditMesh(NewTopology(m), featureAngle)
+	target := opts.TargetEdge
+	if target == 0 {
+		target = mesh.meanEdge()
+	}
+	if target == 0 {
+		return errors.New("Model has no area to remesh")
+	}
+	//Keep the original surface to project the vertices back onto it
+	original := m.Clone()
+	bvh := NewBVH(original)
+	for i := 0; i < iterations; i++ {
+		mesh.splitLongEdges(4 * target / 3)
+		mesh.collapseShortEdges(4*target/5, 4*target/3)
+		mesh.flipEdges(4 * target / 3)
+		mesh.smooth(bvh)
+	}
+	m.Invalidate()
+	m.Triangles, m.Attributes = mesh.triangles(original.Triangles), mesh.attributes(original)
+	m.NumTriangles = uint32(len(m.Triangles))
+	return nil
+}
+
+// editMesh is an indexed mesh changed in place by the remesher. Removed faces have -1 as first vertex
+// until compact drops them.
+type editMesh struct {
+	vertices []Vec3
+	faces    [][3]int
+	//Edges that must stay, keyed by their vertex indices in increasing order, and their vertices
+	features map[[2]int]bool
+	locked   []bool
+	//Triangle of the model every face comes from, to keep its attribute bytes
+	sources []int
+}
+
+// newEditMesh copies the vertices and the faces with three distinct vertices of the topology, keeping
+// the edges bending more than angle degrees
+func newEditMesh(t *Topology, angle float64) *editMesh {
+	mesh := &editMesh{
+		vertices: append([]Vec3(nil), t.Vertices...),
+		features: make(map[[2]int]bool),
+		locked:   make([]bool, len(t.Vertices)),
+	}
+	for i, face := range t.Faces {
+		if face[0] != face[1] && face[1] != face[2] && face[2] != face[0] {
+			mesh.faces = append(mesh.faces, face)
+			mesh.sources = append(mesh.sources, i)
+		}
+	}
+	for _, edge := range t.FeatureEdges(angle) {
+		mesh.features[edge] = true
+		mesh.locked[edge[0]], mesh.locked[edge[1]] = true, true
+	}
+	return mesh
+}
+
+// edgeKey returns the key of the edge between two vertices, whatever their order
+func edgeKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// edges returns the faces using every edge
+func (mesh *editMesh) edges() map[[2]int][]int {
+	edges := make(map[[2]int][]int)
+	for f, face := range mesh.faces {
+		if face[0] < 0 {
+			continue
+		}
+		for j := range face {
+			key := edgeKey(face[j], face[(j+1)%3])
+			edges[key] = append(edges[key], f)
+		}
+	}
+	return edges
+}
+
+// length returns the length of the edge between two vertices
+func (mesh *editMesh) length(a, b int) float32 {
+	return mesh.vertices[a].Sub(mesh.vertices[b]).Length()
+}
+
+// faceNormal returns the normal of a face scaled by twice its area, with vertex b moved to p
+func (mesh *editMesh) faceNormal(face [3]int, b int, p Vec3) Vec3 {
+	var corners [3]Vec3
+	for j, v := range face {
+		corners[j] = mesh.vertices[v]
+		if v == b {
+			corners[j] = p
+		}
+	}
+	return corners[1].Sub(corners[0]).Cross(corners[2].Sub(corners[0]))
+}
+
+// meanEdge returns the average length of the edges of the faces
+func (mesh *editMesh) meanEdge() float32 {
+	var total float64
+	var count int
+	for _, face := range mesh.faces {
+		for j := range face {
+			total += float64(mesh.length(face[j], face[(j+1)%3]))
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return float32(total / float64(count))
+}
+
+// splitLongEdges splits the edges longer than maxLength at their middle, along with the faces using
+// them, until there are none left
+func (mesh *editMesh) splitLongEdges(maxLength float32) {
+	for split := true; split; {
+		split = false
+		edges := mesh.edges()
+		//Faces already split in this pass, their edges in the map are stale
+		touched := make([]bool, len(mesh.faces))
+		//Only split edges that are the longest of every face using them, splitting the others could
+		//make thinner and thinner faces forever
+		longest := make([][2]int, len(mesh.faces))
+		for f, face := range mesh.faces {
+			//Break ties the same way in every face
+			a, b := face[0], face[1]
+			for j := 1; j < 3; j++ {
+				next := face[(j+1)%3]
+				length, longestLength := mesh.length(face[j], next), mesh.length(a, b)
+				if length > longestLength || length == longestLength && lessEdge(edgeKey(face[j], next), edgeKey(a, b)) {
+					a, b = face[j], next
+				}
+			}
+			longest[f] = [2]int{a, b}
+		}
+		for f := range longest {
+			a, b := longest[f][0], longest[f][1]
+			key := edgeKey(a, b)
+			if mesh.length(a, b) > maxLength && !anyTouched(touched, edges[key]) && allLongest(longest, edges[key], key) {
+				middle := len(mesh.vertices)
+				mesh.vertices = append(mesh.vertices, mesh.vertices[a].Add(mesh.vertices[b]).Scale(0.5))
+				mesh.locked = append(mesh.locked, mesh.features[key])
+				if mesh.features[key] {
+					delete(mesh.features, key)
+					mesh.features[edgeKey(a, middle)], mesh.features[edgeKey(middle, b)] = true, true
+				}
+				for _, g := range edges[key] {
+					//Replace each end of the edge by the middle in turn, keeping the winding
+					face, second := mesh.faces[g], mesh.faces[g]
+					for k := range face {
+						if face[k] == a {
+							face[k] = middle
+						}
+						if second[k] == b {
+							second[k] = middle
+						}
+					}
+					mesh.faces[g] = face
+					mesh.faces = append(mesh.faces, second)
+					mesh.sources = append(mesh.sources, mesh.sources[g])
+					touched[g] = true
+					touched = append(touched, true)
+				}
+				split = true
+			}
+		}
+	}
+}
+
+// allLongest checks if the edge is the longest one of all the faces
+func allLongest(longest [][2]int, faces []int, key [2]int) bool {
+	for _, f := range faces {
+		if edgeKey(longest[f][0], longest[f][1]) != key {
+			return false
+		}
+	}
+	return true
+}
+
+// anyTouched checks if any of the faces is marked
+func anyTouched(touched []bool, faces []int) bool {
+	for _, f := range faces {
+		if touched[f] {
+			return true
+		}
+	}
+	return false
+}
+
+// collapseShortEdges merges the ends of the edges shorter than minLength, unless it would make edges
+// longer than maxLength, fold faces over, pinch the surface or move a locked vertex
+func (mesh *editMesh) collapseShortEdges(minLength, maxLength float32) {
+	edges := mesh.edges()
+	around := make([][]int, len(mesh.vertices))
+	neighbors := make([]map[int]bool, len(mesh.vertices))
+	for f, face := range mesh.faces {
+		for j, v := range face {
+			around[v] = append(around[v], f)
+			if neighbors[v] == nil {
+				neighbors[v] = make(map[int]bool)
+			}
+			neighbors[v][face[(j+1)%3]], neighbors[v][face[(j+2)%3]] = true, true
+		}
+	}
+	//Vertices whose faces changed in this pass, the adjacency around them is stale
+	touched := make([]bool, len(mesh.vertices))
+	for f := range mesh.faces {
+		for j := 0; j < 3 && mesh.faces[f][0] >= 0; j++ {
+			a, b := mesh.faces[f][j], mesh.faces[f][(j+1)%3]
+			key := edgeKey(a, b)
+			if touched[a] || touched[b] || mesh.features[key] || len(edges[key]) != 2 || mesh.length(a, b) >= minLength {
+				continue
+			}
+			//Move b onto a, keeping the locked vertex if there is one
+			if mesh.locked[a] && mesh.locked[b] {
+				continue
+			}
+			if mesh.locked[b] {
+				a, b = b, a
+			}
+			//Only the two vertices facing the edge may be neighbours of both ends, or the surface
+			//would pinch
+			common := 0
+			for v := range neighbors[b] {
+				if neighbors[a][v] {
+					common++
+				}
+			}
+			if common != 2 || !mesh.canCollapse(around[b], a, b, maxLength) {
+				continue
+			}
+			for _, g := range around[b] {
+				face := &mesh.faces[g]
+				if face[0] == a || face[1] == a || face[2] == a {
+					face[0] = -1
+					continue
+				}
+				for k := range face {
+					if face[k] == b {
+						face[k] = a
+					}
+				}
+			}
+			for _, v := range []int{a, b} {
+				touched[v] = true
+				for neighbor := range neighbors[v] {
+					touched[neighbor] = true
+				}
+			}
+		}
+	}
+	mesh.compact()
+}
+
+// canCollapse checks that moving b onto a keeps the faces around b (but the ones using a) with their
+// orientation and without edges longer than maxLength
+func (mesh *editMesh) canCollapse(faces []int, a, b int, maxLength float32) bool {
+	target := mesh.vertices[a]
+	for _, g := range faces {
+		face := mesh.faces[g]
+		if face[0] == a || face[1] == a || face[2] == a {
+			continue
+		}
+		for _, v := range face {
+			if v != b && mesh.vertices[v].Sub(target).Length() > maxLength {
+				return false
+			}
+		}
+		if mesh.faceNormal(face, b, target).Dot(mesh.faceNormal(face, b, mesh.vertices[b])) <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// compact drops the removed faces
+func (mesh *editMesh) compact() {
+	kept, sources := mesh.faces[:0], mesh.sources[:0]
+	for i, face := range mesh.faces {
+		if face[0] >= 0 {
+			kept, sources = append(kept, face), append(sources, mesh.sources[i])
+		}
+	}
+	mesh.faces, mesh.sources = kept, sources
+}
+
+// flipEdges flips the edges between two faces when it brings the number of neighbours of the four
+// vertices involved closer to 6 (4 on the borders of holes), without folding the faces over or making
+// edges longer than maxLength
+func (mesh *editMesh) flipEdges(maxLength float32) {
+	edges := mesh.edges()
+	valence := make([]int, len(mesh.vertices))
+	border := make([]bool, len(mesh.vertices))
+	for key, faces := range edges {
+		valence[key[0]]++
+		valence[key[1]]++
+		if len(faces) == 1 {
+			border[key[0]], border[key[1]] = true, true
+		}
+	}
+	deviation := func(v, change int) int {
+		target := 6
+		if border[v] {
+			target = 4
+		}
+		d := valence[v] + change - target
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	//Faces already flipped in this pass, their edges in the map are stale
+	touched := make([]bool, len(mesh.faces))
+	for f := range mesh.faces {
+		for j := 0; j < 3 && !touched[f]; j++ {
+			face := mesh.faces[f]
+			a, b, c := face[j], face[(j+1)%3], face[(j+2)%3]
+			key := edgeKey(a, b)
+			if mesh.features[key] || len(edges[key]) != 2 || anyTouched(touched, edges[key]) {
+				continue
+			}
+			g := edges[key][0] + edges[key][1] - f
+			//The other face goes along the edge the other way: b, a, d
+			d := -1
+			for k, v := range mesh.faces[g] {
+				if v == b && mesh.faces[g][(k+1)%3] == a {
+					d = mesh.faces[g][(k+2)%3]
+				}
+			}
+			if d < 0 || d == c || edges[edgeKey(c, d)] != nil || mesh.length(c, d) > maxLength {
+				continue
+			}
+			before := deviation(a, 0) + deviation(b, 0) + deviation(c, 0) + deviation(d, 0)
+			after := deviation(a, -1) + deviation(b, -1) + deviation(c, 1) + deviation(d, 1)
+			if after >= before {
+				continue
+			}
+			flipped, other := [3]int{a, d, c}, [3]int{d, b, c}
+			normal := mesh.faceNormal(face, -1, Vec3{}).Add(mesh.faceNormal(mesh.faces[g], -1, Vec3{}))
+			if mesh.faceNormal(flipped, -1, Vec3{}).Dot(normal) <= 0 || mesh.faceNormal(other, -1, Vec3{}).Dot(normal) <= 0 {
+				continue
+			}
+			mesh.faces[f], mesh.faces[g] = flipped, other
+			delete(edges, key)
+			edges[edgeKey(c, d)] = []int{f, g}
+			valence[a]--
+			valence[b]--
+			valence[c]++
+			valence[d]++
+			touched[f], touched[g] = true, true
+		}
+	}
+}
+
+// smooth moves every unlocked vertex along the surface towards the middle of its neighbours, and
+// then onto the closest point of the original surface
+func (mesh *editMesh) smooth(original *BVH) {
+	sums := make([]Vec3, len(mesh.vertices))
+	counts := make([]int, len(mesh.vertices))
+	normals := make([]Vec3, len(mesh.vertices))
+	for _, face := range mesh.faces {
+		normal := mesh.faceNormal(face, -1, Vec3{})
+		for j, v := range face {
+			sums[v] = sums[v].Add(mesh.vertices[face[(j+1)%3]]).Add(mesh.vertices[face[(j+2)%3]])
+			counts[v] += 2
+			normals[v] = normals[v].Add(normal)
+		}
+	}
+	moved := make([]Vec3, len(mesh.vertices))
+	for v, p := range mesh.vertices {
+		moved[v] = p
+		if mesh.locked[v] || counts[v] == 0 {
+			continue
+		}
+		//Only move along the tangent plane, the projection takes care of the rest
+		normal := normals[v].Normalize()
+		step := sums[v].Scale(1 / float32(counts[v])).Sub(p)
+		step = step.Sub(normal.Scale(normal.Dot(step)))
+		moved[v] = p.Add(step)
+		if hit, ok := original.Nearest(moved[v]); ok {
+			moved[v] = hit.Point
+		}
+	}
+	mesh.vertices = moved
+}
+
+// attributes returns a copy of the attributes of the triangles of m the faces come from, nil when m
+// has none
+func (mesh *editMesh) attributes(m *Model) []Attributes {
+	if m.Attributes == nil {
+		return nil
+	}
+	attributes := make([]Attributes, len(mesh.faces))
+	for i := range mesh.faces {
+		attributes[i] = m.TriangleAttributes(mesh.sources[i]).clone()
+	}
+	return attributes
+}
+
+// triangles returns the faces as triangles with their normals and the attribute bytes of the
+// triangles they come from
+func (mesh *editMesh) triangles(original []Triangle) []Triangle {
+	triangles := make([]Triangle, len(mesh.faces))
+	for i, face := range mesh.faces {
+		t := &triangles[i]
+		for j, v := range face {
+			t.Vertices[j] = mesh.vertices[v]
+		}
+		t.Normal = triangleNormal(t)
+		t.AttrByteCount = original[mesh.sources[i]].AttrByteCount
+	}
+	return triangles
+}
@@ -0,0 +1,472 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// RepairOptions chooses the passes run by Repair
+type RepairOptions struct {
+	//Merge the vertices closer than the tolerance (0 skips the pass, exact matches are always shared)
+	WeldTolerance float32
+	//Drop the facets without area
+	RemoveDegenerate bool
+	//Drop the facets using the same three vertices as an earlier one
+	RemoveDuplicates bool
+	//Flip facets so neighbours agree, and turn every closed shell outwards
+	Orient bool
+	//Close the holes with new facets
+	FillHoles bool
+	//Replace the stored normals that don't match the winding of their facet
+	FixNormals bool
+	//Tolerances telling the degenerate facets and the normals not matching their winding,
+	//DefaultTolerances for the fields left at 0
+	Tolerances Tolerances
+}
+
+// DefaultRepairOptions runs every pass, welding the vertices closer than the default tolerance
+var DefaultRepairOptions = RepairOptions{
+	WeldTolerance:    DefaultTolerances.Weld,
+	RemoveDegenerate: true,
+	RemoveDuplicates: true,
+	Orient:           true,
+	FillHoles:        true,
+	FixNormals:       true,
+}
+
+// RepairReport counts the changes made by Repair, with the record of every pass that ran
+type RepairReport struct {
+	VerticesWelded    int          `json:"verticesWelded"`
+	DegenerateRemoved int          `json:"degenerateRemoved"`
+	DuplicatesRemoved int          `json:"duplicatesRemoved"`
+	FacetsFlipped     int          `json:"facetsFlipped"`
+	HolesFilled       int          `json:"holesFilled"`
+	FacetsAdded       int          `json:"facetsAdded"`
+	NormalsFixed      int          `json:"normalsFixed"`
+	Passes            []RepairPass `json:"passes"`
+}
+
+// RepairPass records what a pass of Repair changed
+type RepairPass struct {
+	//Name of the pass: weld, degenerate, duplicates, orient, holes or normals
+	Name string `json:"name"`
+	//Positions merged into another one by the weld
+	VerticesWelded int `json:"verticesWelded,omitempty"`
+	//Triangles dropped, as indices in the model before the pass
+	Removed []int `json:"removed,omitempty"`
+	//Triangles changed in place (moved vertices, flipped or given a new normal), as indices in the
+	//model after the pass
+	Changed []int `json:"changed,omitempty"`
+	//Facets added to fill every hole, the new triangles being at the end of the model
+	HoleFacets []int `json:"holeFacets,omitempty"`
+}
+
+// String prints the report in the same style as the Model
+func (r RepairReport) String() string {
+	return fmt.Sprintf("Vertices welded: %v\nDegenerate facets removed: %v\nDuplicate facets removed: %v\nFacets flipped: %v\nHoles filled: %v\nFacets added: %v\nNormals fixed: %v\n",
+		r.VerticesWelded, r.DegenerateRemoved, r.DuplicatesRemoved, r.FacetsFlipped, r.HolesFilled, r.FacetsAdded, r.NormalsFixed)
+}
+
+// Repair fixes the common defects of a model in place, running the chosen passes in order: weld,
+// degenerate and duplicate removal, orientation, hole filling and normals
+func Repair(m *Model, opts RepairOptions) (report RepairReport) {
+	report.Passes = []RepairPass{}
+	if opts.WeldTolerance > 0 {
+		pass := weldVertices(m, opts.WeldTolerance)
+		report.VerticesWelded = pass.VerticesWelded
+		report.Passes = append(report.Passes, pass)
+	}
+	if opts.RemoveDegenerate {
+		pass := removeDegenerateFacets(m, opts.Tolerances)
+		report.DegenerateRemoved = len(pass.Removed)
+		report.Passes = append(report.Passes, pass)
+	}
+	if opts.RemoveDuplicates {
+		pass := removeDuplicateFacets(m)
+		report.DuplicatesRemoved = len(pass.Removed)
+		report.Passes = append(report.Passes, pass)
+	}
+	if opts.Orient {
+		pass := orientFacets(m)
+		report.FacetsFlipped = len(pass.Changed)
+		report.Passes = append(report.Passes, pass)
+	}
+	if opts.FillHoles {
+		pass := fillHoles(m)
+		report.HolesFilled = len(pass.HoleFacets)
+		for _, added := range pass.HoleFacets {
+			report.FacetsAdded += added
+		}
+		report.Passes = append(report.Passes, pass)
+	}
+	if opts.FixNormals {
+		pass := fixNormals(m, opts.Tolerances)
+		report.NormalsFixed = len(pass.Changed)
+		report.Passes = append(report.Passes, pass)
+	}
+	return report
+}
+
+// WeldVertices snaps the vertices closer than tolerance (along each axis) to the first of them, and
+// returns how many distinct positions were merged into another one
+func WeldVertices(m *Model, tolerance float32) (welded int) {
+	return weldVertices(m, tolerance).VerticesWelded
+}
+
+// weldVertices is WeldVertices recording the triangles with a vertex moved
+func weldVertices(m *Model, tolerance float32) (pass RepairPass) {
+	pass.Name = "weld"
+	m.Invalidate()
+	cellOf := func(v Vec3) (cell [3]int64) {
+		for i := range v {
+			cell[i] = int64(math.Floor(float64(v[i] / tolerance)))
+		}
+		return cell
+	}
+	//Vertices are looked up in their cell and the neighbouring ones so close pairs across a cell side merge too
+	cells := make(map[[3]int64][]Vec3)
+	snapped := make(map[Vec3]Vec3)
+	for i := range m.Triangles {
+		moved := false
+		for j := range m.Triangles[i].Vertices {
+			v := Vec3(m.Triangles[i].Vertices[j])
+			target, found := snapped[v]
+			if !found {
+				target, found = findWeldTarget(cells, cellOf(v), v, tolerance)
+				if found {
+					pass.VerticesWelded++
+				} else {
+					target = v
+					cells[cellOf(v)] = append(cells[cellOf(v)], v)
+				}
+				snapped[v] = target
+			}
+			if target != v {
+				moved = true
+			}
+			m.Triangles[i].Vertices[j] = target
+		}
+		if moved {
+			pass.Changed = append(pass.Changed, i)
+		}
+	}
+	return pass
+}
+
+// findWeldTarget looks for a kept vertex within tolerance of v around its cell
+func findWeldTarget(cells map[[3]int64][]Vec3, cell [3]int64, v Vec3, tolerance float32) (Vec3, bool) {
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for dz := int64(-1); dz <= 1; dz++ {
+				for _, kept := range cells[[3]int64{cell[0] + dx, cell[1] + dy, cell[2] + dz}] {
+					d := kept.Sub(v)
+					if abs32(d[0]) <= tolerance && abs32(d[1]) <= tolerance && abs32(d[2]) <= tolerance {
+						return kept, true
+					}
+				}
+			}
+		}
+	}
+	return v, false
+}
+
+func abs32(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// RemoveDegenerateFacets drops the facets without area, or degenerate by DefaultTolerances, and returns
+// how many were removed
+func RemoveDegenerateFacets(m *Model) (removed int) {
+	return len(removeDegenerateFacets(m, DefaultTolerances).Removed)
+}
+
+// removeDegenerateFacets is RemoveDegenerateFacets recording the triangles dropped
+func removeDegenerateFacets(m *Model, tolerances Tolerances) RepairPass {
+	return RepairPass{Name: "degenerate", Removed: m.recordRemoved(func(t *Triangle) bool { return !tolerances.IsDegenerate(t) })}
+}
+
+// RemoveDuplicateFacets drops the facets using the same three vertices as an earlier one, whatever
+// their order, and returns how many were removed
+func RemoveDuplicateFacets(m *Model) (removed int) {
+	return len(removeDuplicateFacets(m).Removed)
+}
+
+// removeDuplicateFacets is RemoveDuplicateFacets recording the triangles dropped
+func removeDuplicateFacets(m *Model) RepairPass {
+	seen := make(map[[3]Vec3]bool)
+	return RepairPass{Name: "duplicates", Removed: m.recordRemoved(func(t *Triangle) bool {
+		key := sortedVertices(t)
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	})}
+}
+
+// recordRemoved drops the triangles like keepTriangles, returning the indices of the ones dropped
+func (m *Model) recordRemoved(keep func(t *Triangle) bool) (removed []int) {
+	m.keepTriangles(func(i int, t *Triangle) bool {
+		if keep(t) {
+			return true
+		}
+		removed = append(removed, i)
+		return false
+	})
+	return removed
+}
+
+// sortedVertices returns the vertices of the triangle in lexicographic order
+func sortedVertices(t *Triangle) [3]Vec3 {
+	v := [3]Vec3{t.Vertices[0], t.Vertices[1], t.Vertices[2]}
+	less := func(a, b Vec3) bool {
+		for i := range a {
+			if a[i] != b[i] {
+				return a[i] < b[i]
+			}
+		}
+		return false
+	}
+	for i := 1; i < 3; i++ {
+		for j := i; j > 0 && less(v[j], v[j-1]); j-- {
+			v[j], v[j-1] = v[j-1], v[j]
+		}
+	}
+	return v
+}
+
+// flipFacet reverses the winding and the normal of a triangle
+func flipFacet(t *Triangle) {
+	t.Vertices[1], t.Vertices[2] = t.Vertices[2], t.Vertices[1]
+	t.Normal = Vec3(t.Normal).Scale(-1)
+}
+
+// OrientFacets flips facets so every pair of neighbours goes along their shared edge in opposite
+// directions, then turns the shells with a negative volume inside out (for shells with holes the
+// volume is approximate, but still tells the outside of nearly closed ones). It returns the number
+// of facets flipped. Edges shared by more than two facets are ignored.
+func OrientFacets(m *Model) (flipped int) {
+	return len(orientFacets(m).Changed)
+}
+
+// orientFacets is OrientFacets recording the triangles flipped
+func orientFacets(m *Model) (pass RepairPass) {
+	pass.Name = "orient"
+	m.Invalidate()
+	topology := NewTopology(m)
+	//Direction in which a face goes along an edge: true when from the lower to the higher index
+	forward := func(face int, edge [2]int) bool {
+		f := topology.Faces[face]
+		for j := range f {
+			if f[j] == edge[0] && f[(j+1)%3] == edge[1] {
+				return true
+			}
+		}
+		return false
+	}
+	faceEdges := func(face int) (edges [][2]int) {
+		f := topology.Faces[face]
+		for j := range f {
+			a, b := f[j], f[(j+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			if a != b {
+				edges = append(edges, [2]int{a, b})
+			}
+		}
+		return edges
+	}
+	isFlipped := make([]bool, len(m.Triangles))
+	visited := make([]bool, len(m.Triangles))
+	for _, shell := range topology.Shells() {
+		//Breadth first from the first face of the shell, which is kept as it is
+		queue := []int{shell[0]}
+		visited[shell[0]] = true
+		for len(queue) > 0 {
+			face := queue[0]
+			queue = queue[1:]
+			for _, edge := range faceEdges(face) {
+				use := topology.edges[edge]
+				if len(use.faces) != 2 {
+					continue
+				}
+				neighbour := use.faces[0]
+				if neighbour == face {
+					neighbour = use.faces[1]
+				}
+				if visited[neighbour] {
+					continue
+				}
+				visited[neighbour] = true
+				//Both go the same way once the flips so far are applied, so the neighbour must turn
+				if (forward(face, edge) != isFlipped[face]) == forward(neighbour, edge) {
+					isFlipped[neighbour] = true
+				}
+				queue = append(queue, neighbour)
+			}
+		}
+		//Shells must enclose a positive volume
+		var volume float64
+		for _, face := range shell {
+			t := m.Triangles[face]
+			if isFlipped[face] {
+				flipFacet(&t)
+			}
+			a, b, c := Vec3(t.Vertices[0]), Vec3(t.Vertices[1]), Vec3(t.Vertices[2])
+			volume += float64(a.Dot(b.Cross(c)))
+		}
+		if volume < 0 {
+			for _, face := range shell {
+				isFlipped[face] = !isFlipped[face]
+			}
+		}
+	}
+	for i := range m.Triangles {
+		if isFlipped[i] {
+			flipFacet(&m.Triangles[i])
+			pass.Changed = append(pass.Changed, i)
+		}
+	}
+	return pass
+}
+
+// FillHoles closes every loop of boundary edges with new facets, wound to match their neighbours,
+// and returns the number of holes filled and of facets added
+func FillHoles(m *Model) (holes, added int) {
+	pass := fillHoles(m)
+	for _, facets := range pass.HoleFacets {
+		added += facets
+	}
+	return len(pass.HoleFacets), added
+}
+
+// fillHoles is FillHoles recording the facets added for every hole
+func fillHoles(m *Model) (pass RepairPass) {
+	pass.Name = "holes"
+	topology := NewTopology(m)
+	//A boundary edge going from a to b in its face is crossed from b to a by the hole
+	next := make(map[int][]int)
+	for edge, use := range topology.edges {
+		if len(use.faces) != 1 {
+			continue
+		}
+		if use.forward == 1 {
+			next[edge[1]] = append(next[edge[1]], edge[0])
+		} else {
+			next[edge[0]] = append(next[edge[0]], edge[1])
+		}
+	}
+	fill := func(loop []int) {
+		points := make([]Vec3, len(loop))
+		for i, v := range loop {
+			points[i] = topology.Vertices[v]
+		}
+		facets := triangulateLoop(points)
+		for _, t := range facets {
+			a, b, c := points[t[0]], points[t[1]], points[t[2]]
+			normal := b.Sub(a).Cross(c.Sub(a)).Normalize()
+			m.Append(Triangle{Normal: normal, Vertices: [3][3]float32{a, b, c}})
+		}
+		pass.HoleFacets = append(pass.HoleFacets, len(facets))
+	}
+	for len(next) > 0 {
+		//Pick the lowest vertex so the result doesn't depend on the map order
+		start := -1
+		for v := range next {
+			if start < 0 || v < start {
+				start = v
+			}
+		}
+		//Walk the boundary, closing a hole every time the path comes back to one of its vertices
+		//(holes touching by a corner share it). Paths that end nowhere are not holes.
+		path, position := []int{start}, map[int]int{start: 0}
+		for {
+			v := path[len(path)-1]
+			outgoing := next[v]
+			if len(outgoing) == 0 {
+				break
+			}
+			w := outgoing[len(outgoing)-1]
+			if len(outgoing) == 1 {
+				delete(next, v)
+			} else {
+				next[v] = outgoing[:len(outgoing)-1]
+			}
+			k, found := position[w]
+			if !found {
+				position[w] = len(path)
+				path = append(path, w)
+				continue
+			}
+			if len(path)-k >= 3 {
+				fill(append([]int(nil), path[k:]...))
+			}
+			for _, u := range path[k+1:] {
+				delete(position, u)
+			}
+			path = path[:k+1]
+		}
+	}
+	return pass
+}
+
+// triangulateLoop splits a closed loop of points in triangles keeping its winding, projecting it on
+// its average plane to clip ears and falling back to a fan when that fails
+func triangulateLoop(points []Vec3) [][3]int {
+	//Newell's method gives the normal of the loop following its winding
+	var normal Vec3
+	for i := range points {
+		a, b := points[i], points[(i+1)%len(points)]
+		normal = normal.Add(Vec3{(a[1] - b[1]) * (a[2] + b[2]), (a[2] - b[2]) * (a[0] + b[0]), (a[0] - b[0]) * (a[1] + b[1])})
+	}
+	normal = normal.Normalize()
+	//Any two directions perpendicular to the normal, right handed so the winding is kept
+	helper := Vec3{1, 0, 0}
+	if abs32(normal[0]) > 0.9 {
+		helper = Vec3{0, 1, 0}
+	}
+	u := helper.Cross(normal).Normalize()
+	v := normal.Cross(u)
+	polygon := make([]Vec2, len(points))
+	for i, p := range points {
+		polygon[i] = Vec2{p.Dot(u), p.Dot(v)}
+	}
+	if triangles, err := Triangulate(polygon); err == nil && len(triangles) == len(points)-2 {
+		return triangles
+	}
+	fan := make([][3]int, 0, len(points)-2)
+	for i := 1; i+1 < len(points); i++ {
+		fan = append(fan, [3]int{0, i, i + 1})
+	}
+	return fan
+}
+
+// FixNormals recomputes the stored normals that don't match the winding of their facet, within
+// DefaultTolerances, and returns how many were changed
+func FixNormals(m *Model) (fixed int) {
+	return len(fixNormals(m, DefaultTolerances).Changed)
+}
+
+// fixNormals is FixNormals recording the triangles given a new normal
+func fixNormals(m *Model, tolerances Tolerances) (pass RepairPass) {
+	pass.Name = "normals"
+	fixed := make([]bool, len(m.Triangles))
+	parallelSum(len(m.Triangles), func(i int) float64 {
+		t := &m.Triangles[i]
+		if tolerances.NormalMatches(t) {
+			return 0
+		}
+		t.Normal, fixed[i] = t.ComputedNormal(), true
+		return 1
+	})
+	for i := range fixed {
+		if fixed[i] {
+			pass.Changed = append(pass.Changed, i)
+		}
+	}
+	return pass
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Instance places a model in a Scene. Several instances can share the same model.
+type Instance struct {
+	Name      string
+	Model     *Model
+	Transform Affine
+	//Color the instance is painted with when rendered, shaded in gray if transparent
+	Color color.RGBA
+}
+
+// Scene is an assembly of models, each placed with its own transformation, kept apart until merged
+type Scene struct {
+	Instances []Instance
+}
+
+// Add places the model in the scene with the transformation and color
+func (s *Scene) Add(name string, m *Model, transform Affine, c color.RGBA) {
+	s.Instances = append(s.Instances, Instance{Name: name, Model: m, Transform: transform, Color: c})
+}
+
+// Find returns the first instance with the given name, or nil if there is none
+func (s *Scene) Find(name string) *Instance {
+	for i := range s.Instances {
+		if s.Instances[i].Name == name {
+			return &s.Instances[i]
+		}
+	}
+	return nil
+}
+
+// Bounds returns the bounding box of all the instances where they are placed
+func (s *Scene) Bounds() Box {
+	bounds := EmptyBox()
+	for i := range s.Instances {
+		bounds = bounds.Union(s.Instances[i].Bounds())
+	}
+	return bounds
+}
+
+// Bounds returns the bounding box of the model where the instance places it
+func (instance *Instance) Bounds() Box {
+	bounds := EmptyBox()
+	for i := range instance.Model.Triangles {
+		for _, v := range instance.Model.Triangles[i].Vertices {
+			bounds = bounds.Extend(instance.Transform.Apply(v))
+		}
+	}
+	return bounds
+}
+
+// Merge returns a single model with a copy of the triangles of every instance where it is placed, in
+// the order of the instances. The models of the scene are not modified.
+func (s *Scene) Merge() *Model {
+	merged, _ := s.merge()
+	return merged
+}
+
+// merge is Merge also returning the index of the first triangle of every instance in the merged model
+func (s *Scene) merge() (merged *Model, starts []int) {
+	merged = &Model{Header: "Scene"}
+	for _, instance := range s.Instances {
+		starts = append(starts, len(merged.Triangles))
+		placed := instance.Model.Clone()
+		Transform(placed, instance.Transform)
+		if placed.Attributes != nil {
+			merged.Attributes = append(growAttributes(merged.Attributes, len(merged.Triangles)), growAttributes(placed.Attributes, len(placed.Triangles))...)
+		}
+		merged.Triangles = append(merged.Triangles, placed.Triangles...)
+	}
+	if merged.Attributes != nil {
+		merged.Attributes = growAttributes(merged.Attributes, len(merged.Triangles))
+	}
+	merged.NumTriangles = uint32(len(merged.Triangles))
+	return merged, starts
+}
+
+// RenderScene shades all the instances of the scene together like RasterizeShaded, each painted with
+// its color. Empty cells are transparent.
+func RenderScene(s *Scene, viewport Viewport, perspective Perspective, opts RenderOptions, light Lighting) [][]color.RGBA {
+	merged, starts := s.merge()
+	colors := make([]color.RGBA, len(merged.Triangles))
+	for instance, start := range starts {
+		end := len(colors)
+		if instance+1 < len(starts) {
+			end = starts[instance+1]
+		}
+		for t := start; t < end; t++ {
+			colors[t] = s.Instances[instance].Color
+		}
+	}
+	return RenderColored(merged, viewport, perspective, opts, light, colors)
+}
+
+// DrawColorsImage paints the colors as an image, one pixel per cell. Transparent cells are painted
+// with the background.
+func DrawColorsImage(colors [][]color.RGBA, background color.Color) *image.RGBA {
+	width := 0
+	if len(colors) > 0 {
+		width = len(colors[0])
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, len(colors)))
+	for i := range colors {
+		for j := range colors[i] {
+			if colors[i][j].A == 0 {
+				img.Set(j, i, background)
+				continue
+			}
+			img.Set(j, i, colors[i][j])
+		}
+	}
+	return img
+}
+
+// DrawColors draws the colors in the terminal like DrawMatrixColorMap, leaving transparent cells
+// empty. ColorNone falls back to DrawMatrix with the brightness of the colors.
+func DrawColors(colors [][]color.RGBA, mode ColorMode) string {
+	var builder strings.Builder
+	RenderColors(&builder, colors, mode)
+	return builder.String()
+}
+
+// RenderColors writes what DrawColors draws, a row at a time
+func RenderColors(w io.Writer, colors [][]color.RGBA, mode ColorMode) error {
+	if mode == ColorNone {
+		rows := rowWriter{w: w}
+		for i := range colors {
+			for _, c := range colors[i] {
+				value := float32(0)
+				if c.A != 0 {
+					//Keep dark colors distinguishable from empty cells
+					value = max(0.01, (0.299*float32(c.R)+0.587*float32(c.G)+0.114*float32(c.B))/255)
+				}
+				rows.row = utf8.AppendRune(rows.row, BlockPalette.Rune(value))
+			}
+			rows.end()
+		}
+		return rows.err
+	}
+	return renderColorRows(w, colors, func(i, j int) string {
+		if colors[i][j].A == 0 {
+			return colorReset
+		}
+		return rgbCode(colors[i][j], mode)
+	})
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Largest number of cells along the longest side of a model in a distance field
+const maxFieldCells = 200
+
+// fieldCell returns the size of the cells of a distance field covering the bounds, as requested but not
+// smaller than the longest side over maxFieldCells
+func fieldCell(bounds Box, cell float32) float32 {
+	size := bounds.Size()
+	longest := float32(math.Max(float64(size[0]), math.Max(float64(size[1]), float64(size[2]))))
+	if cell < longest/maxFieldCells {
+		cell = longest / maxFieldCells
+	}
+	return cell
+}
+
+// distanceGrid holds a scalar field sampled at the corners of a regular grid of cubic cells,
+// negative inside the solid it describes
+type distanceGrid struct {
+	origin Vec3
+	cell   float32
+	//Number of samples along each axis
+	size   [3]int
+	values []float32
+}
+
+// newDistanceGrid covers the bounds plus padding cells on every side
+func newDistanceGrid(bounds Box, cell float32, padding int) *distanceGrid {
+	g := &distanceGrid{cell: cell}
+	extent := bounds.Size()
+	g.origin = bounds.Min.Sub(Vec3{1, 1, 1}.Scale(float32(padding) * cell))
+	for k := range g.size {
+		g.size[k] = int(math.Ceil(float64(extent[k]/cell))) + 2*padding + 1
+	}
+	g.values = make([]float32, g.size[0]*g.size[1]*g.size[2])
+	return g
+}
+
+// index returns the position of the sample i, j, k in values
+func (g *distanceGrid) index(i, j, k int) int {
+	return (k*g.size[1]+j)*g.size[0] + i
+}
+
+// point returns the position of the sample i, j, k
+func (g *distanceGrid) point(i, j, k int) Vec3 {
+	return g.origin.Add(Vec3{float32(i), float32(j), float32(k)}.Scale(g.cell))
+}
+
+// fill sets every sample to the value of the field at its position, one slice along Z at a time
+// in parallel
+func (g *distanceGrid) fill(field func(p Vec3) float32) {
+	g.fillSlices(func(k int) {
+		for j := 0; j < g.size[1]; j++ {
+			for i := 0; i < g.size[0]; i++ {
+				g.values[g.index(i, j, k)] = field(g.point(i, j, k))
+			}
+		}
+	})
+}
+
+// fillSlices calls fillSlice for every slice along Z, from as many goroutines as there are CPUs
+func (g *distanceGrid) fillSlices(fillSlice func(k int)) {
+	inParallel(g.size[2], fillSlice)
+}
+
+// inParallel calls do with every number from 0 to n-1, from as many goroutines as there are CPUs
+func inParallel(n int, do func(i int)) {
+	numbers := make(chan int)
+	var wait sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for i := range numbers {
+				do(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		numbers <- i
+	}
+	close(numbers)
+	wait.Wait()
+}
+
+// signedDistance fills the grid with the distance to the surface of the watertight model, negative
+// inside. Inside and outside are told by the parity of the crossings of one ray per row of samples.
+func (b *BVH) signedDistance(g *distanceGrid) {
+	//Rows are cast slightly off the samples so they don't run along the faces of axis aligned models
+	skew := Vec3{0, 0.000137, 0.000291}.Scale(g.cell)
+	g.fillSlices(func(k int) {
+		for j := 0; j < g.size[1]; j++ {
+			start := g.point(0, j, k).Add(skew)
+			hits := b.RaycastAll(Ray{Origin: start, Direction: Vec3{1, 0, 0}})
+			crossed := 0
+			for i := 0; i < g.size[0]; i++ {
+				along := float32(i) * g.cell
+				for crossed < len(hits) && hits[crossed].Distance < along {
+					crossed++
+				}
+				distance := float32(math.MaxFloat32)
+				if hit, ok := b.Nearest(g.point(i, j, k)); ok {
+					distance = hit.Distance
+				}
+				if crossed%2 == 1 {
+					distance = -distance
+				}
+				g.values[g.index(i, j, k)] = distance
+			}
+		}
+	})
+}
+
+// Corners of a cell by their offset along each axis, numbered x + 2y + 4z
+var cellCorners = [8][3]int{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}, {0, 0, 1}, {1, 0, 1}, {0, 1, 1}, {1, 1, 1}}
+
+// Tetrahedra splitting a cell around its diagonal from corner 0 to 7. Every cell is split the same
+// way, so the faces shared by neighboring cells are split along the same diagonal.
+var cellTetrahedra = [6][4]int{{0, 1, 3, 7}, {0, 1, 5, 7}, {0, 2, 3, 7}, {0, 2, 6, 7}, {0, 4, 5, 7}, {0, 4, 6, 7}}
+
+// isosurface returns the triangles of the surface where the field crosses level (marching
+// tetrahedra), facing the higher values. The surface is closed wherever it stays inside the grid,
+// and the vertices shared by neighboring triangles have exactly the same coordinates.
+func (g *distanceGrid) isosurface(level float32) (triangles []Triangle) {
+	for k := 0; k+1 < g.size[2]; k++ {
+		for j := 0; j+1 < g.size[1]; j++ {
+			for i := 0; i+1 < g.size[0]; i++ {
+				var indices [8]int
+				var points [8]Vec3
+				var values [8]float32
+				below := 0
+				for c, offset := range cellCorners {
+					indices[c] = g.index(i+offset[0], j+offset[1], k+offset[2])
+					points[c] = g.point(i+offset[0], j+offset[1], k+offset[2])
+					values[c] = g.values[indices[c]]
+					if values[c] < level {
+						below++
+					}
+				}
+				if below == 0 || below == 8 {
+					continue
+				}
+				for _, tetrahedron := range cellTetrahedra {
+					triangles = polygonizeTetrahedron(triangles, tetrahedron, indices, points, values, level)
+				}
+			}
+		}
+	}
+	return triangles
+}
+
+// polygonizeTetrahedron appends the piece of the isosurface inside a tetrahedron of a cell
+func polygonizeTetrahedron(triangles []Triangle, tetrahedron [4]int, indices [8]int, points [8]Vec3, values [8]float32, level float32) []Triangle {
+	var inside, outside []int
+	for _, c := range tetrahedron {
+		if values[c] < level {
+			inside = append(inside, c)
+		} else {
+			outside = append(outside, c)
+		}
+	}
+	//Where the surface crosses an edge, interpolated from the lower sample index so both cells
+	//sharing the edge find the same point
+	crossing := func(a, b int) [3]float32 {
+		if indices[a] > indices[b] {
+			a, b = b, a
+		}
+		t := (level - values[a]) / (values[b] - values[a])
+		return points[a].Add(points[b].Sub(points[a]).Scale(t))
+	}
+	var polygon [][3]float32
+	switch len(inside) {
+	case 1:
+		polygon = [][3]float32{crossing(inside[0], outside[0]), crossing(inside[0], outside[1]), crossing(inside[0], outside[2])}
+	case 2:
+		polygon = [][3]float32{crossing(inside[0], outside[0]), crossing(inside[0], outside[1]), crossing(inside[1], outside[1]), crossing(inside[1], outside[0])}
+	case 3:
+		polygon = [][3]float32{crossing(inside[0], outside[0]), crossing(inside[1], outside[0]), crossing(inside[2], outside[0])}
+	default:
+		return triangles
+	}
+	//Face from the samples below the level towards the ones above
+	var from, to Vec3
+	for _, c := range inside {
+		from = from.Add(points[c].Scale(1 / float32(len(inside))))
+	}
+	for _, c := range outside {
+		to = to.Add(points[c].Scale(1 / float32(len(outside))))
+	}
+	direction := to.Sub(from)
+	for v := 1; v+1 < len(polygon); v++ {
+		t := Triangle{Vertices: [3][3]float32{polygon[0], polygon[v], polygon[v+1]}}
+		normal := Vec3(t.Vertices[1]).Sub(t.Vertices[0]).Cross(Vec3(t.Vertices[2]).Sub(t.Vertices[0]))
+		if normal == (Vec3{}) {
+			continue
+		}
+		if normal.Dot(direction) < 0 {
+			t.Vertices[1], t.Vertices[2] = t.Vertices[2], t.Vertices[1]
+			normal = normal.Scale(-1)
+		}
+		t.Normal = normal.Normalize()
+		triangles = append(triangles, t)
+	}
+	return triangles
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+// SectionMetric is the size of a cross section of a model
+type SectionMetric struct {
+	Position float64
+	//Area of the solid in the section, holes excluded, and total length of its contours
+	Area      float32
+	Perimeter float32
+}
+
+// SectionMetrics measures the cross sections of the model by the planes perpendicular to an axis (0
+// for X, 1 for Y and 2 for Z) at the given positions, in the same order. It adds up what every facet
+// contributes without chaining the contours like SliceAt, so it is much faster for many sections and
+// the area is right for consistently oriented models even when the contours don't close.
+func SectionMetrics(m *Model, axis int, positions []float64) []SectionMetric {
+	sections := make([]SectionMetric, len(positions))
+	order := make([]int, len(positions))
+	for i := range positions {
+		sections[i].Position = positions[i]
+		order[i] = i
+	}
+	if axis < 0 || axis > 2 {
+		return sections
+	}
+	//Visit the sections from the lowest so every facet only looks at the ones it crosses
+	sort.Slice(order, func(i, j int) bool { return positions[order[i]] < positions[order[j]] })
+	sorted := make([]float64, len(order))
+	for i, index := range order {
+		sorted[i] = positions[index]
+	}
+	for i := range m.Triangles {
+		//Rotate the axes so the chosen one becomes Z, keeping them right handed
+		var t Triangle
+		for j, v := range m.Triangles[i].Vertices {
+			t.Vertices[j] = [3]float32{v[(axis+1)%3], v[(axis+2)%3], v[axis]}
+		}
+		low := math.Min(float64(t.Vertices[0][2]), math.Min(float64(t.Vertices[1][2]), float64(t.Vertices[2][2])))
+		high := math.Max(float64(t.Vertices[0][2]), math.Max(float64(t.Vertices[1][2]), float64(t.Vertices[2][2])))
+		for k := sort.SearchFloat64s(sorted, low); k < len(sorted) && sorted[k] <= high; k++ {
+			p, q, ok := slicePlane(&t, float32(sorted[k]))
+			if !ok || p == q {
+				continue
+			}
+			section := &sections[order[k]]
+			//The segments go counter-clockwise around the solid, each one adding the area of the
+			//triangle it makes with the origin
+			section.Area += p.Cross(q) / 2
+			d := q.Sub(p)
+			section.Perimeter += float32(math.Sqrt(float64(d[0]*d[0] + d[1]*d[1])))
+		}
+	}
+	return sections
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+// PlanarRegion is a connected group of nearly coplanar faces of a Topology
+type PlanarRegion struct {
+	Faces []int
+	Area  float32
+	//Plane fitted to the region: through the centroid of its faces, along their normals weighted by
+	//their area
+	Plane Plane
+}
+
+// PlanarRegions groups the faces in connected regions growing from a face to its neighbours while
+// their normal stays within angle degrees of the normal of the face the region started from. Faces
+// without area are left out. The regions come from the biggest to the smallest.
+func (t *Topology) PlanarRegions(angle float64) (regions []PlanarRegion) {
+	threshold := float32(math.Cos(radians(angle)))
+	normals := make([]Vec3, len(t.Faces))
+	areas := make([]float32, len(t.Faces))
+	for i, face := range t.Faces {
+		a, b, c := t.Vertices[face[0]], t.Vertices[face[1]], t.Vertices[face[2]]
+		normal := b.Sub(a).Cross(c.Sub(a))
+		normals[i], areas[i] = normal.Normalize(), normal.Length()/2
+	}
+	assigned := make([]bool, len(t.Faces))
+	for seed := range t.Faces {
+		if assigned[seed] || areas[seed] == 0 {
+			continue
+		}
+		assigned[seed] = true
+		region := PlanarRegion{}
+		var normal, centroid Vec3
+		for queue := []int{seed}; len(queue) > 0; queue = queue[1:] {
+			face := queue[0]
+			region.Faces = append(region.Faces, face)
+			region.Area += areas[face]
+			normal = normal.Add(normals[face].Scale(areas[face]))
+			a, b, c := t.Vertices[t.Faces[face][0]], t.Vertices[t.Faces[face][1]], t.Vertices[t.Faces[face][2]]
+			centroid = centroid.Add(a.Add(b).Add(c).Scale(areas[face] / 3))
+			for _, neighbor := range t.Neighbors(face) {
+				if !assigned[neighbor] && areas[neighbor] > 0 && normals[neighbor].Dot(normals[seed]) >= threshold {
+					assigned[neighbor] = true
+					queue = append(queue, neighbor)
+				}
+			}
+		}
+		sort.Ints(region.Faces)
+		region.Plane = Plane{Point: centroid.Scale(1 / region.Area), Normal: normal.Normalize()}
+		regions = append(regions, region)
+	}
+	sort.SliceStable(regions, func(i, j int) bool { return regions[i].Area > regions[j].Area })
+	return regions
+}
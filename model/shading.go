@@ -0,0 +1,74 @@
+package model
+
+// Lighting describes a directional light for shaded renders
+type Lighting struct {
+	//Direction the light comes from, in image coordinates: right, up and towards the viewer
+	Direction Vec3
+	//Light reaching every surface whatever its orientation, between 0 and 1
+	Ambient float32
+}
+
+// DefaultLighting lights the model from the upper left, behind the viewer
+var DefaultLighting = Lighting{Direction: Vec3{-0.5, 0.5, 1}, Ambient: 0.15}
+
+// RasterizeShaded projects the model like RasterizeModelTriangles, but each cell holds the diffuse
+// (Lambertian) lighting of its surface instead of its depth, so the render shows the form of the model
+func RasterizeShaded(m *Model, viewport Viewport, perspective Perspective, opts RenderOptions, light Lighting) [][]float32 {
+	return ShadeDepthBuffer(m, RasterizeDepthBuffer(m, viewport, perspective, opts), perspective, light)
+}
+
+// ShadeDepthBuffer computes the lighting of every visible cell of a depth buffer. Empty cells are 0
+// and lit ones are always above 0 so they remain visible when drawn.
+func ShadeDepthBuffer(m *Model, buffer DepthBuffer, perspective Perspective, light Lighting) [][]float32 {
+	rotation := perspective.ViewRotation()
+	//The view rotation rows are up, right and towards the viewer, reorder the light to match
+	direction := Vec3{light.Direction[1], light.Direction[0], light.Direction[2]}.Normalize()
+	//Compute the intensity of each triangle only once
+	intensities := make(map[int]float32)
+	shaded := make([][]float32, len(buffer.Triangles))
+	for i := range buffer.Triangles {
+		shaded[i] = make([]float32, len(buffer.Triangles[i]))
+		for j, t := range buffer.Triangles[i] {
+			if t < 0 {
+				continue
+			}
+			intensity, found := intensities[t]
+			if !found {
+				intensity = lambert(rotation.MulVec(triangleNormal(&m.Triangles[t])), direction, light.Ambient)
+				intensities[t] = intensity
+			}
+			shaded[i][j] = intensity
+		}
+	}
+	return shaded
+}
+
+// lambert returns the diffuse intensity of a surface with normal n (both in view coordinates)
+func lambert(n, direction Vec3, ambient float32) float32 {
+	//Light both sides, a back face is only visible when there is nothing in front of it
+	if n[2] < 0 {
+		n = n.Scale(-1)
+	}
+	diffuse := n.Dot(direction)
+	if diffuse < 0 {
+		diffuse = 0
+	}
+	intensity := ambient + (1-ambient)*diffuse
+	//Keep lit cells distinguishable from empty ones
+	if intensity < 0.01 {
+		intensity = 0.01
+	}
+	if intensity > 1 {
+		intensity = 1
+	}
+	return intensity
+}
+
+// triangleNormal returns the unit normal from the winding of the triangle, or the stored one if it has no area
+func triangleNormal(t *Triangle) Vec3 {
+	normal := Vec3(t.Vertices[1]).Sub(t.Vertices[0]).Cross(Vec3(t.Vertices[2]).Sub(t.Vertices[0]))
+	if normal == (Vec3{}) {
+		return Vec3(t.Normal).Normalize()
+	}
+	return normal.Normalize()
+}
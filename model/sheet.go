@@ -0,0 +1,115 @@
+package model
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// IsometricCamera looks from the front right and above with the three axes equally foreshortened
+var IsometricCamera = Camera{Yaw: 45, Pitch: 35.264}
+
+// DimetricCamera looks from the front right and above with the vertical axis at half the foreshortening
+var DimetricCamera = Camera{Yaw: 45, Pitch: 20.705}
+
+// Projector turns a model into a matrix from a perspective, like ProjectModelVertices
+type Projector func(m *Model, viewport Viewport, perspective Perspective) [][]float32
+
+// Drawer turns a matrix into text, like DrawMatrix
+type Drawer func(matrix [][]float32) string
+
+// MatrixWriter writes a matrix as text a row at a time, like RenderMatrix
+type MatrixWriter func(w io.Writer, matrix [][]float32) error
+
+// Drawer returns the Drawer building the same text in memory
+func (write MatrixWriter) Drawer() Drawer {
+	return func(matrix [][]float32) string {
+		var builder strings.Builder
+		write(&builder, matrix)
+		return builder.String()
+	}
+}
+
+// SheetView is one panel of a sheet
+type SheetView struct {
+	Label       string
+	Perspective Perspective
+}
+
+// FourViews is the layout for an engineering style sheet: top and isometric views above, front and side below
+var FourViews = [][]SheetView{
+	{{Label: "Top", Perspective: ProjectFromTop}, {Label: "Isometric", Perspective: IsometricCamera}},
+	{{Label: "Front", Perspective: ProjectFromFront}, {Label: "Side", Perspective: ProjectFromSide}},
+}
+
+// DrawSheet draws several views of the model in a grid, each one in its own viewport and labeled
+func DrawSheet(m *Model, viewport Viewport, layout [][]SheetView, project Projector, draw Drawer) string {
+	var rows []string
+	for _, row := range layout {
+		panels := make([]string, len(row))
+		for i, view := range row {
+			panels[i] = view.Label + "\n" + draw(project(m, viewport, view.Perspective))
+		}
+		rows = append(rows, JoinHorizontal("  ", panels...))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// DrawFourViewSheet draws the top, isometric, front and side views of the model in a single output
+func DrawFourViewSheet(m *Model, viewport Viewport, project Projector, draw Drawer) string {
+	return DrawSheet(m, viewport, FourViews, project, draw)
+}
+
+// JoinHorizontal puts blocks of text side by side, padding each one to its widest line
+func JoinHorizontal(separator string, blocks ...string) string {
+	lines := make([][]string, len(blocks))
+	widths := make([]int, len(blocks))
+	height := 0
+	for i, block := range blocks {
+		lines[i] = strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+		for _, line := range lines[i] {
+			if w := visibleWidth(line); w > widths[i] {
+				widths[i] = w
+			}
+		}
+		if len(lines[i]) > height {
+			height = len(lines[i])
+		}
+	}
+	var builder strings.Builder
+	for row := 0; row < height; row++ {
+		for i := range blocks {
+			line := ""
+			if row < len(lines[i]) {
+				line = lines[i][row]
+			}
+			builder.WriteString(line)
+			//Don't pad after the last block
+			if i < len(blocks)-1 {
+				builder.WriteString(strings.Repeat(" ", widths[i]-visibleWidth(line)))
+				builder.WriteString(separator)
+			}
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// visibleWidth counts the characters of a line, skipping ANSI color sequences
+func visibleWidth(line string) int {
+	width := 0
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			//Skip up to the final letter of the sequence
+			for i < len(line) && !(line[i] >= 'A' && line[i] <= 'Z' || line[i] >= 'a' && line[i] <= 'z') {
+				i++
+			}
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+		width++
+	}
+	return width
+}
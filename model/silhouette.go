@@ -0,0 +1,140 @@
+package model
+
+// Silhouette is the shadow of a model projected from a perspective
+type Silhouette struct {
+	//Closed outlines in model units of the view plane (horizontal, vertical), counter-clockwise for
+	//the outer boundaries and clockwise for the holes. The last point is not repeated.
+	Outlines [][]Vec2
+	//Area covered by the projection
+	Area float32
+}
+
+// gridEdge is a side of a cell between two corners of the grid (column, row)
+type gridEdge struct {
+	from, to [2]int
+}
+
+// ProjectSilhouette outlines the area covered by the model seen from the chosen perspective. The
+// projection is rasterized on a grid of resolution x resolution cells, so the outlines follow the
+// cell sides and get closer to the real shape as the resolution grows.
+func ProjectSilhouette(m *Model, resolution int, perspective Perspective) Silhouette {
+	s, _ := projectSilhouette(m, resolution, perspective)
+	return s
+}
+
+// projectSilhouette outlines the model like ProjectSilhouette, also returning the side of the cells
+func projectSilhouette(m *Model, resolution int, perspective Perspective) (s Silhouette, cell float32) {
+	if len(m.Triangles) == 0 || resolution < 1 {
+		return s, 0
+	}
+	viewport := ImageViewport(resolution, resolution)
+	p := newProjection(m, viewport, perspective)
+	//Only fill the triangles, the vertices alone would add loose cells at the far borders
+	buffer := p.newDepthBuffer()
+	for j := range m.Triangles {
+		p.fillTriangle(&buffer, j, &m.Triangles[j])
+	}
+	covered := func(row, col int) bool {
+		return row >= 0 && col >= 0 && row < len(buffer.Triangles) && col < len(buffer.Triangles[row]) && buffer.Triangles[row][col] >= 0
+	}
+	loops, cells := gridLoops(len(buffer.Triangles), viewport.Width, covered)
+	s.Area = float32(cells) * p.scale * p.scale
+	for _, loop := range loops {
+		s.Outlines = append(s.Outlines, p.outlineToView(loop))
+	}
+	return s, p.scale
+}
+
+// gridLoops chains the sides between the covered and the empty cells of a grid into closed loops of
+// corners (column, row), dropping the corners in the middle of straight runs. With the rows going
+// down, the loops around covered cells are counter-clockwise and those around holes clockwise. It
+// also returns the number of covered cells.
+func gridLoops(rows, columns int, covered func(row, col int) bool) (loops [][][2]int, cells int) {
+	//Collect the sides between covered and empty cells, keeping the covered cell on the left
+	//(looking at the grid with the vertical axis up)
+	var edges []gridEdge
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			if !covered(row, col) {
+				continue
+			}
+			cells++
+			if !covered(row+1, col) {
+				edges = append(edges, gridEdge{[2]int{col, row + 1}, [2]int{col + 1, row + 1}})
+			}
+			if !covered(row, col+1) {
+				edges = append(edges, gridEdge{[2]int{col + 1, row + 1}, [2]int{col + 1, row}})
+			}
+			if !covered(row-1, col) {
+				edges = append(edges, gridEdge{[2]int{col + 1, row}, [2]int{col, row}})
+			}
+			if !covered(row, col-1) {
+				edges = append(edges, gridEdge{[2]int{col, row}, [2]int{col, row + 1}})
+			}
+		}
+	}
+	//Chain the sides into closed loops
+	outgoing := make(map[[2]int][]int)
+	for i := range edges {
+		outgoing[edges[i].from] = append(outgoing[edges[i].from], i)
+	}
+	used := make([]bool, len(edges))
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+		var loop [][2]int
+		for current := start; !used[current]; {
+			used[current] = true
+			loop = append(loop, edges[current].from)
+			next := -1
+			for _, candidate := range outgoing[edges[current].to] {
+				if !used[candidate] {
+					next = candidate
+					//Where two cells only touch by a corner, turn left so each one keeps its own loop
+					if turnsLeft(edges[current], edges[candidate]) {
+						break
+					}
+				}
+			}
+			if next < 0 {
+				break
+			}
+			current = next
+		}
+		loops = append(loops, simplifyGridLoop(loop))
+	}
+	return loops, cells
+}
+
+// turnsLeft checks if the edge b turns left after a, with the rows of the grid going down
+func turnsLeft(a, b gridEdge) bool {
+	ax, ay := a.to[0]-a.from[0], a.from[1]-a.to[1]
+	bx, by := b.to[0]-b.from[0], b.from[1]-b.to[1]
+	return ax*by-ay*bx > 0
+}
+
+// simplifyGridLoop drops the corners in the middle of straight runs
+func simplifyGridLoop(loop [][2]int) [][2]int {
+	simplified := make([][2]int, 0, len(loop))
+	for i := range loop {
+		previous, next := loop[(i+len(loop)-1)%len(loop)], loop[(i+1)%len(loop)]
+		if (next[0]-loop[i][0])*(loop[i][1]-previous[1])-(next[1]-loop[i][1])*(loop[i][0]-previous[0]) == 0 {
+			continue
+		}
+		simplified = append(simplified, loop[i])
+	}
+	return simplified
+}
+
+// outlineToView converts grid corners back to model units of the view plane
+func (p projection) outlineToView(loop [][2]int) []Vec2 {
+	outline := make([]Vec2, len(loop))
+	for i, corner := range loop {
+		outline[i] = Vec2{
+			p.mins[1] + float32(corner[0])*p.scale,
+			p.mins[0] + (p.height-float32(corner[1])*p.viewport.aspect())*p.scale,
+		}
+	}
+	return outline
+}
@@ -0,0 +1,191 @@
+package model
+
+import "math"
+
+// Layer is the cross section of a model by a horizontal plane
+type Layer struct {
+	Z float32
+	//Closed contours in the XY plane, counter-clockwise around the solid and clockwise around the
+	//holes (for consistently oriented models). The last point is not repeated.
+	Contours [][]Vec2
+	//Area of the solid inside the contours, holes excluded
+	Area float32
+	//Total length of the contours
+	Perimeter float32
+}
+
+// SliceAt cuts the model with the horizontal plane at height z. Vertices lying exactly on the plane
+// count as above it, so faces touching the plane from below don't leave loose segments.
+func SliceAt(m *Model, z float32) (layer Layer) {
+	layer.Z = z
+	//Segments chained by their start point
+	segments := make(map[Vec2][]Vec2)
+	for i := range m.Triangles {
+		p, q, ok := slicePlane(&m.Triangles[i], z)
+		if !ok || p == q {
+			continue
+		}
+		segments[p] = append(segments[p], q)
+	}
+	for len(segments) > 0 {
+		//Start from the lowest point so the result doesn't depend on the map order
+		var start Vec2
+		first := true
+		for p := range segments {
+			if first || p[0] < start[0] || (p[0] == start[0] && p[1] < start[1]) {
+				start, first = p, false
+			}
+		}
+		contour := []Vec2{start}
+		for current := start; ; {
+			ends := segments[current]
+			if len(ends) == 0 {
+				//Open chain, the model has a hole here
+				break
+			}
+			next := ends[len(ends)-1]
+			if len(ends) == 1 {
+				delete(segments, current)
+			} else {
+				segments[current] = ends[:len(ends)-1]
+			}
+			if next == start {
+				break
+			}
+			contour = append(contour, next)
+			current = next
+		}
+		if len(contour) < 3 {
+			continue
+		}
+		layer.Contours = append(layer.Contours, contour)
+		layer.Area += PolygonArea(contour)
+		for i := range contour {
+			d := contour[(i+1)%len(contour)].Sub(contour[i])
+			layer.Perimeter += float32(math.Sqrt(float64(d[0]*d[0] + d[1]*d[1])))
+		}
+	}
+	return layer
+}
+
+// Lines returns the contours of the layer as closed polylines at its height, to draw them over the
+// model with HighlightLines
+func (layer Layer) Lines() [][]Vec3 {
+	lines := make([][]Vec3, len(layer.Contours))
+	for i, contour := range layer.Contours {
+		lines[i] = make([]Vec3, len(contour)+1)
+		for j := range lines[i] {
+			p := contour[j%len(contour)]
+			lines[i][j] = Vec3{p[0], p[1], layer.Z}
+		}
+	}
+	return lines
+}
+
+// Slice cuts the model in layers of the given height from the bottom up, each one sliced at its middle
+func Slice(m *Model, layerHeight float32) (layers []Layer) {
+	if len(m.Triangles) == 0 || layerHeight <= 0 {
+		return layers
+	}
+	mins, maxs := getMinsMaxs(m)
+	count := int(math.Ceil(float64((maxs[2] - mins[2]) / layerHeight)))
+	for i := 0; i < count; i++ {
+		layers = append(layers, SliceAt(m, mins[2]+(float32(i)+0.5)*layerHeight))
+	}
+	return layers
+}
+
+// slicePlane returns the segment where the triangle crosses the plane at height z, going
+// counter-clockwise around the solid when looking from above
+func slicePlane(t *Triangle, z float32) (p, q Vec2, ok bool) {
+	var points []Vec2
+	for j := range t.Vertices {
+		a, b := Vec3(t.Vertices[j]), Vec3(t.Vertices[(j+1)%3])
+		if (a[2] >= z) == (b[2] >= z) {
+			continue
+		}
+		//Always interpolate from the same end so both faces of an edge get exactly the same point
+		if a[0] > b[0] || (a[0] == b[0] && (a[1] > b[1] || (a[1] == b[1] && a[2] > b[2]))) {
+			a, b = b, a
+		}
+		//Vertices on the plane are used as they are, interpolating could round them differently
+		switch z {
+		case a[2]:
+			points = append(points, Vec2{a[0], a[1]})
+		case b[2]:
+			points = append(points, Vec2{b[0], b[1]})
+		default:
+			f := (z - a[2]) / (b[2] - a[2])
+			points = append(points, Vec2{a[0] + (b[0]-a[0])*f, a[1] + (b[1]-a[1])*f})
+		}
+	}
+	if len(points) != 2 {
+		return p, q, false
+	}
+	p, q = points[0], points[1]
+	//The solid is on the left of the segment, opposite to where the normal points
+	normal := triangleNormal(t)
+	if (q[1]-p[1])*normal[0]-(q[0]-p[0])*normal[1] < 0 {
+		p, q = q, p
+	}
+	return p, q, true
+}
+
+// RasterizeLayer draws the layer in the viewport, filling the solid with 0.5 and marking the contours
+// with 1. The area between mins and maxs (usually the bounds of the whole model) is fitted to the
+// viewport so all the layers of a model line up.
+func RasterizeLayer(layer Layer, mins, maxs Vec2, viewport Viewport) [][]float32 {
+	matrix := make([][]float32, viewport.Height)
+	for i := range matrix {
+		matrix[i] = make([]float32, viewport.Width)
+	}
+	height := float32(viewport.Height-1) * viewport.aspect()
+	scale := float32(1)
+	if horizontal, vertical := (maxs[0]-mins[0])/float32(viewport.Width-1), (maxs[1]-mins[1])/height; horizontal > vertical {
+		scale = horizontal
+	} else if vertical > 0 {
+		scale = vertical
+	}
+	//Continuous matrix coordinates of a point, rows going down
+	toScreen := func(p Vec2) (row, col float32) {
+		return (height - (p[1]-mins[1])/scale) / viewport.aspect(), (p[0] - mins[0]) / scale
+	}
+	//Fill the cells whose center is inside an odd number of contours
+	for row := range matrix {
+		var crossings []float32
+		for _, contour := range layer.Contours {
+			for i := range contour {
+				aRow, aCol := toScreen(contour[i])
+				bRow, bCol := toScreen(contour[(i+1)%len(contour)])
+				y := float32(row) + 0.5
+				if (aRow > y) != (bRow > y) {
+					crossings = append(crossings, aCol+(y-aRow)/(bRow-aRow)*(bCol-aCol))
+				}
+			}
+		}
+		for col := range matrix[row] {
+			x, inside := float32(col)+0.5, false
+			for _, crossing := range crossings {
+				if crossing < x {
+					inside = !inside
+				}
+			}
+			if inside {
+				matrix[row][col] = 0.5
+			}
+		}
+	}
+	//Mark the contours on top
+	for _, contour := range layer.Contours {
+		for i := range contour {
+			aRow, aCol := toScreen(contour[i])
+			bRow, bCol := toScreen(contour[(i+1)%len(contour)])
+			bresenham(int(aRow), int(aCol), int(bRow), int(bCol), func(row, col int, t float32) {
+				if row >= 0 && col >= 0 && row < len(matrix) && col < len(matrix[row]) {
+					matrix[row][col] = 1
+				}
+			})
+		}
+	}
+	return matrix
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// Magic bytes starting a snapshot, followed by the version of the format
+const snapshotMagic = "stl2ascii snapshot"
+
+// snapshotVersion is the version of the snapshot format written, older ones are rejected
+const snapshotVersion = 1
+
+// Snapshot is a model with what was computed about it, saved and read back as it is by WriteSnapshot
+// and ReadSnapshot to skip preprocessing the next time
+type Snapshot struct {
+	Model Model
+	//Length unit of the coordinates ("mm", "in"...), empty if unknown
+	Units string
+	//Bounding box of the model
+	Bounds Box
+	//Shared vertices and faces, nil if they weren't computed
+	Topology *Topology
+	//Color of every triangle, empty if the model has no colors
+	Colors []color.RGBA
+	//Anything else worth keeping
+	Metadata map[string]string
+}
+
+// NewSnapshot computes the bounds and the topology of the model for a snapshot. The model is not copied.
+func NewSnapshot(m *Model) *Snapshot {
+	s := &Snapshot{Model: *m, Bounds: EmptyBox(), Topology: NewTopology(m)}
+	if len(m.Triangles) > 0 {
+		mins, maxs := getMinsMaxs(m)
+		s.Bounds = Box{Min: mins, Max: maxs}
+	}
+	return s
+}
+
+// snapshotData is what is encoded for a Snapshot, the edges of the topology are indexed again when reading it
+type snapshotData struct {
+	Header     string
+	Name       string
+	Triangles  []Triangle
+	Attributes []Attributes
+	Units      string
+	Bounds     Box
+	Vertices   []Vec3
+	Faces      [][3]int
+	Topology   bool
+	Colors     []color.RGBA
+	Metadata   map[string]string
+}
+
+// WriteSnapshot encodes the snapshot with gob after a magic string and the version of the format
+func WriteSnapshot(w io.Writer, s *Snapshot) error {
+	if len(s.Colors) != 0 && len(s.Colors) != len(s.Model.Triangles) {
+		return errors.New("Snapshot must have one color per triangle or none")
+	}
+	buffered := bufio.NewWriter(w)
+	fmt.Fprintf(buffered, "%s %d\n", snapshotMagic, snapshotVersion)
+	data := snapshotData{
+		Header:     s.Model.Header,
+		Name:       s.Model.Name,
+		Triangles:  s.Model.Triangles,
+		Attributes: s.Model.Attributes,
+		Units:      s.Units,
+		Bounds:     s.Bounds,
+		Colors:     s.Colors,
+		Metadata:   s.Metadata,
+	}
+	if s.Topology != nil {
+		data.Vertices, data.Faces, data.Topology = s.Topology.Vertices, s.Topology.Faces, true
+	}
+	if err := gob.NewEncoder(buffered).Encode(&data); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// ReadSnapshot decodes a snapshot written by WriteSnapshot
+func ReadSnapshot(r io.Reader) (s *Snapshot, err error) {
+	buffered := bufio.NewReader(r)
+	var version int
+	if _, err := fmt.Fscanf(buffered, snapshotMagic+" %d\n", &version); err != nil {
+		return nil, errors.New("Not a snapshot")
+	}
+	if version != snapshotVersion {
+		return nil, fmt.Errorf("Unsupported snapshot version %d", version)
+	}
+	var data snapshotData
+	if err := gob.NewDecoder(buffered).Decode(&data); err != nil {
+		return nil, err
+	}
+	s = &Snapshot{
+		Model:    Model{Header: data.Header, Name: data.Name, NumTriangles: uint32(len(data.Triangles)), Triangles: data.Triangles},
+		Units:    data.Units,
+		Bounds:   data.Bounds,
+		Colors:   data.Colors,
+		Metadata: data.Metadata,
+	}
+	if data.Attributes != nil {
+		s.Model.Attributes = growAttributes(data.Attributes, len(data.Triangles))
+	}
+	if data.Topology {
+		if len(data.Faces) != len(data.Triangles) {
+			return nil, errors.New("Snapshot topology doesn't match its triangles")
+		}
+		for _, face := range data.Faces {
+			for _, v := range face {
+				if v < 0 || v >= len(data.Vertices) {
+					return nil, errors.New("Snapshot topology has faces out of its vertices")
+				}
+			}
+		}
+		s.Topology = &Topology{Vertices: data.Vertices, Faces: data.Faces}
+		s.Topology.indexEdges()
+	}
+	return s, nil
+}
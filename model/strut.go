@@ -0,0 +1,116 @@
+package model
+
+import (
+	"errors"
+	"math"
+)
+
+// StrutOptions tunes Struts and WireframeSolid
+type StrutOptions struct {
+	//Radius of the cylinders along the segments
+	Radius float32
+	//Radius of the spheres where the segments end, the strut radius if 0
+	JointRadius float32
+	//Only the edges where the surface bends by more than this many degrees become struts in
+	//WireframeSolid, every edge if 0
+	FeatureAngle float64
+	//Size of the cells of the distance field the surface is built from, half the strut radius if 0
+	//(but never so small that the longest side of the model takes more than maxFieldCells cells)
+	CellSize float32
+}
+
+// WireframeSolid turns the edges of the model into struts, giving a printable wireframe sculpture of it
+func WireframeSolid(m *Model, opts StrutOptions) (*Model, error) {
+	t := NewTopology(m)
+	var edges [][2]int
+	if opts.FeatureAngle > 0 {
+		edges = t.FeatureEdges(opts.FeatureAngle)
+	} else {
+		edges = t.Edges()
+	}
+	segments := make([][2]Vec3, len(edges))
+	for i, edge := range edges {
+		segments[i] = [2]Vec3{t.Vertices[edge[0]], t.Vertices[edge[1]]}
+	}
+	wireframe, err := Struts(segments, opts)
+	if err != nil {
+		return nil, err
+	}
+	wireframe.Header = m.Header
+	return wireframe, nil
+}
+
+// Struts builds a watertight solid made of a cylinder along every segment and a sphere at each of
+// their ends, joined in a single surface rebuilt from a distance field
+func Struts(segments [][2]Vec3, opts StrutOptions) (*Model, error) {
+	if opts.Radius <= 0 {
+		return nil, errors.New("Strut radius must be positive")
+	}
+	if opts.JointRadius < 0 || opts.CellSize < 0 {
+		return nil, errors.New("Joint radius and cell size can't be negative")
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("No segments to build struts along")
+	}
+	joint := opts.JointRadius
+	if joint == 0 {
+		joint = opts.Radius
+	}
+	reach := float32(math.Max(float64(opts.Radius), float64(joint)))
+	bounds := EmptyBox()
+	for _, segment := range segments {
+		bounds = bounds.Extend(segment[0]).Extend(segment[1])
+	}
+	bounds = Box{Min: bounds.Min.Sub(Vec3{reach, reach, reach}), Max: bounds.Max.Add(Vec3{reach, reach, reach})}
+	cell := opts.CellSize
+	if cell == 0 {
+		cell = opts.Radius / 2
+	}
+	cell = fieldCell(bounds, cell)
+	grid := newDistanceGrid(bounds, cell, 1)
+	for n := range grid.values {
+		grid.values[n] = math.MaxFloat32
+	}
+	//Each shape only lowers the samples around it, the closest shape sets the distance
+	lower := func(min, max Vec3, distance func(p Vec3) float32) {
+		var low, high [3]int
+		for k := range low {
+			low[k] = int(math.Max(0, math.Floor(float64((min[k]-grid.origin[k])/cell))))
+			high[k] = int(math.Min(float64(grid.size[k]-1), math.Ceil(float64((max[k]-grid.origin[k])/cell))))
+		}
+		for k := low[2]; k <= high[2]; k++ {
+			for j := low[1]; j <= high[1]; j++ {
+				for i := low[0]; i <= high[0]; i++ {
+					n := grid.index(i, j, k)
+					grid.values[n] = float32(math.Min(float64(grid.values[n]), float64(distance(grid.point(i, j, k)))))
+				}
+			}
+		}
+	}
+	//A margin of two cells around each shape so the surface is interpolated between right values
+	margin := Vec3{1, 1, 1}.Scale(2 * cell)
+	joints := make(map[Vec3]bool)
+	for _, segment := range segments {
+		a, b := segment[0], segment[1]
+		extent := Vec3{opts.Radius, opts.Radius, opts.Radius}.Add(margin)
+		box := EmptyBox().Extend(a).Extend(b)
+		lower(box.Min.Sub(extent), box.Max.Add(extent), func(p Vec3) float32 {
+			return segmentDistance(p, a, b) - opts.Radius
+		})
+		for _, end := range segment {
+			if joints[end] {
+				continue
+			}
+			joints[end] = true
+			extent := Vec3{joint, joint, joint}.Add(margin)
+			lower(end.Sub(extent), end.Add(extent), func(p Vec3) float32 {
+				return p.Sub(end).Length() - joint
+			})
+		}
+	}
+	triangles := grid.isosurface(0)
+	if len(triangles) == 0 {
+		return nil, errors.New("Struts are too thin for the cells of the distance field")
+	}
+	return &Model{Header: "Struts", Triangles: triangles, NumTriangles: uint32(len(triangles))}, nil
+}
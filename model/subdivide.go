@@ -0,0 +1,23 @@
+package model
+
+import "errors"
+
+// SubdivideLongEdges splits the facets of the model in place until no edge is longer than maxEdge,
+// always splitting the longest edge of the facets at its middle so they don't get thinner. The new
+// facets stay on the original ones, with normals following their winding, the attribute bytes and the
+// Attributes of the facet they come from. Facets with repeated vertices are dropped.
+func SubdivideLongEdges(m *Model, maxEdge float64) error {
+	if maxEdge <= 0 {
+		return errors.New("Maximum edge length must be positive")
+	}
+	if len(m.Triangles) == 0 {
+		return nil
+	}
+	//Nothing is collapsed, flipped or smoothed so no edge needs to stay in place
+	mesh := newEditMesh(NewTopology(m), 180)
+	mesh.splitLongEdges(float32(maxEdge))
+	m.Invalidate()
+	m.Triangles, m.Attributes = mesh.triangles(m.Triangles), mesh.attributes(m)
+	m.NumTriangles = uint32(len(m.Triangles))
+	return nil
+}
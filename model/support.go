@@ -0,0 +1,236 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// SupportOptions tunes GenerateSupports
+type SupportOptions struct {
+	//Overhangs needing supports, see FindOverhangs (the build direction is always +Z)
+	OverhangAngle float64
+	//Distance between the support points along X and Y
+	Spacing float32
+	//Radius of the pillars and branches
+	Radius float32
+	//Room left between the supports and the part, so they break off
+	Gap float32
+	//Grow trees, whose branches lean towards each other and join into shared trunks, instead of a
+	//straight pillar under every point
+	Tree bool
+	//Largest angle of the branches from the vertical, in degrees
+	BranchAngle float64
+	//Size of the cells of the distance field the surface is built from, see StrutOptions
+	CellSize float32
+	//Tolerances of the rays cast between the supports and the part, DefaultTolerances for the fields
+	//left at 0
+	Tolerances Tolerances
+}
+
+// DefaultSupportOptions matches common settings for FDM printers
+var DefaultSupportOptions = SupportOptions{
+	OverhangAngle: DefaultOverhangAngle,
+	Spacing:       3,
+	Radius:        0.5,
+	Gap:           0.2,
+	BranchAngle:   30,
+}
+
+// supportNode is the top of a column still going down while growing the supports
+type supportNode struct {
+	point Vec3
+	//Facet above the node, which the rays going down from it ignore
+	facet int
+}
+
+// SupportPoints returns the points of the overhangs needing supports: those found right above the
+// nodes of a grid of the given spacing over the build plate, with the facets they are on
+func SupportPoints(m *Model, opts SupportOptions) (points []Vec3, facets []int) {
+	if opts.Spacing <= 0 || len(m.Triangles) == 0 {
+		return nil, nil
+	}
+	overhangs := FindOverhangs(m, OverhangOptions{MaxAngle: opts.OverhangAngle})
+	overhanging := make(map[int]bool, len(overhangs.Facets))
+	for _, i := range overhangs.Facets {
+		overhanging[i] = true
+	}
+	bvh := NewBVH(m)
+	bounds := bvh.Bounds()
+	size := bounds.Size()
+	columns, rows := int(size[0]/opts.Spacing)+1, int(size[1]/opts.Spacing)+1
+	//Center the grid on the model, so symmetric parts get symmetric supports
+	start := Vec2{
+		bounds.Min[0] + (size[0]-float32(columns-1)*opts.Spacing)/2,
+		bounds.Min[1] + (size[1]-float32(rows-1)*opts.Spacing)/2,
+	}
+	below := bounds.Min[2] - 1
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			origin := Vec3{start[0] + float32(column)*opts.Spacing, start[1] + float32(row)*opts.Spacing, below}
+			for _, hit := range bvh.RaycastAll(Ray{Origin: origin, Direction: Vec3{0, 0, 1}}) {
+				if overhanging[hit.Triangle] {
+					points = append(points, hit.Point)
+					facets = append(facets, hit.Triangle)
+				}
+			}
+		}
+	}
+	return points, facets
+}
+
+// GenerateSupports builds the supports holding the overhangs of the model while it is printed
+// upright, as a separate model that can be written on its own or merged with the part. From every
+// point given by SupportPoints, a pillar goes down to the build plate, or to the part when it is in
+// the way, keeping the gap away from it; with Tree set, the pillars lean towards each other and join
+// as high as they can without going through the part. The struts are joined in a single watertight
+// surface, like the ones of Struts. It returns nil and no error when nothing needs supports.
+func GenerateSupports(m *Model, opts SupportOptions) (*Model, error) {
+	if opts.Spacing <= 0 || opts.Radius <= 0 {
+		return nil, errors.New("Support spacing and radius must be positive")
+	}
+	if opts.Gap < 0 || opts.CellSize < 0 {
+		return nil, errors.New("Support gap and cell size can't be negative")
+	}
+	if opts.Tree && (opts.BranchAngle <= 0 || opts.BranchAngle >= 90) {
+		return nil, errors.New("Branch angle must be between 0 and 90 degrees")
+	}
+	points, facets := SupportPoints(m, opts)
+	if len(points) == 0 {
+		return nil, nil
+	}
+	bvh := NewBVH(m)
+	bounds := bvh.Bounds()
+	epsilon := opts.Tolerances.Epsilon(bounds)
+	//The joints at the ends of the struts are spheres, the lowest ones rest on the plate
+	plate := bounds.Min[2] + opts.Radius
+	clearance := opts.Gap + opts.Radius
+	up := Vec3{0, 0, 1}
+	//Tips below the points, far enough for the joint to keep the gap from the slanted facet
+	var nodes []supportNode
+	for n, p := range points {
+		facing := -triangleNormal(&m.Triangles[facets[n]]).Dot(up)
+		tip := p.Sub(up.Scale(clearance / max(facing, 0.5)))
+		if tip[2] > plate {
+			nodes = append(nodes, supportNode{tip, facets[n]})
+		}
+	}
+	var segments [][2]Vec3
+	if opts.Tree {
+		nodes, segments = growBranches(bvh, nodes, opts, plate, epsilon)
+	}
+	//Every column left goes straight down to the plate or to the part
+	for _, node := range nodes {
+		bottom := Vec3{node.point[0], node.point[1], plate}
+		if hit, ok := bvh.hitBelow(node.point, node.facet, epsilon); ok {
+			bottom = hit.Point.Add(up.Scale(clearance / max(triangleNormal(&m.Triangles[hit.Triangle]).Dot(up), 0.5)))
+		}
+		if bottom[2] < node.point[2] {
+			segments = append(segments, [2]Vec3{node.point, bottom})
+		}
+	}
+	if len(segments) == 0 {
+		return nil, nil
+	}
+	supports, err := Struts(segments, StrutOptions{Radius: opts.Radius, CellSize: opts.CellSize})
+	if err != nil {
+		return nil, err
+	}
+	supports.Header = "Supports"
+	return supports, nil
+}
+
+// growBranches joins the columns two by two where their branches meet, leaning at most by the branch
+// angle, going for the highest meeting points first. It returns the columns left and the branches.
+func growBranches(bvh *BVH, nodes []supportNode, opts SupportOptions, plate, epsilon float32) ([]supportNode, [][2]Vec3) {
+	slope := float32(math.Tan(radians(opts.BranchAngle)))
+	//Columns farther apart than this don't join, or everything would end in a single low trunk
+	reach := 3 * opts.Spacing
+	type pair struct {
+		a, b int
+		meet Vec3
+	}
+	var segments [][2]Vec3
+	for {
+		var pairs []pair
+		for a := range nodes {
+			for b := a + 1; b < len(nodes); b++ {
+				if meet, ok := branchesMeet(nodes[a].point, nodes[b].point, slope, reach); ok && meet[2] > plate {
+					pairs = append(pairs, pair{a, b, meet})
+				}
+			}
+		}
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].meet[2] > pairs[j].meet[2] })
+		joined := make([]bool, len(nodes))
+		var next []supportNode
+		for _, p := range pairs {
+			if joined[p.a] || joined[p.b] {
+				continue
+			}
+			if !bvh.segmentClear(nodes[p.a].point, p.meet, nodes[p.a].facet, epsilon) ||
+				!bvh.segmentClear(nodes[p.b].point, p.meet, nodes[p.b].facet, epsilon) {
+				continue
+			}
+			joined[p.a], joined[p.b] = true, true
+			for _, end := range [2]Vec3{nodes[p.a].point, nodes[p.b].point} {
+				if end != p.meet {
+					segments = append(segments, [2]Vec3{end, p.meet})
+				}
+			}
+			next = append(next, supportNode{p.meet, -1})
+		}
+		if len(next) == 0 {
+			return nodes, segments
+		}
+		for n := range nodes {
+			if !joined[n] {
+				next = append(next, nodes[n])
+			}
+		}
+		nodes = next
+	}
+}
+
+// branchesMeet returns the highest point reached by branches going down from a and b and leaning
+// by the given slope (horizontal over vertical), when they are within reach of each other
+func branchesMeet(a, b Vec3, slope, reach float32) (meet Vec3, ok bool) {
+	if a[2] < b[2] {
+		a, b = b, a
+	}
+	horizontal := Vec3{b[0] - a[0], b[1] - a[1], 0}
+	distance := horizontal.Length()
+	if distance > reach {
+		return meet, false
+	}
+	//The higher one can go down to the lower one
+	if distance <= slope*(a[2]-b[2]) {
+		return b, true
+	}
+	//Both lean towards each other, the higher one going further
+	fromA := (distance + slope*(a[2]-b[2])) / 2
+	meet = a.Add(horizontal.Scale(fromA / distance))
+	meet[2] = a[2] - fromA/slope
+	return meet, true
+}
+
+// segmentClear checks if the segment between two points crosses no facet other than the one given
+func (b *BVH) segmentClear(from, to Vec3, facet int, epsilon float32) bool {
+	length := to.Sub(from).Length()
+	if length == 0 {
+		return true
+	}
+	distance, ok := b.wallDistance(Ray{Origin: from, Direction: to.Sub(from).Scale(1 / length)}, facet, epsilon)
+	return !ok || distance > length
+}
+
+// hitBelow returns the first facet straight below the point, other than the one given
+func (b *BVH) hitBelow(p Vec3, facet int, epsilon float32) (hit RayHit, ok bool) {
+	hit.Distance = math.MaxFloat32
+	b.traverseRay(Ray{Origin: p, Direction: Vec3{0, 0, -1}}, func(t int, d float32) {
+		if t != facet && d > epsilon && d < hit.Distance {
+			hit.Triangle, hit.Distance, ok = t, d, true
+		}
+	}, func() float32 { return hit.Distance })
+	hit.Point = p.Sub(Vec3{0, 0, hit.Distance})
+	return hit, ok
+}
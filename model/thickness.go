@@ -0,0 +1,75 @@
+package model
+
+import "math"
+
+// ThinWalls lists the facets of a model where the wall is thinner than a minimum
+type ThinWalls struct {
+	Facets []int
+	//Total area of those facets
+	Area float32
+	//Thinnest wall found anywhere in the model, 0 if no probe met the other side
+	Thinnest float32
+	//Thickness measured behind every facet, 0 where no probe met the other side (open models, or
+	//degenerate facets)
+	Thickness []float32
+}
+
+// FindThinWalls probes the thickness behind every facet, casting rays inwards (against the normal
+// given by the winding) from its centroid and from halfway to each vertex, and lists the facets where
+// any of them meets the other side of the wall closer than minThickness. The model should be
+// watertight and consistently oriented (see Repair).
+func FindThinWalls(m *Model, minThickness float32) ThinWalls {
+	return FindThinWallsWith(m, minThickness, DefaultTolerances)
+}
+
+// FindThinWallsWith is FindThinWalls ignoring the hits closer to the start of the probes than
+// tolerances.Probe times the size of the model
+func FindThinWallsWith(m *Model, minThickness float32, tolerances Tolerances) (w ThinWalls) {
+	w.Thickness = make([]float32, len(m.Triangles))
+	if len(m.Triangles) == 0 {
+		return w
+	}
+	bvh := NewBVH(m)
+	//Ignore the hits right at the start, they are the facet itself or its neighbors
+	epsilon := bvh.Bounds().Size().Length() * tolerances.withDefaults().Probe
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		if triangleArea(t) == 0 {
+			continue
+		}
+		normal := triangleNormal(t)
+		a, b, c := Vec3(t.Vertices[0]), Vec3(t.Vertices[1]), Vec3(t.Vertices[2])
+		centroid := a.Add(b).Add(c).Scale(1.0 / 3)
+		probes := [4]Vec3{centroid, centroid.Add(a).Scale(0.5), centroid.Add(b).Scale(0.5), centroid.Add(c).Scale(0.5)}
+		thickness := float32(math.MaxFloat32)
+		for _, probe := range probes {
+			if distance, ok := bvh.wallDistance(Ray{Origin: probe, Direction: normal.Scale(-1)}, i, epsilon); ok && distance < thickness {
+				thickness = distance
+			}
+		}
+		if thickness == math.MaxFloat32 {
+			continue
+		}
+		w.Thickness[i] = thickness
+		if w.Thinnest == 0 || thickness < w.Thinnest {
+			w.Thinnest = thickness
+		}
+		if thickness < minThickness {
+			w.Facets = append(w.Facets, i)
+			w.Area += triangleArea(t)
+		}
+	}
+	return w
+}
+
+// wallDistance returns the distance along the ray to the first triangle other than the one it starts
+// from and farther than epsilon
+func (b *BVH) wallDistance(r Ray, from int, epsilon float32) (distance float32, ok bool) {
+	distance = math.MaxFloat32
+	b.traverseRay(r, func(t int, d float32) {
+		if t != from && d > epsilon && d < distance {
+			distance, ok = d, true
+		}
+	}, func() float32 { return distance })
+	return distance, ok
+}
@@ -0,0 +1,157 @@
+package model
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// ThumbnailOptions tunes GenerateThumbnails
+type ThumbnailOptions struct {
+	//Width and height of the thumbnails in pixels, 256 if 0
+	Size int
+	//Direction to draw the models from, isometric if nil
+	Perspective Perspective
+	//Models rendered at the same time, one per CPU if 0
+	Workers int
+	//Directory for the thumbnails, mirroring the tree of the models. Empty writes each one next to its model.
+	OutputDir string
+	//Render the thumbnails again even if they are newer than their model
+	Force bool
+	//Render the thumbnails this many times larger and scale them down to smooth their edges, see
+	//Downsample (0 or 1 for none)
+	Supersample int
+}
+
+// ThumbnailResult reports what happened to the thumbnail of a model
+type ThumbnailResult struct {
+	Model, Thumbnail string
+	//The thumbnail was already up to date
+	Skipped bool
+	Err     error
+}
+
+// Thumbnail shades the model in a transparent square image of size x size pixels
+func Thumbnail(m *Model, size int, perspective Perspective) *image.RGBA {
+	return SupersampledThumbnail(m, size, perspective, 1)
+}
+
+// SupersampledThumbnail shades the model like Thumbnail, rendering it factor times larger and
+// scaling it down so the edges are smooth
+func SupersampledThumbnail(m *Model, size int, perspective Perspective, factor int) *image.RGBA {
+	factor = max(factor, 1)
+	matrix := RasterizeShaded(m, ImageViewport(size*factor, size*factor), perspective, RenderOptions{}, DefaultLighting)
+	return Downsample(DrawMatrixImage(matrix, image.Transparent), factor)
+}
+
+// ThumbnailPath returns where GenerateThumbnails writes the thumbnail of a model found under root,
+// adding .png to its name so models with the same name and different formats don't collide
+func ThumbnailPath(root, modelPath, outputDir string) string {
+	if outputDir == "" {
+		return modelPath + ".png"
+	}
+	relative, err := filepath.Rel(root, modelPath)
+	if err != nil {
+		relative = filepath.Base(modelPath)
+	}
+	return filepath.Join(outputDir, relative+".png")
+}
+
+// GenerateThumbnails walks the tree under root rendering a PNG thumbnail for every STL and OBJ
+// file, several at a time. Each result is passed to report (one call at a time) as soon as it is
+// done, the error returned is the one walking the tree.
+func GenerateThumbnails(root string, opts ThumbnailOptions, report func(ThumbnailResult)) error {
+	if opts.Size <= 0 {
+		opts.Size = 256
+	}
+	if opts.Perspective == nil {
+		opts.Perspective = IsometricCamera
+	}
+	if opts.Supersample > MaxSupersample {
+		return fmt.Errorf("Thumbnails can't be supersampled more than %d times", MaxSupersample)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	outputDir, _ := filepath.Abs(opts.OutputDir)
+	paths := make(chan string)
+	results := make(chan ThumbnailResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				results <- writeThumbnail(root, path, opts)
+			}
+		}()
+	}
+	//Report from a single goroutine so the callback doesn't need to be safe for concurrent use
+	reported := make(chan struct{})
+	go func() {
+		for result := range results {
+			if report != nil {
+				report(result)
+			}
+		}
+		close(reported)
+	}()
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			//Don't look for models among the thumbnails
+			if absolute, _ := filepath.Abs(path); opts.OutputDir != "" && absolute == outputDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsModelFile(path) {
+			paths <- path
+		}
+		return nil
+	})
+	close(paths)
+	wg.Wait()
+	close(results)
+	<-reported
+	return err
+}
+
+// writeThumbnail renders the thumbnail of a model unless it is already up to date
+func writeThumbnail(root, path string, opts ThumbnailOptions) (result ThumbnailResult) {
+	result.Model = path
+	result.Thumbnail = ThumbnailPath(root, path, opts.OutputDir)
+	if !opts.Force {
+		modelInfo, modelErr := os.Stat(path)
+		thumbnailInfo, thumbnailErr := os.Stat(result.Thumbnail)
+		if modelErr == nil && thumbnailErr == nil && thumbnailInfo.ModTime().After(modelInfo.ModTime()) {
+			result.Skipped = true
+			return result
+		}
+	}
+	m, err := ReadFile(path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if err := os.MkdirAll(filepath.Dir(result.Thumbnail), 0755); err != nil {
+		result.Err = err
+		return result
+	}
+	thumbnailFile, err := os.Create(result.Thumbnail)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Err = EncodeImage(thumbnailFile, SupersampledThumbnail(&m, opts.Size, opts.Perspective, opts.Supersample), ImagePNG)
+	if closeErr := thumbnailFile.Close(); result.Err == nil {
+		result.Err = closeErr
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package model
+
+import "math"
+
+// Tolerances are the thresholds telling when numbers are close enough to be taken as the same, shared
+// by the routines of this package instead of each one having its own. A field left at 0 takes the
+// value of DefaultTolerances.
+type Tolerances struct {
+	//Vertices closer than this along every axis are the same point when welding
+	Weld float32
+	//Facets with an area up to this one are degenerate, only those without any area when 0
+	DegenerateArea float32
+	//Degrees between a stored normal and the one of the winding of its facet for it to be wrong
+	NormalAngle float64
+	//Degrees between two directions for them to be the same, so facets are coplanar
+	Coplanar float64
+	//Distance to a plane, relative to the size of the model, for points to be on it
+	Relative float32
+	//Distance along a ray, relative to the size of the model, under which a hit is the surface the ray
+	//was cast from or its neighbors
+	Probe float32
+	//Difference along every axis between two unit normals for their facets to be the same flat face
+	NormalCell float32
+}
+
+// DefaultTolerances are the tolerances used when none are given, and for the zero fields of those
+// given. Changing them changes every routine using them.
+var DefaultTolerances = Tolerances{
+	Weld:           0.0001,
+	DegenerateArea: 0,
+	//A cosine of 0.999, the normals of files written by other programs are often off by a degree or two
+	NormalAngle: 2.5625,
+	//A cosine of 0.9999
+	Coplanar:   0.81,
+	Relative:   1e-6,
+	Probe:      1e-5,
+	NormalCell: 1e-3,
+}
+
+// withDefaults fills the zero fields with DefaultTolerances
+func (t Tolerances) withDefaults() Tolerances {
+	if t.Weld <= 0 {
+		t.Weld = DefaultTolerances.Weld
+	}
+	if t.DegenerateArea <= 0 {
+		t.DegenerateArea = DefaultTolerances.DegenerateArea
+	}
+	if t.NormalAngle <= 0 {
+		t.NormalAngle = DefaultTolerances.NormalAngle
+	}
+	if t.Coplanar <= 0 {
+		t.Coplanar = DefaultTolerances.Coplanar
+	}
+	if t.Relative <= 0 {
+		t.Relative = DefaultTolerances.Relative
+	}
+	if t.Probe <= 0 {
+		t.Probe = DefaultTolerances.Probe
+	}
+	if t.NormalCell <= 0 {
+		t.NormalCell = DefaultTolerances.NormalCell
+	}
+	return t
+}
+
+// IsDegenerate checks if the triangle is too small to count as a facet
+func (t Tolerances) IsDegenerate(triangle *Triangle) bool {
+	area := triangleArea(triangle)
+	return area == 0 || area <= t.withDefaults().DegenerateArea
+}
+
+// NormalMatches checks if the stored normal of the triangle agrees with its winding. Triangles
+// without area have no winding and always match, zero normals never do.
+func (t Tolerances) NormalMatches(triangle *Triangle) bool {
+	computed := triangle.ComputedNormal()
+	if computed.Length() == 0 {
+		return true
+	}
+	return float64(Vec3(triangle.Normal).Normalize().Dot(computed)) >= math.Cos(t.withDefaults().NormalAngle*math.Pi/180)
+}
+
+// SameDirection checks if two unit vectors point the same way
+func (t Tolerances) SameDirection(a, b Vec3) bool {
+	return float64(a.Dot(b)) >= math.Cos(t.withDefaults().Coplanar*math.Pi/180)
+}
+
+// Epsilon returns the distance under which points are on a plane for a model of the given bounds
+func (t Tolerances) Epsilon(bounds Box) float32 {
+	return bounds.Size().Length() * t.withDefaults().Relative
+}
@@ -0,0 +1,210 @@
+package model
+
+import "sort"
+
+// Topology describes how the triangles of a model connect to each other once the vertices with
+// exactly the same coordinates are merged
+type Topology struct {
+	//Distinct vertices and, for every triangle, the indices of its three vertices
+	Vertices []Vec3
+	Faces    [][3]int
+	//Uses of every edge, keyed by its vertex indices in increasing order
+	edges map[[2]int]*edgeUse
+	//Faces using every vertex, indexed on the first call to FacesAroundVertex
+	vertexFaces [][]int
+}
+
+// edgeUse records the faces sharing an edge and how many of them go along it from the lower to the
+// higher vertex index, which tells if their orientations agree
+type edgeUse struct {
+	faces   []int
+	forward int
+}
+
+// NewTopology merges the shared vertices of the model and indexes its edges
+func NewTopology(m *Model) *Topology {
+	t := &Topology{Faces: make([][3]int, len(m.Triangles))}
+	indices := make(map[Vec3]int)
+	for i := range m.Triangles {
+		for j, v := range m.Triangles[i].Vertices {
+			index, found := indices[v]
+			if !found {
+				index = len(t.Vertices)
+				indices[v] = index
+				t.Vertices = append(t.Vertices, v)
+			}
+			t.Faces[i][j] = index
+		}
+	}
+	t.indexEdges()
+	return t
+}
+
+// indexEdges records the faces using every edge
+func (t *Topology) indexEdges() {
+	t.edges = make(map[[2]int]*edgeUse)
+	for i, face := range t.Faces {
+		for j := range face {
+			a, b := face[j], face[(j+1)%3]
+			//Collapsed edges don't connect anything
+			if a == b {
+				continue
+			}
+			key, forward := [2]int{a, b}, 1
+			if a > b {
+				key, forward = [2]int{b, a}, 0
+			}
+			use := t.edges[key]
+			if use == nil {
+				use = &edgeUse{}
+				t.edges[key] = use
+			}
+			use.faces = append(use.faces, i)
+			use.forward += forward
+		}
+	}
+}
+
+// NumEdges returns the number of distinct edges
+func (t *Topology) NumEdges() int {
+	return len(t.edges)
+}
+
+// Edges returns every edge, as pairs of vertex indices in increasing order
+func (t *Topology) Edges() (edges [][2]int) {
+	for key := range t.edges {
+		edges = append(edges, key)
+	}
+	sortEdges(edges)
+	return edges
+}
+
+// BoundaryEdges returns the number of edges used by a single face, which are the borders of holes
+func (t *Topology) BoundaryEdges() (count int) {
+	for _, use := range t.edges {
+		if len(use.faces) == 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// NonManifoldEdges returns the number of edges shared by more than two faces
+func (t *Topology) NonManifoldEdges() (count int) {
+	for _, use := range t.edges {
+		if len(use.faces) > 2 {
+			count++
+		}
+	}
+	return count
+}
+
+// InconsistentEdges returns the number of edges between two faces with opposite orientations
+// (both faces go along the edge the same way)
+func (t *Topology) InconsistentEdges() (count int) {
+	for _, use := range t.edges {
+		if len(use.faces) == 2 && use.forward != 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// IsWatertight checks if the surface is closed and consistently oriented: every edge is shared by
+// exactly two faces going along it in opposite directions
+func (t *Topology) IsWatertight() bool {
+	if len(t.edges) == 0 {
+		return false
+	}
+	for _, use := range t.edges {
+		if len(use.faces) != 2 || use.forward != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// DegenerateFaces returns the indices of the faces without area: repeated or collinear vertices
+func (t *Topology) DegenerateFaces() (faces []int) {
+	for i, face := range t.Faces {
+		a, b, c := t.Vertices[face[0]], t.Vertices[face[1]], t.Vertices[face[2]]
+		if face[0] == face[1] || face[1] == face[2] || face[2] == face[0] || b.Sub(a).Cross(c.Sub(a)).Length() == 0 {
+			faces = append(faces, i)
+		}
+	}
+	return faces
+}
+
+// Shells groups the faces in connected pieces, faces being connected when they share an edge
+func (t *Topology) Shells() [][]int {
+	parents := make([]int, len(t.Faces))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+	for _, use := range t.edges {
+		for _, face := range use.faces[1:] {
+			parents[find(face)] = find(use.faces[0])
+		}
+	}
+	//Number the shells in the order of their first face
+	shellOf := make(map[int]int)
+	var shells [][]int
+	for i := range t.Faces {
+		root := find(i)
+		shell, found := shellOf[root]
+		if !found {
+			shell = len(shells)
+			shellOf[root] = shell
+			shells = append(shells, nil)
+		}
+		shells[shell] = append(shells[shell], i)
+	}
+	return shells
+}
+
+// Neighbors returns the faces sharing an edge with the face, each one once and in increasing order
+func (t *Topology) Neighbors(face int) (neighbors []int) {
+	seen := map[int]bool{face: true}
+	for j := range t.Faces[face] {
+		a, b := t.Faces[face][j], t.Faces[face][(j+1)%3]
+		if a > b {
+			a, b = b, a
+		}
+		use := t.edges[[2]int{a, b}]
+		if use == nil {
+			continue
+		}
+		for _, other := range use.faces {
+			if !seen[other] {
+				seen[other] = true
+				neighbors = append(neighbors, other)
+			}
+		}
+	}
+	sort.Ints(neighbors)
+	return neighbors
+}
+
+// FacesAroundVertex returns the faces using the vertex, an index in Vertices, in increasing order
+func (t *Topology) FacesAroundVertex(vertex int) []int {
+	if t.vertexFaces == nil {
+		t.vertexFaces = make([][]int, len(t.Vertices))
+		for i, face := range t.Faces {
+			for j, v := range face {
+				//Collapsed faces use some vertex twice
+				if j > 0 && v == face[0] || j > 1 && v == face[1] {
+					continue
+				}
+				t.vertexFaces[v] = append(t.vertexFaces[v], i)
+			}
+		}
+	}
+	return t.vertexFaces[vertex]
+}
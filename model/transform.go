@@ -0,0 +1,83 @@
+package model
+
+import "math"
+
+// Affine is a linear transformation (rotation, scale, mirror...) followed by a translation
+type Affine struct {
+	Linear      Mat3
+	Translation Vec3
+}
+
+// IdentityAffine returns the transformation leaving everything in place
+func IdentityAffine() Affine {
+	return Affine{Linear: Identity()}
+}
+
+// Translate returns the transformation moving everything by v
+func Translate(v Vec3) Affine {
+	return Affine{Linear: Identity(), Translation: v}
+}
+
+// Scale returns the transformation scaling each axis around the origin, mirroring the negative ones
+func Scale(v Vec3) Affine {
+	return Affine{Linear: Mat3{{v[0], 0, 0}, {0, v[1], 0}, {0, 0, v[2]}}}
+}
+
+// RotateX returns the rotation of angle radians around the X axis (counter-clockwise looking from +X)
+func RotateX(angle float64) Affine {
+	sin, cos := float32(math.Sin(angle)), float32(math.Cos(angle))
+	return Affine{Linear: Mat3{{1, 0, 0}, {0, cos, -sin}, {0, sin, cos}}}
+}
+
+// RotateY returns the rotation of angle radians around the Y axis (counter-clockwise looking from +Y)
+func RotateY(angle float64) Affine {
+	sin, cos := float32(math.Sin(angle)), float32(math.Cos(angle))
+	return Affine{Linear: Mat3{{cos, 0, sin}, {0, 1, 0}, {-sin, 0, cos}}}
+}
+
+// RotateZ returns the rotation of angle radians around the Z axis (counter-clockwise looking from +Z)
+func RotateZ(angle float64) Affine {
+	sin, cos := float32(math.Sin(angle)), float32(math.Cos(angle))
+	return Affine{Linear: Mat3{{cos, -sin, 0}, {sin, cos, 0}, {0, 0, 1}}}
+}
+
+// Then returns the transformation applying a and then b
+func (a Affine) Then(b Affine) Affine {
+	return Affine{Linear: b.Linear.Mul(a.Linear), Translation: b.Linear.MulVec(a.Translation).Add(b.Translation)}
+}
+
+// Apply returns the point p transformed
+func (a Affine) Apply(p Vec3) Vec3 {
+	return a.Linear.MulVec(p).Add(a.Translation)
+}
+
+// CenterTransform returns the translation moving the center of the bounding box of the model to the origin
+func CenterTransform(m *Model) Affine {
+	if len(m.Triangles) == 0 {
+		return IdentityAffine()
+	}
+	mins, maxs := getMinsMaxs(m)
+	return Translate(Box{Min: mins, Max: maxs}.Center().Scale(-1))
+}
+
+// Transform applies the transformation to the model in place. Normals follow the surface and, when
+// the transformation mirrors, the winding of every triangle is reversed so they still face outwards.
+func Transform(m *Model, a Affine) {
+	m.Invalidate()
+	normals := a.Linear.Cofactor()
+	mirrors := a.Linear.Determinant() < 0
+	if mirrors {
+		//The cofactors of a mirror point the normals inwards
+		normals = normals.Mul(Scale(Vec3{-1, -1, -1}).Linear)
+	}
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		for j := range t.Vertices {
+			t.Vertices[j] = a.Apply(t.Vertices[j])
+		}
+		t.Normal = normals.MulVec(t.Normal).Normalize()
+		if mirrors {
+			t.Vertices[1], t.Vertices[2] = t.Vertices[2], t.Vertices[1]
+		}
+	}
+}
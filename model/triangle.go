@@ -0,0 +1,71 @@
+package model
+
+import "math"
+
+// Area returns the area of the triangle
+func (t *Triangle) Area() float32 {
+	return triangleArea(t)
+}
+
+// Centroid returns the average of the three vertices
+func (t *Triangle) Centroid() Vec3 {
+	return Vec3(t.Vertices[0]).Add(t.Vertices[1]).Add(t.Vertices[2]).Scale(1.0 / 3)
+}
+
+// ComputedNormal returns the unit normal from the winding of the vertices, ignoring the stored one. It
+// is the zero vector when the triangle has no area.
+func (t *Triangle) ComputedNormal() Vec3 {
+	return Vec3(t.Vertices[1]).Sub(t.Vertices[0]).Cross(Vec3(t.Vertices[2]).Sub(t.Vertices[0])).Normalize()
+}
+
+// edgeLengths returns the length of the edges leaving each vertex towards the next one
+func (t *Triangle) edgeLengths() (lengths [3]float32) {
+	for i := range lengths {
+		lengths[i] = Vec3(t.Vertices[(i+1)%3]).Sub(t.Vertices[i]).Length()
+	}
+	return lengths
+}
+
+// AspectRatio returns the longest edge over the diameter of the inscribed circle, scaled so an
+// equilateral triangle gives 1. Slivers and needles give big values, and triangles without area give
+// +Inf.
+func (t *Triangle) AspectRatio() float32 {
+	lengths := t.edgeLengths()
+	longest := lengths[0]
+	if lengths[1] > longest {
+		longest = lengths[1]
+	}
+	if lengths[2] > longest {
+		longest = lengths[2]
+	}
+	area := t.Area()
+	if area == 0 {
+		return float32(math.Inf(1))
+	}
+	//The radius of the inscribed circle is the area over the half perimeter
+	inradius := 2 * area / (lengths[0] + lengths[1] + lengths[2])
+	return longest / (2 * float32(math.Sqrt(3)) * inradius)
+}
+
+// MinAngle returns the smallest of the three angles of the triangle, in degrees. It is 0 when the
+// triangle has no area.
+func (t *Triangle) MinAngle() float64 {
+	minAngle := 180.0
+	for i := range t.Vertices {
+		a := Vec3(t.Vertices[(i+1)%3]).Sub(t.Vertices[i])
+		b := Vec3(t.Vertices[(i+2)%3]).Sub(t.Vertices[i])
+		lengths := float64(a.Length()) * float64(b.Length())
+		if lengths == 0 {
+			return 0
+		}
+		cos := math.Max(-1, math.Min(1, float64(a.Dot(b))/lengths))
+		minAngle = math.Min(minAngle, degrees(math.Acos(cos)))
+	}
+	return minAngle
+}
+
+// Plane returns the plane holding the triangle, its normal following the winding of the vertices. The
+// normal is the zero vector when the triangle has no area.
+func (t *Triangle) Plane() Plane {
+	return Plane{Point: t.Vertices[0], Normal: t.ComputedNormal()}
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// URLOptions tunes CreateFromURLWith
+type URLOptions struct {
+	//Largest download in bytes, 64 MB if 0
+	MaxSize int64
+	//Time the whole download may take, 30 seconds if 0, on top of the deadline of the context
+	Timeout time.Duration
+	//Format of the model (stl, obj, json, csv or tsv), sniffed from the content, its type and the
+	//extension of the URL if empty
+	Format string
+	//Directory keeping the downloads, named after the URL and the format they were read in, so every
+	//URL is only downloaded once. Nothing is kept if empty.
+	CacheDir string
+	//Client making the requests, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// CreateFromURL downloads a model over HTTP or HTTPS and reads it, with the default limits of
+// CreateFromURLWith
+func CreateFromURL(ctx context.Context, rawURL string) (Model, error) {
+	return CreateFromURLWith(ctx, rawURL, URLOptions{})
+}
+
+// CreateFromURLWith downloads a model over HTTP or HTTPS and reads it in the format of the options,
+// or the one found by SniffFormat. Downloads larger than the limit or slower than the timeout fail
+// before being read.
+func CreateFromURLWith(ctx context.Context, rawURL string, opts URLOptions) (m Model, err error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return m, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return m, fmt.Errorf("Unsupported URL scheme %q", parsed.Scheme)
+	}
+	if opts.MaxSize < 0 || opts.Timeout < 0 {
+		return m, errors.New("Size limit and timeout can't be negative")
+	}
+	cached := ""
+	if opts.CacheDir != "" {
+		sum := sha256.Sum256([]byte(rawURL))
+		cached = filepath.Join(opts.CacheDir, hex.EncodeToString(sum[:]))
+		//The extension is the format the download was read in, which only its content type may have told
+		for _, format := range []string{"stl", "obj", "json", "csv", "tsv"} {
+			if opts.Format != "" && format != opts.Format {
+				continue
+			}
+			if data, err := os.ReadFile(cached + "." + format); err == nil {
+				return decodeFormat(data, format, "", parsed.Path)
+			}
+		}
+	}
+	data, contentType, err := download(ctx, rawURL, opts)
+	if err != nil {
+		return m, err
+	}
+	format := opts.Format
+	if format == "" {
+		format = SniffFormat(data, contentType, parsed.Path)
+	}
+	m, err = decodeFormat(data, format, contentType, parsed.Path)
+	//Only keep what could be read, writing it whole before it can be found
+	if err == nil && cached != "" {
+		if file, tempErr := os.CreateTemp(opts.CacheDir, "download-*"); tempErr == nil {
+			_, writeErr := file.Write(data)
+			closeErr := file.Close()
+			if writeErr != nil || closeErr != nil || os.Rename(file.Name(), cached+"."+format) != nil {
+				os.Remove(file.Name())
+			}
+		}
+	}
+	return m, err
+}
+
+// download gets the body of the URL within the limits of the options, with its content type
+func download(ctx context.Context, rawURL string, opts URLOptions) (data []byte, contentType string, err error) {
+	maxSize, timeout, client := opts.MaxSize, opts.Timeout, opts.Client
+	if maxSize == 0 {
+		maxSize = 64 << 20
+	}
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("Downloading %s failed: %s", rawURL, response.Status)
+	}
+	if response.ContentLength > maxSize {
+		return nil, "", fmt.Errorf("Model is larger than %d bytes", maxSize)
+	}
+	//Read one byte more than the limit to tell a file of that size from a larger one
+	data, err = io.ReadAll(io.LimitReader(response.Body, maxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, "", fmt.Errorf("Model is larger than %d bytes", maxSize)
+	}
+	return data, response.Header.Get("Content-Type"), nil
+}
+
+// SniffFormat guesses the format of a model (stl, obj, json, csv or tsv) from its first bytes, then
+// from its MIME content type and the extension of its name, both of which may be empty. Anything
+// else is taken for STL.
+func SniffFormat(data []byte, contentType, name string) string {
+	start := bytes.TrimLeft(data[:min(len(data), 512)], " \t\r\n")
+	switch {
+	case bytes.HasPrefix(start, []byte("solid")):
+		//Binary files may start with it too, the STL reader tells them apart
+		return "stl"
+	case bytes.HasPrefix(start, []byte("{")):
+		return "json"
+	case bytes.HasPrefix(start, []byte("v ")), bytes.HasPrefix(start, []byte("o ")),
+		bytes.HasPrefix(start, []byte("g ")), bytes.HasPrefix(start, []byte("mtllib ")):
+		return "obj"
+	}
+	switch strings.TrimSpace(strings.Split(contentType, ";")[0]) {
+	case "model/stl", "model/x.stl-binary", "model/x.stl-ascii", "application/sla", "application/vnd.ms-pki.stl":
+		return "stl"
+	case "model/obj":
+		return "obj"
+	case "application/json":
+		return "json"
+	case "text/csv":
+		return "csv"
+	case "text/tab-separated-values":
+		return "tsv"
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".obj":
+		return "obj"
+	case ".json":
+		return "json"
+	case ".csv":
+		return "csv"
+	case ".tsv":
+		return "tsv"
+	}
+	return "stl"
+}
+
+// decodeFormat reads a model from memory in the format, or the sniffed one if it is empty
+func decodeFormat(data []byte, format, contentType, name string) (m Model, err error) {
+	if format == "" {
+		format = SniffFormat(data, contentType, name)
+	}
+	switch format {
+	case "stl":
+		return CreateFromSTLBytes(data)
+	case "obj":
+		return CreateFromOBJ(bytes.NewReader(data))
+	case "json":
+		return CreateFromJSON(bytes.NewReader(data))
+	case "csv":
+		return CreateFromCSV(bytes.NewReader(data), 0)
+	case "tsv":
+		return CreateFromCSV(bytes.NewReader(data), '\t')
+	}
+	return m, fmt.Errorf("Unknown model format %q", format)
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IssueKind is what Validate found wrong with a model
+type IssueKind string
+
+const (
+	//NumTriangles doesn't match the triangles, or the attributes are not one per triangle
+	IssueCount IssueKind = "count"
+	//Coordinates or normals are NaN or infinite
+	IssueNonFinite IssueKind = "non-finite"
+	//Stored normals don't match the winding of their facets
+	IssueNormals IssueKind = "normals"
+	//Facets without area
+	IssueDegenerate IssueKind = "degenerate"
+	//Edges used by a single facet, the borders of holes
+	IssueOpen IssueKind = "open"
+	//Edges shared by more than two facets
+	IssueNonManifold IssueKind = "non-manifold"
+	//Edges between facets facing opposite ways
+	IssueOrientation IssueKind = "orientation"
+)
+
+// Issue is something wrong with a model found by Validate
+type Issue struct {
+	Severity Severity  `json:"severity"`
+	Kind     IssueKind `json:"kind"`
+	Message  string    `json:"message"`
+	//Indices of the triangles concerned, in increasing order, when it can be pinned down to some
+	Triangles []int `json:"triangles,omitempty"`
+}
+
+// Issues is the list of the issues of a model, in the order of their kinds
+type Issues []Issue
+
+// Worst returns the highest severity among the issues, SeverityOK when there are none
+func (issues Issues) Worst() (worst Severity) {
+	for _, issue := range issues {
+		if issue.Severity > worst {
+			worst = issue.Severity
+		}
+	}
+	return worst
+}
+
+// String prints one line per issue
+func (issues Issues) String() string {
+	var b strings.Builder
+	for _, issue := range issues {
+		fmt.Fprintf(&b, "%v: %v: %v\n", issue.Kind, issue.Severity, issue.Message)
+	}
+	return b.String()
+}
+
+// ValidateOptions tunes Validate
+type ValidateOptions struct {
+	//Tolerances telling the normals not matching their winding and the degenerate facets,
+	//DefaultTolerances for the fields left at 0. Zero normals, which many exporters write, are always
+	//accepted.
+	Tolerances Tolerances
+	//Accept open surfaces, such as sheets, without reporting their borders
+	AllowOpen bool
+}
+
+// Validate checks the model for the defects that break the programs reading it: a triangle count out
+// of step, NaN or infinite numbers, normals not matching the winding, facets without area, and
+// surfaces that are open, non-manifold or inconsistently oriented. It returns nothing when the model
+// is sound. Degenerate facets and bad normals are warnings, the rest are errors.
+func Validate(m *Model, opts ValidateOptions) (issues Issues) {
+	add := func(severity Severity, kind IssueKind, triangles []int, format string, args ...interface{}) {
+		issues = append(issues, Issue{Severity: severity, Kind: kind, Message: fmt.Sprintf(format, args...), Triangles: triangles})
+	}
+	if err := m.CheckCount(); err != nil {
+		add(SeverityError, IssueCount, nil, "%v", err)
+	}
+	if nonFinite := NonFiniteFacets(m); len(nonFinite) > 0 {
+		add(SeverityError, IssueNonFinite, nonFinite, "%v facets have NaN or infinite numbers", len(nonFinite))
+	}
+	var badNormals, degenerate []int
+	for i := range m.Triangles {
+		t := &m.Triangles[i]
+		if t.Normal != [3]float32{} && finiteTriangle(t) && !opts.Tolerances.NormalMatches(t) {
+			badNormals = append(badNormals, i)
+		}
+		if opts.Tolerances.IsDegenerate(t) {
+			degenerate = append(degenerate, i)
+		}
+	}
+	if len(badNormals) > 0 {
+		add(SeverityWarning, IssueNormals, badNormals, "%v facets have normals more than %v degrees away from their winding",
+			len(badNormals), opts.Tolerances.withDefaults().NormalAngle)
+	}
+	if len(degenerate) > 0 {
+		add(SeverityWarning, IssueDegenerate, degenerate, "%v facets have no area", len(degenerate))
+	}
+
+	topology := NewTopology(m)
+	if !opts.AllowOpen {
+		if count, faces := topology.facesOfEdges(func(use *edgeUse) bool { return len(use.faces) == 1 }); count > 0 {
+			add(SeverityError, IssueOpen, faces, "%v edges border holes in the surface", count)
+		}
+	}
+	if count, faces := topology.facesOfEdges(func(use *edgeUse) bool { return len(use.faces) > 2 }); count > 0 {
+		add(SeverityError, IssueNonManifold, faces, "%v edges are shared by more than two facets", count)
+	}
+	if count, faces := topology.facesOfEdges(func(use *edgeUse) bool { return len(use.faces) == 2 && use.forward != 1 }); count > 0 {
+		add(SeverityError, IssueOrientation, faces, "%v edges are between facets facing opposite ways", count)
+	}
+	return issues
+}
+
+// facesOfEdges returns the number of edges matching and the faces using them, in increasing order
+func (t *Topology) facesOfEdges(match func(use *edgeUse) bool) (count int, faces []int) {
+	seen := make(map[int]bool)
+	for _, use := range t.edges {
+		if !match(use) {
+			continue
+		}
+		count++
+		for _, f := range use.faces {
+			if !seen[f] {
+				seen[f] = true
+				faces = append(faces, f)
+			}
+		}
+	}
+	sort.Ints(faces)
+	return count, faces
+}
@@ -0,0 +1,276 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Vec3 is a point or direction in model space
+type Vec3 [3]float32
+
+// Add returns a + b
+func (a Vec3) Add(b Vec3) Vec3 {
+	return Vec3{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+// Sub returns a - b
+func (a Vec3) Sub(b Vec3) Vec3 {
+	return Vec3{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+// Scale returns a multiplied by s
+func (a Vec3) Scale(s float32) Vec3 {
+	return Vec3{a[0] * s, a[1] * s, a[2] * s}
+}
+
+// Dot returns the dot product of a and b
+func (a Vec3) Dot(b Vec3) float32 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+// Cross returns the cross product of a and b
+func (a Vec3) Cross(b Vec3) Vec3 {
+	return Vec3{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+// Length returns the euclidean length of a
+func (a Vec3) Length() float32 {
+	return float32(math.Sqrt(float64(a.Dot(a))))
+}
+
+// Normalize returns a scaled to unit length (or the zero vector if a has no length)
+func (a Vec3) Normalize() Vec3 {
+	length := a.Length()
+	if length == 0 {
+		return a
+	}
+	return a.Scale(1 / length)
+}
+
+// Min returns the component-wise minimum of a and b
+func (a Vec3) Min(b Vec3) Vec3 {
+	for i := range a {
+		if b[i] < a[i] {
+			a[i] = b[i]
+		}
+	}
+	return a
+}
+
+// Max returns the component-wise maximum of a and b
+func (a Vec3) Max(b Vec3) Vec3 {
+	for i := range a {
+		if b[i] > a[i] {
+			a[i] = b[i]
+		}
+	}
+	return a
+}
+
+// Mat3 is a 3x3 matrix, mostly used for rotations
+type Mat3 [3][3]float32
+
+// Identity returns the identity matrix
+func Identity() Mat3 {
+	return Mat3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+}
+
+// MulVec returns the product of the matrix and v
+func (a Mat3) MulVec(v Vec3) Vec3 {
+	return Vec3{
+		a[0][0]*v[0] + a[0][1]*v[1] + a[0][2]*v[2],
+		a[1][0]*v[0] + a[1][1]*v[1] + a[1][2]*v[2],
+		a[2][0]*v[0] + a[2][1]*v[1] + a[2][2]*v[2],
+	}
+}
+
+// Mul returns the product a * b (b is applied first)
+func (a Mat3) Mul(b Mat3) (c Mat3) {
+	for i := range c {
+		for j := range c[i] {
+			c[i][j] = a[i][0]*b[0][j] + a[i][1]*b[1][j] + a[i][2]*b[2][j]
+		}
+	}
+	return c
+}
+
+// Transpose returns the matrix with rows and columns swapped, which is the inverse of a rotation
+func (a Mat3) Transpose() (t Mat3) {
+	for i := range t {
+		for j := range t[i] {
+			t[i][j] = a[j][i]
+		}
+	}
+	return t
+}
+
+// Determinant returns the determinant of the matrix, negative when it mirrors
+func (a Mat3) Determinant() float32 {
+	return a[0][0]*(a[1][1]*a[2][2]-a[1][2]*a[2][1]) -
+		a[0][1]*(a[1][0]*a[2][2]-a[1][2]*a[2][0]) +
+		a[0][2]*(a[1][0]*a[2][1]-a[1][1]*a[2][0])
+}
+
+// Cofactor returns the matrix of cofactors, which is the inverse transpose scaled by the determinant.
+// It maps the normals of a surface transformed by the matrix.
+func (a Mat3) Cofactor() (c Mat3) {
+	for i := range c {
+		for j := range c[i] {
+			r0, r1 := (i+1)%3, (i+2)%3
+			c0, c1 := (j+1)%3, (j+2)%3
+			c[i][j] = a[r0][c0]*a[r1][c1] - a[r0][c1]*a[r1][c0]
+		}
+	}
+	return c
+}
+
+// Box is an axis aligned bounding box
+type Box struct {
+	Min Vec3
+	Max Vec3
+}
+
+// EmptyBox returns a box that contains nothing and grows with Extend
+func EmptyBox() Box {
+	return Box{
+		Min: Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32},
+		Max: Vec3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32},
+	}
+}
+
+// Extend returns the box grown to include p
+func (b Box) Extend(p Vec3) Box {
+	return Box{Min: b.Min.Min(p), Max: b.Max.Max(p)}
+}
+
+// Union returns the smallest box containing both boxes
+func (b Box) Union(o Box) Box {
+	return Box{Min: b.Min.Min(o.Min), Max: b.Max.Max(o.Max)}
+}
+
+// Size returns the length of the box along each axis
+func (b Box) Size() Vec3 {
+	return b.Max.Sub(b.Min)
+}
+
+// Center returns the middle point of the box
+func (b Box) Center() Vec3 {
+	return b.Min.Add(b.Max).Scale(0.5)
+}
+
+// Contains checks if p is inside the box (boundary included)
+func (b Box) Contains(p Vec3) bool {
+	for i := range p {
+		if p[i] < b.Min[i] || p[i] > b.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects checks if both boxes overlap (touching counts)
+func (b Box) Intersects(o Box) bool {
+	for i := range b.Min {
+		if b.Max[i] < o.Min[i] || o.Max[i] < b.Min[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// distanceSquared returns the squared distance from p to the box (0 if inside)
+func (b Box) distanceSquared(p Vec3) float32 {
+	var d float32
+	for i := range p {
+		if p[i] < b.Min[i] {
+			d += (b.Min[i] - p[i]) * (b.Min[i] - p[i])
+		} else if p[i] > b.Max[i] {
+			d += (p[i] - b.Max[i]) * (p[i] - b.Max[i])
+		}
+	}
+	return d
+}
+
+// Bounds returns the bounding box of the triangle
+func (t *Triangle) Bounds() Box {
+	return EmptyBox().Extend(t.Vertices[0]).Extend(t.Vertices[1]).Extend(t.Vertices[2])
+}
+
+// closestPointOnTriangle returns the point of the triangle abc closest to p
+func closestPointOnTriangle(p, a, b, c Vec3) Vec3 {
+	//Check if p is in the vertex region outside a
+	ab, ac, ap := b.Sub(a), c.Sub(a), p.Sub(a)
+	d1, d2 := ab.Dot(ap), ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+	//Check if p is in the vertex region outside b
+	bp := p.Sub(b)
+	d3, d4 := ab.Dot(bp), ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+	//Check if p is in the edge region of ab
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return a.Add(ab.Scale(d1 / (d1 - d3)))
+	}
+	//Check if p is in the vertex region outside c
+	cp := p.Sub(c)
+	d5, d6 := ab.Dot(cp), ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+	//Check if p is in the edge region of ac
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return a.Add(ac.Scale(d2 / (d2 - d6)))
+	}
+	//Check if p is in the edge region of bc
+	va := d3*d6 - d5*d4
+	if va <= 0 && (d4-d3) >= 0 && (d5-d6) >= 0 {
+		return b.Add(c.Sub(b).Scale((d4 - d3) / ((d4 - d3) + (d5 - d6))))
+	}
+	//p is inside the face region
+	denom := va + vb + vc
+	if denom == 0 {
+		//Degenerate triangle, all vertices are on a line
+		return a
+	}
+	return a.Add(ab.Scale(vb / denom)).Add(ac.Scale(vc / denom))
+}
+
+// Vec2 is a point in a plane, used for 2D profiles and outlines
+type Vec2 [2]float32
+
+// Sub returns a - b
+func (a Vec2) Sub(b Vec2) Vec2 {
+	return Vec2{a[0] - b[0], a[1] - b[1]}
+}
+
+// Cross returns the z component of the cross product of a and b
+func (a Vec2) Cross(b Vec2) float32 {
+	return a[0]*b[1] - a[1]*b[0]
+}
+
+// ParseVec3 reads a vector from "x,y,z"
+func ParseVec3(s string) (v Vec3, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return v, errors.New("Vector must be x,y,z")
+	}
+	for i := range parts {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 32)
+		if err != nil {
+			return v, err
+		}
+		v[i] = float32(parsed)
+	}
+	return v, nil
+}
@@ -0,0 +1,202 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// View is everything deciding how a model is drawn, so a render can be saved as JSON and repeated
+type View struct {
+	//Direction to draw from (front, side, top, iso, dimetric or sheet), ignored when there is a camera
+	Projection string  `json:"projection,omitempty"`
+	Camera     *Camera `json:"camera,omitempty"`
+	//Width of the grid in characters or pixels, and its height (0 to keep the proportions)
+	Width  int `json:"width"`
+	Height int `json:"height,omitempty"`
+	//Height of a cell relative to its width, 1 for images
+	Aspect float64 `json:"aspect"`
+	//Where the model is placed, stretched to fill the grid when there is none
+	Frame *Frame `json:"frame,omitempty"`
+	//What to draw (vertices, depth, wire, shaded or curvature) and the curvature (mean or gaussian)
+	Mode      string `json:"mode"`
+	Curvature string `json:"curvature,omitempty"`
+	Cull      bool   `json:"cull,omitempty"`
+	//Direction the light comes from when shading, in view coordinates (right, up, towards the viewer)
+	Light   Vec3    `json:"light"`
+	Ambient float64 `json:"ambient"`
+	//How to paint the terminal: palette (block, ascii or custom characters), colors (none, 256, true or auto)
+	Palette string `json:"palette,omitempty"`
+	Color   string `json:"color,omitempty"`
+	Braille bool   `json:"braille,omitempty"`
+	//Paint each shell, color, material or region of the model in its own color, shading it (empty for none)
+	ColorBy string `json:"colorBy,omitempty"`
+	//Highlight the facets overhanging more than this angle in degrees, the walls thinner than this
+	//and the edges bending more than this angle in degrees (0 for none)
+	Overhang float64 `json:"overhang,omitempty"`
+	ThinWall float64 `json:"thinWall,omitempty"`
+	Features float64 `json:"features,omitempty"`
+	//Highlight the cross sections by the horizontal planes at these heights
+	Sections []float64 `json:"sections,omitempty"`
+	//Render images this many times larger and scale them down to smooth their edges (0 or 1 for none)
+	Supersample int `json:"supersample,omitempty"`
+}
+
+// DefaultView draws the vertices of the model from the front, 160 characters wide
+var DefaultView = View{
+	Projection: "front",
+	Width:      160,
+	Aspect:     2,
+	Mode:       "vertices",
+	Curvature:  "mean",
+	Light:      Vec3{-0.5, 0.5, 1},
+	Ambient:    0.15,
+	Palette:    "block",
+	Color:      "none",
+}
+
+// ViewPresets are named views for common uses, the base of the views read by ReadView
+var ViewPresets = map[string]View{
+	"terminal": DefaultView,
+	//A colored isometric render for the terminal
+	"shaded": DefaultView.with(func(v *View) { v.Projection, v.Mode, v.Color = "iso", "shaded", "auto" }),
+	//The four views of a drawing sheet, with their edges
+	"sheet": DefaultView.with(func(v *View) { v.Projection, v.Mode = "sheet", "wire" }),
+	//Small and large isometric images
+	"thumbnail": DefaultView.with(func(v *View) { v.Projection, v.Width, v.Aspect, v.Mode, v.Supersample = "iso", 256, 1, "shaded", 3 }),
+	"preview":   DefaultView.with(func(v *View) { v.Projection, v.Width, v.Aspect, v.Mode, v.Supersample = "iso", 1024, 1, "shaded", 2 }),
+	//What to look at before printing: overhangs and thin walls on an isometric image
+	"print": DefaultView.with(func(v *View) {
+		v.Projection, v.Width, v.Aspect, v.Mode, v.Overhang, v.ThinWall, v.Supersample = "iso", 512, 1, "shaded", 45, 0.8, 2
+	}),
+}
+
+// with returns a copy of the view changed by edit
+func (v View) with(edit func(v *View)) View {
+	edit(&v)
+	return v
+}
+
+// ViewPreset returns the preset of the name
+func ViewPreset(name string) (View, error) {
+	v, ok := ViewPresets[name]
+	if !ok {
+		names := make([]string, 0, len(ViewPresets))
+		for preset := range ViewPresets {
+			names = append(names, preset)
+		}
+		sort.Strings(names)
+		return v, fmt.Errorf("Unknown view preset %q, expected one of %v", name, names)
+	}
+	return v, nil
+}
+
+// ReadView reads a view from JSON. The object may name a "preset" to start from, DefaultView
+// otherwise, and only sets the fields that differ from it:
+//
+//	{"preset": "thumbnail", "camera": {"yaw": 30, "pitch": 20}, "width": 512}
+func ReadView(r io.Reader) (v View, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return v, err
+	}
+	var base struct {
+		Preset string `json:"preset"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return v, err
+	}
+	v = DefaultView
+	if base.Preset != "" {
+		if v, err = ViewPreset(base.Preset); err != nil {
+			return v, err
+		}
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, err
+	}
+	return v, v.Validate()
+}
+
+// WriteView writes the view as indented JSON that ReadView reads back
+func WriteView(w io.Writer, v View) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Perspective returns the perspective of the camera, or of the projection without one, nil being the
+// four view sheet
+func (v View) Perspective() (Perspective, error) {
+	if v.Camera != nil {
+		return *v.Camera, nil
+	}
+	return ParseProjection(v.Projection)
+}
+
+// ParseProjection returns the perspective of a named direction (front, side, top, iso or dimetric),
+// nil for the four view sheet
+func ParseProjection(name string) (Perspective, error) {
+	switch name {
+	case "front":
+		return ProjectFromFront, nil
+	case "side":
+		return ProjectFromSide, nil
+	case "top":
+		return ProjectFromTop, nil
+	case "iso":
+		return IsometricCamera, nil
+	case "dimetric":
+		return DimetricCamera, nil
+	case "sheet":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("Unknown view %q", name)
+}
+
+// Validate checks the names and the sizes of the view
+func (v View) Validate() error {
+	if _, err := v.Perspective(); err != nil {
+		return err
+	}
+	if v.Width < 1 || v.Height < 0 || v.Aspect <= 0 {
+		return errors.New("The width and the aspect must be positive and the height can't be negative")
+	}
+	if v.Frame != nil && (v.Frame.Margin < 0 || v.Frame.Margin >= 0.5 || v.Frame.Zoom < 0 || v.Frame.Scale < 0) {
+		return errors.New("The margin must be between 0 and 0.5 and the zoom and the scale can't be negative")
+	}
+	if v.Supersample < 0 || v.Supersample > MaxSupersample {
+		return fmt.Errorf("Supersample must be between 0 and %d", MaxSupersample)
+	}
+	switch v.Mode {
+	case "vertices", "depth", "wire", "shaded", "curvature":
+	default:
+		return fmt.Errorf("Unknown mode %q", v.Mode)
+	}
+	switch v.Curvature {
+	case "", "mean", "gaussian":
+	default:
+		return fmt.Errorf("Unknown curvature %q", v.Curvature)
+	}
+	if v.Palette != "" {
+		if _, err := ParsePalette(v.Palette); err != nil {
+			return err
+		}
+	}
+	switch v.Color {
+	case "", "none", "256", "true", "truecolor", "24bit", "auto":
+	default:
+		return fmt.Errorf("Unknown color mode %q", v.Color)
+	}
+	if v.ColorBy != "" {
+		if _, err := ParseColorBy(v.ColorBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package model
+
+// Viewport is the matrix a model is projected on
+type Viewport struct {
+	//Number of columns and rows of the matrix
+	Width, Height int
+	//Height of a cell relative to its width: about 2 for terminal characters and 1 for image pixels
+	//(0 is taken as 1)
+	CellAspect float32
+	//Where the model is placed in the matrix
+	Frame Frame
+}
+
+// Frame places the model in a Viewport. The zero Frame stretches the model to fill the matrix from its
+// bottom left corner; any other centers it, fitted inside the margin, before zooming and panning.
+type Frame struct {
+	//Space left empty on each side, as a fraction of the width and of the height (below 0.5)
+	Margin float32 `json:"margin,omitempty"`
+	//Magnification around the center of the model, where 0 is the same as 1. The parts going out of
+	//the matrix are cropped.
+	Zoom float32 `json:"zoom,omitempty"`
+	//Offset of the model to the right and up, as a fraction of the width and of the height
+	Pan Vec2 `json:"pan"`
+	//Model units per cell width instead of fitting the model, so models drawn with the same scale keep
+	//their relative sizes (0 fits the model). The zoom still applies and the margin doesn't.
+	Scale float32 `json:"scale,omitempty"`
+	//Fit the bounding sphere of the model instead of what the view shows of it, so the scale and the
+	//center don't change with the direction, like between the frames of a turntable
+	Steady bool `json:"steady,omitempty"`
+}
+
+// fills checks if the frame stretches the model to fill the matrix as the zero Frame does
+func (f Frame) fills() bool {
+	return f.Margin == 0 && (f.Zoom == 0 || f.Zoom == 1) && f.Pan == Vec2{} && f.Scale == 0 && !f.Steady
+}
+
+// bounds returns the mins and the maxs in view coordinates to fit for a model inside the box: the
+// ones given, or those of the bounding sphere of the box when the frame is steady
+func (f Frame) bounds(rotation Mat3, box Box, mins, maxs [3]float32) ([3]float32, [3]float32) {
+	if !f.Steady || box.Min[0] > box.Max[0] {
+		return mins, maxs
+	}
+	center, radius := rotation.MulVec(box.Center()), box.Size().Length()/2
+	return center.Sub(Vec3{radius, radius, radius}), center.Add(Vec3{radius, radius, radius})
+}
+
+// TerminalViewport returns the viewport used by ProjectModelVertices: size + 1 columns and half as
+// many rows, to compensate for terminal line height
+func TerminalViewport(size int) Viewport {
+	return Viewport{Width: size + 1, Height: size/2 + 1, CellAspect: 2}
+}
+
+// NewViewport returns a viewport width cells wide with cells of the given aspect and enough rows to
+// keep the proportions of a square area when height is 0
+func NewViewport(width, height int, cellAspect float32) Viewport {
+	v := Viewport{Width: width, Height: height, CellAspect: cellAspect}
+	if height <= 0 {
+		v.Height = int(float32(width-1)/v.aspect()) + 1
+	}
+	return v
+}
+
+func (v Viewport) aspect() float32 {
+	if v.CellAspect <= 0 {
+		return 1
+	}
+	return v.CellAspect
+}
+
+// FitScale returns the Frame scale fitting the largest of the models in the viewport from the
+// perspective, inside the margin of its frame and around their bounding spheres if it is steady.
+// Each model drawn with it keeps its size relative to the others.
+func FitScale(models []*Model, viewport Viewport, perspective Perspective) (scale float32) {
+	viewport.Frame.Scale, viewport.Frame.Zoom = 0, 1
+	for _, m := range models {
+		if len(m.Triangles) > 0 {
+			scale = max(scale, newProjection(m, viewport, perspective).scale)
+		}
+	}
+	return scale
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"errors"
+	"math"
+)
+
+// VoxelGrid is a solid made of the full cubes of a regular grid
+type VoxelGrid struct {
+	//Corner of the first voxel, the one with the lowest coordinates
+	Origin Vec3
+	//Side of the voxels
+	Size float32
+	//Number of voxels along each axis
+	Dimensions [3]int
+	full       []bool
+}
+
+// NewVoxelGrid returns an empty grid of the given number of voxels along each axis
+func NewVoxelGrid(origin Vec3, size float32, dimensions [3]int) *VoxelGrid {
+	return &VoxelGrid{Origin: origin, Size: size, Dimensions: dimensions, full: make([]bool, dimensions[0]*dimensions[1]*dimensions[2])}
+}
+
+// Voxelize fills the voxels whose center is inside the watertight model, on a grid starting a voxel
+// before its lowest corner and leaving at least an empty voxel on every side
+func Voxelize(m *Model, size float32) (*VoxelGrid, error) {
+	if size <= 0 {
+		return nil, errors.New("Voxel size must be positive")
+	}
+	if len(m.Triangles) == 0 {
+		return nil, errors.New("Model has no triangles")
+	}
+	if !NewTopology(m).IsWatertight() {
+		return nil, errors.New("Model must be watertight to be voxelized")
+	}
+	bvh := NewBVH(m)
+	bounds := bvh.Bounds()
+	extent := bounds.Size()
+	var dimensions [3]int
+	for k := range dimensions {
+		dimensions[k] = int(math.Ceil(float64(extent[k]/size))) + 2
+	}
+	g := NewVoxelGrid(bounds.Min.Sub(Vec3{size, size, size}), size, dimensions)
+	//Inside and outside are told by the parity of the crossings of one ray per row, cast slightly
+	//off the centers so they don't run along the faces of axis aligned models
+	skew := Vec3{0, 0.000137, 0.000291}.Scale(size)
+	inParallel(g.Dimensions[2], func(k int) {
+		for j := 0; j < g.Dimensions[1]; j++ {
+			hits := bvh.RaycastAll(Ray{Origin: g.Center(0, j, k).Add(skew), Direction: Vec3{1, 0, 0}})
+			crossed := 0
+			for i := 0; i < g.Dimensions[0]; i++ {
+				for crossed < len(hits) && hits[crossed].Distance < float32(i)*size {
+					crossed++
+				}
+				g.full[g.index(i, j, k)] = crossed%2 == 1
+			}
+		}
+	})
+	return g, nil
+}
+
+// index returns the position of the voxel i, j, k in full
+func (g *VoxelGrid) index(i, j, k int) int {
+	return (k*g.Dimensions[1]+j)*g.Dimensions[0] + i
+}
+
+// inside checks if i, j, k is a voxel of the grid
+func (g *VoxelGrid) inside(i, j, k int) bool {
+	return i >= 0 && j >= 0 && k >= 0 && i < g.Dimensions[0] && j < g.Dimensions[1] && k < g.Dimensions[2]
+}
+
+// Get tells if the voxel i, j, k is full, those out of the grid are empty
+func (g *VoxelGrid) Get(i, j, k int) bool {
+	return g.inside(i, j, k) && g.full[g.index(i, j, k)]
+}
+
+// Set fills or empties the voxel i, j, k, which must be in the grid
+func (g *VoxelGrid) Set(i, j, k int, full bool) {
+	g.full[g.index(i, j, k)] = full
+}
+
+// Center returns the position of the center of the voxel i, j, k
+func (g *VoxelGrid) Center(i, j, k int) Vec3 {
+	return g.Origin.Add(Vec3{float32(i) + 0.5, float32(j) + 0.5, float32(k) + 0.5}.Scale(g.Size))
+}
+
+// Locate returns the voxel containing the point, which may be out of the grid
+func (g *VoxelGrid) Locate(p Vec3) (i, j, k int) {
+	cell := p.Sub(g.Origin).Scale(1 / g.Size)
+	return int(math.Floor(float64(cell[0]))), int(math.Floor(float64(cell[1]))), int(math.Floor(float64(cell[2])))
+}
+
+// Sample tells if the point is in a full voxel
+func (g *VoxelGrid) Sample(p Vec3) bool {
+	return g.Get(g.Locate(p))
+}
+
+// Each calls visit with every full voxel, in order along X, then Y, then Z
+func (g *VoxelGrid) Each(visit func(i, j, k int)) {
+	for k := 0; k < g.Dimensions[2]; k++ {
+		for j := 0; j < g.Dimensions[1]; j++ {
+			for i := 0; i < g.Dimensions[0]; i++ {
+				if g.full[g.index(i, j, k)] {
+					visit(i, j, k)
+				}
+			}
+		}
+	}
+}
+
+// Count returns the number of full voxels
+func (g *VoxelGrid) Count() (count int) {
+	for _, full := range g.full {
+		if full {
+			count++
+		}
+	}
+	return count
+}
+
+// Volume returns the volume of the full voxels
+func (g *VoxelGrid) Volume() float32 {
+	return float32(g.Count()) * g.Size * g.Size * g.Size
+}
+
+// Model rebuilds the surface of the full voxels, halfway between the centers of the full and the
+// empty ones (marching tetrahedra), as a watertight model
+func (g *VoxelGrid) Model() *Model {
+	//One sample per voxel, with a row of empty ones around the grid so the surface is closed
+	samples := &distanceGrid{origin: g.Origin.Sub(Vec3{g.Size, g.Size, g.Size}.Scale(0.5)), cell: g.Size}
+	for k := range samples.size {
+		samples.size[k] = g.Dimensions[k] + 2
+	}
+	samples.values = make([]float32, samples.size[0]*samples.size[1]*samples.size[2])
+	for k := 0; k < samples.size[2]; k++ {
+		for j := 0; j < samples.size[1]; j++ {
+			for i := 0; i < samples.size[0]; i++ {
+				value := float32(1)
+				if g.Get(i-1, j-1, k-1) {
+					value = -1
+				}
+				samples.values[samples.index(i, j, k)] = value
+			}
+		}
+	}
+	triangles := samples.isosurface(0)
+	return &Model{Header: "Voxels", Triangles: triangles, NumTriangles: uint32(len(triangles))}
+}
@@ -0,0 +1,75 @@
+package model
+
+// ProjectModelEdges projects the model in the viewport from the chosen perspective, drawing the
+// edges of every triangle as lines instead of only marking the vertices
+func ProjectModelEdges(m *Model, viewport Viewport, perspective Perspective, opts RenderOptions) [][]float32 {
+	p := newProjection(m, viewport, perspective)
+	matrix := p.newMatrix()
+	for j := range m.Triangles {
+		if opts.CullBackFaces && !p.facesViewer(&m.Triangles[j]) {
+			continue
+		}
+		for k := range m.Triangles[j].Vertices {
+			p.drawLine(matrix, m.Triangles[j].Vertices[k], m.Triangles[j].Vertices[(k+1)%3])
+		}
+	}
+	return matrix
+}
+
+// drawLine marks the cells between two vertices (Bresenham) with the value interpolated along the line
+func (p projection) drawLine(matrix [][]float32, from, to [3]float32) {
+	row0, col0, value0 := p.cell(from)
+	row1, col1, value1 := p.cell(to)
+	bresenham(row0, col0, row1, col1, func(row, col int, t float32) {
+		if row < 0 || row >= len(matrix) || col < 0 || col >= len(matrix[row]) {
+			return
+		}
+		value := value0 + (value1-value0)*t
+		if value > matrix[row][col] {
+			matrix[row][col] = value
+		}
+	})
+}
+
+// bresenham calls plot for every cell of the line between two cells, with t going from 0 to 1 along it
+func bresenham(row0, col0, row1, col1 int, plot func(row, col int, t float32)) {
+	dRow, dCol := abs(row1-row0), -abs(col1-col0)
+	stepRow, stepCol := 1, 1
+	if row0 > row1 {
+		stepRow = -1
+	}
+	if col0 > col1 {
+		stepCol = -1
+	}
+	steps := dRow
+	if -dCol > steps {
+		steps = -dCol
+	}
+	err := dRow + dCol
+	for i := 0; ; i++ {
+		t := float32(1)
+		if steps > 0 {
+			t = float32(i) / float32(steps)
+		}
+		plot(row0, col0, t)
+		if row0 == row1 && col0 == col1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dCol {
+			err += dCol
+			row0 += stepRow
+		}
+		if e2 <= dRow {
+			err += dRow
+			col0 += stepCol
+		}
+	}
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
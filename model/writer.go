@@ -0,0 +1,183 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Size of the binary STL header
+const binaryHeaderSize = 80
+
+// WriteBinarySTL encodes the model as a binary STL. The header is cut to 80 bytes and the triangle
+// count is always the number of triangles in the slice.
+func WriteBinarySTL(w io.Writer, m *Model) error {
+	header := make([]byte, binaryHeaderSize+4)
+	copy(header[:binaryHeaderSize], m.Header)
+	binary.LittleEndian.PutUint32(header[binaryHeaderSize:], uint32(len(m.Triangles)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, m.Triangles)
+}
+
+// ASCIIOptions tunes how the numbers of the text formats are written, by WriteASCIISTLWith,
+// ASCIIEncoder and WriteOBJWith
+type ASCIIOptions struct {
+	//Digits of the numbers, significant ones in the g and e notations and after the decimal point in
+	//the f notation. As many as needed to read back exactly the same values if 0.
+	Precision int
+	//Notation of the numbers: 'e' always with an exponent, 'f' never, and 'g' (the default when 0)
+	//only for large and tiny numbers
+	Notation byte
+	//Drop the zeros ending the decimals, and the point when nothing is left after it, which a
+	//precision in the e and f notations pads the numbers with
+	TrimZeros bool
+}
+
+// check fails for an unknown notation
+func (opts ASCIIOptions) check() error {
+	switch opts.Notation {
+	case 0, 'g', 'e', 'f':
+		return nil
+	}
+	return fmt.Errorf("Unknown number notation %q", opts.Notation)
+}
+
+// appendFloat appends the number as the options say
+func (opts ASCIIOptions) appendFloat(b []byte, f float32) []byte {
+	notation, precision := opts.Notation, opts.Precision
+	if notation == 0 {
+		notation = 'g'
+	}
+	switch {
+	case precision <= 0:
+		precision = -1
+	case notation == 'e':
+		//strconv counts the digits after the point, not the one before it
+		precision--
+	}
+	start := len(b)
+	b = strconv.AppendFloat(b, float64(f), notation, precision, 32)
+	if !opts.TrimZeros || bytes.IndexByte(b[start:], '.') < 0 {
+		return b
+	}
+	//Keep the exponent apart while trimming the mantissa
+	end := len(b)
+	if e := bytes.IndexByte(b[start:], 'e'); e >= 0 {
+		end = start + e
+	}
+	exponent := string(b[end:])
+	mantissa := bytes.TrimRight(bytes.TrimRight(b[start:end], "0"), ".")
+	return append(b[:start+len(mantissa)], exponent...)
+}
+
+// appendCoordinates appends the three numbers separated by spaces as the options say
+func (opts ASCIIOptions) appendCoordinates(b []byte, v [3]float32) []byte {
+	for k, f := range v {
+		if k > 0 {
+			b = append(b, ' ')
+		}
+		b = opts.appendFloat(b, f)
+	}
+	return b
+}
+
+// WriteASCIISTL encodes the model as an ASCII STL, naming the solid with the Name of the model or
+// after the header when it has none
+func WriteASCIISTL(w io.Writer, m *Model) error {
+	return WriteASCIISTLWith(w, m, ASCIIOptions{})
+}
+
+// WriteASCIISTLWith encodes the model as an ASCII STL like WriteASCIISTL, with the numbers written as
+// the options say
+func WriteASCIISTLWith(w io.Writer, m *Model, opts ASCIIOptions) error {
+	e := NewASCIIEncoder(w, solidName(m), opts)
+	for i := range m.Triangles {
+		e.Encode(&m.Triangles[i])
+	}
+	return e.Close()
+}
+
+// WriteAllASCIISTL encodes the models as the solids of a single ASCII STL, one after the other, named
+// like WriteASCIISTL does
+func WriteAllASCIISTL(w io.Writer, models []Model) error {
+	buffered := bufio.NewWriter(w)
+	for i := range models {
+		e := NewASCIIEncoder(buffered, solidName(&models[i]), ASCIIOptions{})
+		for j := range models[i].Triangles {
+			e.Encode(&models[i].Triangles[j])
+		}
+		if err := e.Close(); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+// ASCIIEncoder writes an ASCII STL one facet at a time, so models too large to be held in memory can
+// be written as they are made or read from elsewhere. Only a small buffer is kept.
+type ASCIIEncoder struct {
+	buffered *bufio.Writer
+	name     string
+	opts     ASCIIOptions
+	//Text of the facet being written, reused for every facet
+	line []byte
+	err  error
+}
+
+// NewASCIIEncoder starts a solid of the given name, made single line, on w. Close must be called once
+// every facet is encoded.
+func NewASCIIEncoder(w io.Writer, name string, opts ASCIIOptions) *ASCIIEncoder {
+	e := &ASCIIEncoder{buffered: bufio.NewWriter(w), name: strings.Join(strings.Fields(name), " "), opts: opts}
+	if e.err = opts.check(); e.err != nil {
+		return e
+	}
+	_, e.err = e.buffered.WriteString("solid " + e.name + "\n")
+	return e
+}
+
+// Encode writes a facet, returning the first error met by the encoder
+func (e *ASCIIEncoder) Encode(t *Triangle) error {
+	if e.err != nil {
+		return e.err
+	}
+	e.line = append(e.line[:0], "  facet normal "...)
+	e.line = e.opts.appendCoordinates(e.line, t.Normal)
+	e.line = append(e.line, "\n    outer loop\n"...)
+	for _, v := range t.Vertices {
+		e.line = append(e.line, "      vertex "...)
+		e.line = append(e.opts.appendCoordinates(e.line, v), '\n')
+	}
+	e.line = append(e.line, "    endloop\n  endfacet\n"...)
+	_, e.err = e.buffered.Write(e.line)
+	return e.err
+}
+
+// Close ends the solid and flushes what is buffered, without closing the writer
+func (e *ASCIIEncoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if _, e.err = e.buffered.WriteString("endsolid " + e.name + "\n"); e.err == nil {
+		e.err = e.buffered.Flush()
+	}
+	return e.err
+}
+
+// solidName turns the name of the model, or its header, into a single line name for an ASCII STL
+func solidName(m *Model) string {
+	if m.Name != "" {
+		return strings.Join(strings.Fields(m.Name), " ")
+	}
+	return strings.Join(strings.Fields(m.Header), " ")
+}
+
+// formatFloat prints the shortest text that reads back to exactly the same number
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Grow or shrink the surface of a model
+func offsetCommand(args []string) {
+	flags := flag.NewFlagSet("offset", flag.ExitOnError)
+	output := flags.String("o", "", "Write the offset model to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	distance := flags.Float64("d", 0, "Distance the surface moves outwards (negative to shrink it)")
+	method := flags.String("method", "auto", "How the surface is moved (auto|normals|field)")
+	cell := flags.Float64("cell", 0, "Size of the cells of the distance field (0 for half the distance)")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii offset [flags] pathtofile -d distance -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *output == "" || *cell < 0 {
+		flags.Usage()
+	}
+	opts := model.OffsetOptions{Distance: float32(*distance), CellSize: float32(*cell)}
+	switch *method {
+	case "auto":
+		opts.Method = model.OffsetAuto
+	case "normals":
+		opts.Method = model.OffsetVertexNormals
+	case "field":
+		opts.Method = model.OffsetDistanceField
+	default:
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	check(model.OffsetWith(&aModel, opts))
+	check(saveModel(*output, "", &aModel, *ascii))
+}
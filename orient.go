@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Find the best way of placing a model on the build plate
+func orientCommand(args []string) {
+	flags := flag.NewFlagSet("orient", flag.ExitOnError)
+	samples := flags.Int("samples", 100, "Directions tried as the build direction, besides the axes and the largest flat faces")
+	angle := flags.Float64("angle", model.DefaultOverhangAngle, "Largest overhang angle from the vertical printed without supports")
+	top := flags.Int("top", 1, "Print this many of the best orientations")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	output := flags.String("o", "", "Write the model rotated to the best orientation to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii orient [flags] pathtofile")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *samples <= 0 || *angle <= 0 || *angle >= 90 || *top <= 0 {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	report := model.OptimizeOrientation(&aModel, model.OrientationOptions{Samples: *samples, OverhangAngle: *angle})
+	if *top < len(report.Candidates) {
+		report.Candidates = report.Candidates[:*top]
+	}
+	if *asJSON {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		check(err)
+		fmt.Println(string(encoded))
+	} else {
+		for i, candidate := range report.Candidates {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(candidate)
+		}
+	}
+	if *output != "" {
+		model.Transform(&aModel, model.Affine{Linear: report.Best.Rotation})
+		check(saveModel(*output, "", &aModel, *ascii))
+	}
+}
@@ -0,0 +1,160 @@
+package primitives
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// ReadDXF reads the closed outlines of the entities of an ASCII DXF file as polygons for Extrude,
+// ExtrudeProfile or Revolve: the lightweight and the old style polylines, with their bulges turned
+// into arcs staying within tolerance of them, and the circles. Polylines are closed by a line back to
+// their start even without their closed flag. Lines, splines, blocks and the other entities are
+// ignored, as are the Z coordinates.
+func ReadDXF(r io.Reader, tolerance float64) (polygons [][]model.Vec2, err error) {
+	if tolerance <= 0 {
+		return nil, errors.New("Tolerance must be positive")
+	}
+	pairs, err := readDXFPairs(r)
+	if err != nil {
+		return nil, err
+	}
+	//The entity being read, its vertices with their bulges and the values of its other codes
+	var entity string
+	var vertices []dxfVertex
+	values := make(map[int]float64)
+	inEntities, inPolyline := false, false
+	finish := func() {
+		var polygon []model.Vec2
+		switch entity {
+		case "LWPOLYLINE":
+			polygon = dxfOutline(vertices, tolerance)
+		case "CIRCLE":
+			polygon = ellipse(values[10], values[20], values[40], values[40], tolerance)
+		}
+		if polygon = model.CleanPolygon(polygon); len(polygon) >= 3 {
+			polygons = append(polygons, polygon)
+		}
+	}
+	for _, pair := range pairs {
+		if pair.code == 0 {
+			//A new entity ends the previous one, an old style polyline only ends at its SEQEND
+			switch {
+			case inPolyline && pair.value == "VERTEX":
+				vertices = append(vertices, dxfVertex{})
+				entity = "VERTEX"
+				continue
+			case inPolyline:
+				entity = "LWPOLYLINE"
+				inPolyline = false
+			}
+			if inEntities {
+				finish()
+			}
+			entity, vertices = pair.value, nil
+			clear(values)
+			switch pair.value {
+			case "ENDSEC":
+				inEntities = false
+			case "POLYLINE":
+				inPolyline = inEntities
+			}
+			continue
+		}
+		if entity == "SECTION" && pair.code == 2 {
+			inEntities = pair.value == "ENTITIES"
+			continue
+		}
+		if !inEntities {
+			continue
+		}
+		value, err := strconv.ParseFloat(pair.value, 64)
+		if err != nil && (pair.code == 10 || pair.code == 20 || pair.code == 40 || pair.code == 42) {
+			return nil, fmt.Errorf("DXF %v: invalid number %q for code %d", entity, pair.value, pair.code)
+		}
+		switch {
+		case entity == "LWPOLYLINE" && pair.code == 10:
+			//Every vertex of a lightweight polyline starts with its X
+			vertices = append(vertices, dxfVertex{x: value})
+		case (entity == "LWPOLYLINE" || entity == "VERTEX") && len(vertices) > 0 && pair.code == 20:
+			vertices[len(vertices)-1].y = value
+		case (entity == "LWPOLYLINE" || entity == "VERTEX") && len(vertices) > 0 && pair.code == 42:
+			vertices[len(vertices)-1].bulge = value
+		case entity == "VERTEX" && len(vertices) > 0 && pair.code == 10:
+			vertices[len(vertices)-1].x = value
+		default:
+			values[pair.code] = value
+		}
+	}
+	if len(polygons) == 0 {
+		return nil, errors.New("No closed shapes found in the DXF file")
+	}
+	return polygons, nil
+}
+
+// dxfPair is a group of a DXF file, a code telling what the value is
+type dxfPair struct {
+	code  int
+	value string
+}
+
+// dxfVertex is a vertex of a polyline, the bulge being the tangent of a quarter of the angle of the
+// arc going to the next vertex, positive when counter-clockwise and 0 for a straight line
+type dxfVertex struct {
+	x, y, bulge float64
+}
+
+// readDXFPairs reads the groups of an ASCII DXF file, each a code line followed by a value line
+func readDXFPairs(r io.Reader) (pairs []dxfPair, err error) {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line += 2 {
+		code, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid DXF group code on line %d", line)
+		}
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("Missing DXF value on line %d", line+1)
+		}
+		pairs = append(pairs, dxfPair{code, strings.TrimSpace(scanner.Text())})
+	}
+	return pairs, scanner.Err()
+}
+
+// dxfOutline returns the outline of a closed polyline, its bulges flattened to arcs
+func dxfOutline(vertices []dxfVertex, tolerance float64) (polygon []model.Vec2) {
+	for i, v := range vertices {
+		polygon = append(polygon, model.Vec2{float32(v.x), float32(v.y)})
+		next := vertices[(i+1)%len(vertices)]
+		if v.bulge == 0 {
+			continue
+		}
+		//The arc through both ends sweeping four times the angle of the bulge
+		chord := math.Hypot(next.x-v.x, next.y-v.y)
+		if chord == 0 {
+			continue
+		}
+		sweep := 4 * math.Atan(v.bulge)
+		radius := chord / (2 * math.Sin(math.Abs(sweep)/2))
+		//The center is on the bisector of the chord, to the left of it for counter-clockwise arcs
+		mx, my := (v.x+next.x)/2, (v.y+next.y)/2
+		offset := radius * math.Cos(sweep/2)
+		if sweep < 0 {
+			offset = -offset
+		}
+		nx, ny := -(next.y-v.y)/chord, (next.x-v.x)/chord
+		cx, cy := mx+nx*offset, my+ny*offset
+		start := math.Atan2(v.y-cy, v.x-cx)
+		segments := arcSegments(radius, sweep, tolerance)
+		for s := 1; s < segments; s++ {
+			angle := start + sweep*float64(s)/float64(segments)
+			polygon = append(polygon, model.Vec2{float32(cx + radius*math.Cos(angle)), float32(cy + radius*math.Sin(angle))})
+		}
+	}
+	return polygon
+}
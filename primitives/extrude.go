@@ -0,0 +1,132 @@
+package primitives
+
+import (
+	"errors"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Extrude turns a simple polygon on the XY plane into a solid going from Z 0 to height.
+// The polygon can be in either orientation and doesn't need to be closed.
+func Extrude(polygon []model.Vec2, height float64) (m model.Model, err error) {
+	if height <= 0 {
+		return m, errors.New("Height must be positive")
+	}
+	polygon = model.CleanPolygon(polygon)
+	//Triangulate the caps (the triangles are counter-clockwise seen from the top)
+	caps, err := model.Triangulate(polygon)
+	if err != nil {
+		return m, err
+	}
+	if len(caps) == 0 {
+		return m, errors.New("Polygon has no area")
+	}
+	m.Header = header("extrusion")
+	bottom := func(i int) model.Vec3 { return model.Vec3{polygon[i][0], polygon[i][1], 0} }
+	top := func(i int) model.Vec3 { return model.Vec3{polygon[i][0], polygon[i][1], float32(height)} }
+	for _, t := range caps {
+		addTriangle(&m, top(t[0]), top(t[1]), top(t[2]))
+		addTriangle(&m, bottom(t[0]), bottom(t[2]), bottom(t[1]))
+	}
+	//Side walls, following the polygon counter-clockwise so they face outwards
+	ccw := model.PolygonArea(polygon) > 0
+	for i := range polygon {
+		a, b := i, (i+1)%len(polygon)
+		if !ccw {
+			a, b = b, a
+		}
+		addQuad(&m, bottom(a), bottom(b), top(b), top(a))
+	}
+	return m, nil
+}
+
+// ExtrudeProfile turns closed polygons on the XY plane, such as those read by ReadSVG or ReadDXF, into
+// a solid going from Z 0 to height. Polygons inside an odd number of others are holes, so a gasket is
+// an outline around its holes and a logo may have islands in its holes. The polygons can be in either
+// orientation but must not cross each other.
+func ExtrudeProfile(polygons [][]model.Vec2, height float64) (m model.Model, err error) {
+	if height <= 0 {
+		return m, errors.New("Height must be positive")
+	}
+	var cleaned [][]model.Vec2
+	for _, polygon := range polygons {
+		if polygon = model.CleanPolygon(polygon); len(polygon) >= 3 && model.PolygonArea(polygon) != 0 {
+			cleaned = append(cleaned, polygon)
+		}
+	}
+	if len(cleaned) == 0 {
+		return m, errors.New("Profile has no area")
+	}
+	//Each polygon is nested in those containing its first point, the smallest of them being its parent
+	depth := make([]int, len(cleaned))
+	parent := make([]int, len(cleaned))
+	for i, polygon := range cleaned {
+		parent[i] = -1
+		for j, other := range cleaned {
+			if i == j || !insidePolygon(polygon[0], other) {
+				continue
+			}
+			depth[i]++
+			if parent[i] < 0 || abs32(model.PolygonArea(other)) < abs32(model.PolygonArea(cleaned[parent[i]])) {
+				parent[i] = j
+			}
+		}
+	}
+	holes := make(map[int][][]model.Vec2)
+	for i, polygon := range cleaned {
+		if depth[i]%2 == 1 {
+			holes[parent[i]] = append(holes[parent[i]], polygon)
+		}
+	}
+	m.Header = header("extrusion")
+	for i, outer := range cleaned {
+		if depth[i]%2 == 1 {
+			continue
+		}
+		caps, err := model.TriangulateWithHoles(outer, holes[i])
+		if err != nil {
+			return m, err
+		}
+		//The caps index the points of the outer polygon followed by those of its holes
+		points := append([]model.Vec2(nil), outer...)
+		for _, hole := range holes[i] {
+			points = append(points, hole...)
+		}
+		bottom := func(p model.Vec2) model.Vec3 { return model.Vec3{p[0], p[1], 0} }
+		top := func(p model.Vec2) model.Vec3 { return model.Vec3{p[0], p[1], float32(height)} }
+		for _, t := range caps {
+			addTriangle(&m, top(points[t[0]]), top(points[t[1]]), top(points[t[2]]))
+			addTriangle(&m, bottom(points[t[0]]), bottom(points[t[2]]), bottom(points[t[1]]))
+		}
+		//Walls go counter-clockwise around the outer polygon and clockwise around the holes
+		for h, loop := range append([][]model.Vec2{outer}, holes[i]...) {
+			reverse := (model.PolygonArea(loop) > 0) != (h == 0)
+			for j := range loop {
+				a, b := loop[j], loop[(j+1)%len(loop)]
+				if reverse {
+					a, b = b, a
+				}
+				addQuad(&m, bottom(a), bottom(b), top(b), top(a))
+			}
+		}
+	}
+	return m, nil
+}
+
+// insidePolygon checks if p is inside the polygon by the parity of the edges crossed going right
+func insidePolygon(p model.Vec2, polygon []model.Vec2) (inside bool) {
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < a[0]+(p[1]-a[1])/(b[1]-a[1])*(b[0]-a[0]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func abs32(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,238 @@
+// Package primitives generates closed solids as models. Basic shapes are centered on the origin.
+package primitives
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Box creates a box with the given size along each axis
+func Box(size model.Vec3) (m model.Model, err error) {
+	if size[0] <= 0 || size[1] <= 0 || size[2] <= 0 {
+		return m, errors.New("Box size must be positive")
+	}
+	m.Header = header("box")
+	//Corners are indexed by bits: x is bit 0, y is bit 1 and z is bit 2
+	var corners [8]model.Vec3
+	for i := range corners {
+		for axis := range size {
+			corners[i][axis] = size[axis] / 2
+			if i&(1<<uint(axis)) == 0 {
+				corners[i][axis] = -corners[i][axis]
+			}
+		}
+	}
+	//Faces counter-clockwise when seen from outside: -Z, +Z, -Y, +Y, -X, +X
+	faces := [6][4]int{{0, 2, 3, 1}, {4, 5, 7, 6}, {0, 1, 5, 4}, {2, 6, 7, 3}, {0, 4, 6, 2}, {1, 3, 7, 5}}
+	for _, f := range faces {
+		addQuad(&m, corners[f[0]], corners[f[1]], corners[f[2]], corners[f[3]])
+	}
+	return m, nil
+}
+
+// UVSphere creates a sphere made of rings of latitude and segments of longitude
+func UVSphere(radius float64, segments, rings int) (m model.Model, err error) {
+	if radius <= 0 {
+		return m, errors.New("Sphere radius must be positive")
+	}
+	if segments < 3 || rings < 2 {
+		return m, errors.New("Sphere needs at least 3 segments and 2 rings")
+	}
+	m.Header = header("uv sphere")
+	point := func(ring, segment int) model.Vec3 {
+		//Snap the poles so every segment shares exactly the same vertex there
+		if ring == 0 {
+			return vec(0, 0, radius)
+		}
+		if ring == rings {
+			return vec(0, 0, -radius)
+		}
+		theta := math.Pi * float64(ring) / float64(rings)
+		phi := 2 * math.Pi * float64(segment%segments) / float64(segments)
+		return vec(radius*math.Sin(theta)*math.Cos(phi), radius*math.Sin(theta)*math.Sin(phi), radius*math.Cos(theta))
+	}
+	for i := 0; i < rings; i++ {
+		for j := 0; j < segments; j++ {
+			a, b, c, d := point(i, j), point(i+1, j), point(i+1, j+1), point(i, j+1)
+			//The first and last rings meet at the poles so they only need one triangle per segment
+			if i != rings-1 {
+				addTriangle(&m, a, b, c)
+			}
+			if i != 0 {
+				addTriangle(&m, a, c, d)
+			}
+		}
+	}
+	return m, nil
+}
+
+// Icosphere creates a sphere by subdividing an icosahedron, giving evenly sized triangles
+func Icosphere(radius float64, subdivisions int) (m model.Model, err error) {
+	if radius <= 0 {
+		return m, errors.New("Sphere radius must be positive")
+	}
+	if subdivisions < 0 {
+		return m, errors.New("Subdivisions can't be negative")
+	}
+	m.Header = header("icosphere")
+	//Start from the unit icosahedron
+	t := (1 + math.Sqrt(5)) / 2
+	vertices := []model.Vec3{
+		vec(-1, t, 0), vec(1, t, 0), vec(-1, -t, 0), vec(1, -t, 0),
+		vec(0, -1, t), vec(0, 1, t), vec(0, -1, -t), vec(0, 1, -t),
+		vec(t, 0, -1), vec(t, 0, 1), vec(-t, 0, -1), vec(-t, 0, 1),
+	}
+	for i := range vertices {
+		vertices[i] = vertices[i].Normalize()
+	}
+	faces := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+	//Split every triangle in four, sharing the midpoints between neighbours
+	for s := 0; s < subdivisions; s++ {
+		midpoints := make(map[[2]int]int)
+		midpoint := func(a, b int) int {
+			key := [2]int{a, b}
+			if a > b {
+				key = [2]int{b, a}
+			}
+			if index, found := midpoints[key]; found {
+				return index
+			}
+			vertices = append(vertices, vertices[a].Add(vertices[b]).Normalize())
+			midpoints[key] = len(vertices) - 1
+			return len(vertices) - 1
+		}
+		subdivided := make([][3]int, 0, 4*len(faces))
+		for _, f := range faces {
+			ab, bc, ca := midpoint(f[0], f[1]), midpoint(f[1], f[2]), midpoint(f[2], f[0])
+			subdivided = append(subdivided, [3]int{f[0], ab, ca}, [3]int{f[1], bc, ab}, [3]int{f[2], ca, bc}, [3]int{ab, bc, ca})
+		}
+		faces = subdivided
+	}
+	for _, f := range faces {
+		addTriangle(&m, vertices[f[0]].Scale(float32(radius)), vertices[f[1]].Scale(float32(radius)), vertices[f[2]].Scale(float32(radius)))
+	}
+	return m, nil
+}
+
+// Cylinder creates a cylinder along the Z axis
+func Cylinder(radius, height float64, segments int) (m model.Model, err error) {
+	if radius <= 0 {
+		return m, errors.New("Cylinder radius must be positive")
+	}
+	m, err = frustum(radius, radius, height, segments)
+	if err != nil {
+		return m, err
+	}
+	m.Header = header("cylinder")
+	return m, nil
+}
+
+// Cone creates a cone along the Z axis with the tip at the top
+func Cone(radius, height float64, segments int) (m model.Model, err error) {
+	if radius <= 0 {
+		return m, errors.New("Cone radius must be positive")
+	}
+	m, err = frustum(radius, 0, height, segments)
+	if err != nil {
+		return m, err
+	}
+	m.Header = header("cone")
+	return m, nil
+}
+
+// Frustum creates a truncated cone along the Z axis with different bottom and top radii
+func Frustum(bottomRadius, topRadius, height float64, segments int) (m model.Model, err error) {
+	if bottomRadius < 0 || topRadius < 0 || bottomRadius+topRadius == 0 {
+		return m, errors.New("Frustum radii can't be negative and at least one must be positive")
+	}
+	m, err = frustum(bottomRadius, topRadius, height, segments)
+	if err != nil {
+		return m, err
+	}
+	m.Header = header("frustum")
+	return m, nil
+}
+
+func frustum(bottomRadius, topRadius, height float64, segments int) (m model.Model, err error) {
+	if height <= 0 {
+		return m, errors.New("Height must be positive")
+	}
+	if segments < 3 {
+		return m, errors.New("At least 3 segments are needed")
+	}
+	point := func(radius, z float64, segment int) model.Vec3 {
+		angle := 2 * math.Pi * float64(segment%segments) / float64(segments)
+		return vec(radius*math.Cos(angle), radius*math.Sin(angle), z)
+	}
+	bottomCenter, topCenter := vec(0, 0, -height/2), vec(0, 0, height/2)
+	for j := 0; j < segments; j++ {
+		a, b := point(bottomRadius, -height/2, j), point(bottomRadius, -height/2, j+1)
+		c, d := point(topRadius, height/2, j+1), point(topRadius, height/2, j)
+		//Side wall, with a single triangle when one of the ends is a tip
+		if topRadius > 0 {
+			addTriangle(&m, a, c, d)
+		}
+		if bottomRadius > 0 {
+			addTriangle(&m, a, b, c)
+			addTriangle(&m, bottomCenter, b, a)
+		}
+		if topRadius > 0 {
+			addTriangle(&m, topCenter, d, c)
+		}
+	}
+	return m, nil
+}
+
+// Torus creates a ring around the Z axis, majorRadius being the distance from the center to the tube axis
+func Torus(majorRadius, minorRadius float64, majorSegments, minorSegments int) (m model.Model, err error) {
+	if minorRadius <= 0 || majorRadius <= minorRadius {
+		return m, errors.New("Torus radii must be positive with the major radius larger than the minor one")
+	}
+	if majorSegments < 3 || minorSegments < 3 {
+		return m, errors.New("Torus needs at least 3 segments in each direction")
+	}
+	m.Header = header("torus")
+	point := func(i, j int) model.Vec3 {
+		u := 2 * math.Pi * float64(i%majorSegments) / float64(majorSegments)
+		v := 2 * math.Pi * float64(j%minorSegments) / float64(minorSegments)
+		ring := majorRadius + minorRadius*math.Cos(v)
+		return vec(ring*math.Cos(u), ring*math.Sin(u), minorRadius*math.Sin(v))
+	}
+	for i := 0; i < majorSegments; i++ {
+		for j := 0; j < minorSegments; j++ {
+			addQuad(&m, point(i, j), point(i+1, j), point(i+1, j+1), point(i, j+1))
+		}
+	}
+	return m, nil
+}
+
+// header returns the header for a generated model
+func header(name string) string {
+	return fmt.Sprintf("Generated by stl2ascii primitives - %v", name)
+}
+
+// vec builds a model.Vec3 from float64 coordinates
+func vec(x, y, z float64) model.Vec3 {
+	return model.Vec3{float32(x), float32(y), float32(z)}
+}
+
+// addTriangle appends the triangle abc (counter-clockwise seen from outside) with its normal
+func addTriangle(m *model.Model, a, b, c model.Vec3) {
+	normal := b.Sub(a).Cross(c.Sub(a)).Normalize()
+	m.Triangles = append(m.Triangles, model.Triangle{Normal: normal, Vertices: [3][3]float32{a, b, c}})
+	m.NumTriangles++
+}
+
+// addQuad appends the quad abcd as two triangles
+func addQuad(m *model.Model, a, b, c, d model.Vec3) {
+	addTriangle(m, a, b, c)
+	addTriangle(m, a, c, d)
+}
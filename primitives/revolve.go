@@ -0,0 +1,87 @@
+package primitives
+
+import (
+	"errors"
+	"math"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Revolve sweeps a profile around the Z axis to build a lathe solid. Each profile point is
+// (distance to the axis, height) and the profile is closed implicitly, so a vase outline going from
+// the axis to the axis only needs its outer side. The angle is in radians, 2π makes a full turn
+// and anything less leaves the two ends capped.
+func Revolve(profile []model.Vec2, segments int, angle float64) (m model.Model, err error) {
+	if segments < 3 {
+		return m, errors.New("At least 3 segments are needed")
+	}
+	if angle <= 0 || angle > 2*math.Pi+1e-9 {
+		return m, errors.New("Angle must be in (0, 2π]")
+	}
+	profile = model.CleanPolygon(profile)
+	if len(profile) < 2 {
+		return m, errors.New("Profile needs at least 2 points")
+	}
+	for _, p := range profile {
+		if p[0] < 0 {
+			return m, errors.New("Profile can't cross the axis")
+		}
+	}
+	area := model.PolygonArea(profile)
+	if area == 0 {
+		return m, errors.New("Profile has no area")
+	}
+	m.Header = header("revolution")
+	full := angle >= 2*math.Pi-1e-9
+	point := func(p model.Vec2, segment int) model.Vec3 {
+		if full {
+			segment %= segments
+		}
+		theta := angle * float64(segment) / float64(segments)
+		return model.Vec3{p[0] * float32(math.Cos(theta)), p[0] * float32(math.Sin(theta)), p[1]}
+	}
+	for i := range profile {
+		p, q := profile[i], profile[(i+1)%len(profile)]
+		if p[0] == 0 && q[0] == 0 {
+			//Edges along the axis don't sweep any surface
+			continue
+		}
+		for j := 0; j < segments; j++ {
+			a, b, c, d := point(p, j), point(q, j), point(q, j+1), point(p, j+1)
+			//Keep the outside of the profile facing outwards, a counter-clockwise profile has it on the right
+			if area > 0 {
+				b, d = d, b
+			}
+			//Points on the axis collapse the quad into a triangle
+			switch {
+			case a == b || b == c:
+				addTriangle(&m, a, c, d)
+			case c == d || d == a:
+				addTriangle(&m, a, b, c)
+			default:
+				addQuad(&m, a, b, c, d)
+			}
+		}
+	}
+	if !full {
+		err = capRevolution(&m, profile, func(p model.Vec2) model.Vec3 { return point(p, 0) }, func(p model.Vec2) model.Vec3 { return point(p, segments) })
+		if err != nil {
+			return model.Model{}, err
+		}
+	}
+	return m, nil
+}
+
+// capRevolution closes both ends of a partial revolution with the triangulated profile
+func capRevolution(m *model.Model, profile []model.Vec2, start, end func(model.Vec2) model.Vec3) error {
+	triangles, err := model.Triangulate(profile)
+	if err != nil {
+		return err
+	}
+	//Counter-clockwise triangles in the profile plane face backwards from the sweep, so they close the start
+	for _, t := range triangles {
+		addTriangle(m, start(profile[t[0]]), start(profile[t[1]]), start(profile[t[2]]))
+		addTriangle(m, end(profile[t[0]]), end(profile[t[2]]), end(profile[t[1]]))
+	}
+	return nil
+}
@@ -0,0 +1,492 @@
+package primitives
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Most segments a curve is flattened into
+const maxCurveSegments = 256
+
+// ReadSVG reads the outlines of the shapes of an SVG file as closed polygons for Extrude,
+// ExtrudeProfile or Revolve: the paths, with their lines, curves and arcs flattened to stay within
+// tolerance of them, and the polygons, polylines, rectangles, circles and ellipses. Every subpath is
+// closed, open ones by a line back to their start, and the transforms of the elements and the groups
+// around them are applied. The Y axis is flipped, so the drawing stays upright with Y going up.
+// Styles, text, clipping and the units of the document are ignored, the user units are taken as
+// model units.
+func ReadSVG(r io.Reader, tolerance float64) (polygons [][]model.Vec2, err error) {
+	if tolerance <= 0 {
+		return nil, errors.New("Tolerance must be positive")
+	}
+	decoder := xml.NewDecoder(r)
+	//Transforms of the open elements, the last one being the current one
+	stack := []svgMatrix{{1, 0, 0, -1, 0, 0}}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			attributes := make(map[string]string)
+			for _, a := range element.Attr {
+				attributes[a.Name.Local] = a.Value
+			}
+			transform, err := parseSVGTransform(attributes["transform"])
+			if err != nil {
+				return nil, err
+			}
+			current := stack[len(stack)-1].then(transform)
+			stack = append(stack, current)
+			shapes, err := svgShapes(element.Name.Local, attributes, tolerance)
+			if err != nil {
+				return nil, fmt.Errorf("SVG %v: %v", element.Name.Local, err)
+			}
+			for _, shape := range shapes {
+				shape = model.CleanPolygon(shape)
+				if len(shape) < 3 {
+					continue
+				}
+				for i := range shape {
+					shape[i] = current.apply(shape[i])
+				}
+				polygons = append(polygons, shape)
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(polygons) == 0 {
+		return nil, errors.New("No shapes found in the SVG file")
+	}
+	return polygons, nil
+}
+
+// svgShapes returns the outlines of an element, before its transform, nothing for the elements
+// that aren't shapes
+func svgShapes(name string, attributes map[string]string, tolerance float64) ([][]model.Vec2, error) {
+	number := func(attribute string) float64 {
+		value, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(attributes[attribute]), "px"), 64)
+		return value
+	}
+	switch name {
+	case "path":
+		return parseSVGPath(attributes["d"], tolerance)
+	case "polygon", "polyline":
+		numbers, err := svgNumbers(attributes["points"])
+		if err != nil {
+			return nil, err
+		}
+		var polygon []model.Vec2
+		for i := 0; i+1 < len(numbers); i += 2 {
+			polygon = append(polygon, model.Vec2{float32(numbers[i]), float32(numbers[i+1])})
+		}
+		return [][]model.Vec2{polygon}, nil
+	case "rect":
+		x, y, width, height := number("x"), number("y"), number("width"), number("height")
+		if width <= 0 || height <= 0 {
+			return nil, nil
+		}
+		return [][]model.Vec2{{{float32(x), float32(y)}, {float32(x + width), float32(y)}, {float32(x + width), float32(y + height)}, {float32(x), float32(y + height)}}}, nil
+	case "circle":
+		r := number("r")
+		return [][]model.Vec2{ellipse(number("cx"), number("cy"), r, r, tolerance)}, nil
+	case "ellipse":
+		return [][]model.Vec2{ellipse(number("cx"), number("cy"), number("rx"), number("ry"), tolerance)}, nil
+	}
+	return nil, nil
+}
+
+// ellipse returns the outline of an ellipse, staying within tolerance of it
+func ellipse(cx, cy, rx, ry, tolerance float64) (polygon []model.Vec2) {
+	if rx <= 0 || ry <= 0 {
+		return nil
+	}
+	segments := arcSegments(math.Max(rx, ry), 2*math.Pi, tolerance)
+	for i := 0; i < segments; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(segments)
+		polygon = append(polygon, model.Vec2{float32(cx + rx*math.Cos(angle)), float32(cy + ry*math.Sin(angle))})
+	}
+	return polygon
+}
+
+// arcSegments returns the number of chords keeping an arc of the radius and angle within tolerance
+func arcSegments(radius, angle, tolerance float64) int {
+	step := math.Pi / 2
+	if tolerance < radius {
+		step = 2 * math.Acos(1-tolerance/radius)
+	}
+	return min(max(int(math.Ceil(math.Abs(angle)/step)), 3), maxCurveSegments)
+}
+
+// svgMatrix is an affine transformation of the plane, mapping x, y to a*x + c*y + e, b*x + d*y + f
+type svgMatrix [6]float64
+
+// then returns the transformation applying m after the inner one
+func (m svgMatrix) then(inner svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*inner[0] + m[2]*inner[1], m[1]*inner[0] + m[3]*inner[1],
+		m[0]*inner[2] + m[2]*inner[3], m[1]*inner[2] + m[3]*inner[3],
+		m[0]*inner[4] + m[2]*inner[5] + m[4], m[1]*inner[4] + m[3]*inner[5] + m[5],
+	}
+}
+
+// apply transforms the point
+func (m svgMatrix) apply(p model.Vec2) model.Vec2 {
+	x, y := float64(p[0]), float64(p[1])
+	return model.Vec2{float32(m[0]*x + m[2]*y + m[4]), float32(m[1]*x + m[3]*y + m[5])}
+}
+
+// parseSVGTransform reads a transform attribute, a list of matrix, translate, scale, rotate, skewX
+// and skewY applied from the last one to the first
+func parseSVGTransform(s string) (svgMatrix, error) {
+	result := svgMatrix{1, 0, 0, 1, 0, 0}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, " \t\r\n,") {
+		open, close := strings.IndexByte(s, '('), strings.IndexByte(s, ')')
+		if open < 0 || close < open {
+			return result, fmt.Errorf("Invalid transform %q", s)
+		}
+		name := strings.TrimSpace(s[:open])
+		args, err := svgNumbers(s[open+1 : close])
+		if err != nil {
+			return result, err
+		}
+		s = s[close+1:]
+		arg := func(i int, fallback float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return fallback
+		}
+		var m svgMatrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				return result, errors.New("Transform matrix needs 6 numbers")
+			}
+			copy(m[:], args)
+		case "translate":
+			m = svgMatrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			m = svgMatrix{arg(0, 1), 0, 0, arg(1, arg(0, 1)), 0, 0}
+		case "rotate":
+			angle := arg(0, 0) * math.Pi / 180
+			cos, sin := math.Cos(angle), math.Sin(angle)
+			cx, cy := arg(1, 0), arg(2, 0)
+			//Rotate around cx, cy
+			m = svgMatrix{1, 0, 0, 1, cx, cy}.then(svgMatrix{cos, sin, -sin, cos, 0, 0}).then(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			m = svgMatrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			m = svgMatrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return result, fmt.Errorf("Unknown transform %q", name)
+		}
+		result = result.then(m)
+	}
+	return result, nil
+}
+
+// svgNumbers reads a list of numbers separated by spaces or commas
+func svgNumbers(s string) (numbers []float64, err error) {
+	scanner := svgScanner{s: s}
+	for scanner.skipSeparators(); scanner.more(); scanner.skipSeparators() {
+		value, err := scanner.number()
+		if err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, value)
+	}
+	return numbers, nil
+}
+
+// svgScanner reads the commands and the numbers of path data, where numbers may follow each other
+// without separators as in "1-2.5.5"
+type svgScanner struct {
+	s   string
+	pos int
+}
+
+func (sc *svgScanner) more() bool {
+	return sc.pos < len(sc.s)
+}
+
+func (sc *svgScanner) skipSeparators() {
+	for sc.more() && (unicode.IsSpace(rune(sc.s[sc.pos])) || sc.s[sc.pos] == ',') {
+		sc.pos++
+	}
+}
+
+// number reads the next number
+func (sc *svgScanner) number() (float64, error) {
+	sc.skipSeparators()
+	start := sc.pos
+	if sc.more() && (sc.s[sc.pos] == '-' || sc.s[sc.pos] == '+') {
+		sc.pos++
+	}
+	dot, exponent := false, false
+	for sc.more() {
+		c := sc.s[sc.pos]
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '.' && !dot && !exponent:
+			dot = true
+		case (c == 'e' || c == 'E') && !exponent && sc.pos > start:
+			exponent = true
+			if sc.pos+1 < len(sc.s) && (sc.s[sc.pos+1] == '-' || sc.s[sc.pos+1] == '+') {
+				sc.pos++
+			}
+		default:
+			return sc.parse(start)
+		}
+		sc.pos++
+	}
+	return sc.parse(start)
+}
+
+func (sc *svgScanner) parse(start int) (float64, error) {
+	value, err := strconv.ParseFloat(sc.s[start:sc.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid number at %d in %q", start, sc.s)
+	}
+	return value, nil
+}
+
+// flag reads an arc flag, a single 0 or 1 that may be followed by the next number without separator
+func (sc *svgScanner) flag() (bool, error) {
+	sc.skipSeparators()
+	if !sc.more() || (sc.s[sc.pos] != '0' && sc.s[sc.pos] != '1') {
+		return false, fmt.Errorf("Invalid arc flag at %d in %q", sc.pos, sc.s)
+	}
+	sc.pos++
+	return sc.s[sc.pos-1] == '1', nil
+}
+
+// parseSVGPath reads the data of a path, returning a polygon per subpath
+func parseSVGPath(d string, tolerance float64) (polygons [][]model.Vec2, err error) {
+	sc := svgScanner{s: d}
+	var polygon []model.Vec2
+	var current, start, control [2]float64
+	var command, previous byte
+	closePolygon := func() {
+		if len(polygon) > 0 {
+			polygons = append(polygons, polygon)
+		}
+		polygon = nil
+	}
+	add := func(p [2]float64) {
+		polygon = append(polygon, model.Vec2{float32(p[0]), float32(p[1])})
+		current = p
+	}
+	for sc.skipSeparators(); sc.more(); sc.skipSeparators() {
+		if c := sc.s[sc.pos]; unicode.IsLetter(rune(c)) && c != 'e' && c != 'E' {
+			command = c
+			sc.pos++
+		} else if command == 0 {
+			return nil, fmt.Errorf("Path must start with a command: %q", d)
+		}
+		relative := command >= 'a'
+		//Reads the next point, relative to the current one for lowercase commands
+		point := func() ([2]float64, error) {
+			x, err := sc.number()
+			if err != nil {
+				return [2]float64{}, err
+			}
+			y, err := sc.number()
+			if relative {
+				x, y = x+current[0], y+current[1]
+			}
+			return [2]float64{x, y}, err
+		}
+		switch command {
+		case 'M', 'm':
+			closePolygon()
+			p, err := point()
+			if err != nil {
+				return nil, err
+			}
+			add(p)
+			start = p
+			//Pairs following a move are lines
+			command = 'L' + command - 'M'
+		case 'L', 'l':
+			p, err := point()
+			if err != nil {
+				return nil, err
+			}
+			add(p)
+		case 'H', 'h', 'V', 'v':
+			value, err := sc.number()
+			if err != nil {
+				return nil, err
+			}
+			p := current
+			axis := 0
+			if command == 'V' || command == 'v' {
+				axis = 1
+			}
+			if relative {
+				p[axis] += value
+			} else {
+				p[axis] = value
+			}
+			add(p)
+		case 'C', 'c', 'S', 's', 'Q', 'q', 'T', 't':
+			//The first control point of the smooth curves mirrors the last one of the previous curve
+			mirrored := current
+			upper := command &^ 0x20
+			if (upper == 'S' && (previous == 'C' || previous == 'S')) || (upper == 'T' && (previous == 'Q' || previous == 'T')) {
+				mirrored = [2]float64{2*current[0] - control[0], 2*current[1] - control[1]}
+			}
+			var points [][2]float64
+			count := map[byte]int{'C': 3, 'S': 2, 'Q': 2, 'T': 1}[upper]
+			for i := 0; i < count; i++ {
+				p, err := sc.pointFrom(relative, current)
+				if err != nil {
+					return nil, err
+				}
+				points = append(points, p)
+			}
+			var curve [][2]float64
+			switch upper {
+			case 'C':
+				curve = [][2]float64{current, points[0], points[1], points[2]}
+			case 'S':
+				curve = [][2]float64{current, mirrored, points[0], points[1]}
+			case 'Q':
+				curve = [][2]float64{current, points[0], points[1]}
+			case 'T':
+				curve = [][2]float64{current, mirrored, points[0]}
+			}
+			for _, p := range flattenBezier(curve, tolerance) {
+				add(p)
+			}
+			control = curve[len(curve)-2]
+			previous = upper
+			continue
+		case 'A', 'a':
+			var radii [2]float64
+			for i := range radii {
+				if radii[i], err = sc.number(); err != nil {
+					return nil, err
+				}
+			}
+			rotation, err := sc.number()
+			if err != nil {
+				return nil, err
+			}
+			large, err := sc.flag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := sc.flag()
+			if err != nil {
+				return nil, err
+			}
+			end, err := point()
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range flattenArc(current, end, radii, rotation, large, sweep, tolerance) {
+				add(p)
+			}
+		case 'Z', 'z':
+			closePolygon()
+			current = start
+		default:
+			return nil, fmt.Errorf("Unknown path command %q", command)
+		}
+		previous = command &^ 0x20
+	}
+	closePolygon()
+	return polygons, nil
+}
+
+// pointFrom reads a point, relative to the current one if asked
+func (sc *svgScanner) pointFrom(relative bool, current [2]float64) (p [2]float64, err error) {
+	if p[0], err = sc.number(); err != nil {
+		return p, err
+	}
+	if p[1], err = sc.number(); err != nil {
+		return p, err
+	}
+	if relative {
+		p[0], p[1] = p[0]+current[0], p[1]+current[1]
+	}
+	return p, nil
+}
+
+// flattenBezier returns the points along a quadratic or cubic Bézier curve after its start, closer
+// together the longer its control polygon
+func flattenBezier(curve [][2]float64, tolerance float64) (points [][2]float64) {
+	length := 0.0
+	for i := 1; i < len(curve); i++ {
+		length += math.Hypot(curve[i][0]-curve[i-1][0], curve[i][1]-curve[i-1][1])
+	}
+	segments := min(max(int(math.Ceil(math.Sqrt(length/tolerance))), 1), maxCurveSegments)
+	for s := 1; s <= segments; s++ {
+		t := float64(s) / float64(segments)
+		//De Casteljau
+		work := append([][2]float64(nil), curve...)
+		for n := len(work) - 1; n > 0; n-- {
+			for i := 0; i < n; i++ {
+				work[i] = [2]float64{work[i][0] + (work[i+1][0]-work[i][0])*t, work[i][1] + (work[i+1][1]-work[i][1])*t}
+			}
+		}
+		points = append(points, work[0])
+	}
+	return points
+}
+
+// flattenArc returns the points along an elliptical arc of a path after its start, converting its
+// endpoints to a center as in the appendix of the SVG specification
+func flattenArc(from, to [2]float64, radii [2]float64, rotation float64, large, sweep bool, tolerance float64) [][2]float64 {
+	rx, ry := math.Abs(radii[0]), math.Abs(radii[1])
+	if rx == 0 || ry == 0 || from == to {
+		return [][2]float64{to}
+	}
+	phi := rotation * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (from[0]-to[0])/2, (from[1]-to[1])/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+	//Radii too small to reach the end are scaled up
+	if scale := x1*x1/(rx*rx) + y1*y1/(ry*ry); scale > 1 {
+		rx, ry = rx*math.Sqrt(scale), ry*math.Sqrt(scale)
+	}
+	numerator := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	factor := math.Sqrt(math.Max(0, numerator/(rx*rx*y1*y1+ry*ry*x1*x1)))
+	if large == sweep {
+		factor = -factor
+	}
+	cx1, cy1 := factor*rx*y1/ry, -factor*ry*x1/rx
+	cx, cy := cos*cx1-sin*cy1+(from[0]+to[0])/2, sin*cx1+cos*cy1+(from[1]+to[1])/2
+	angle := func(ux, uy float64) float64 { return math.Atan2(uy, ux) }
+	start := angle((x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((-x1-cx1)/rx, (-y1-cy1)/ry) - start
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+	segments := arcSegments(math.Max(rx, ry), delta, tolerance)
+	points := make([][2]float64, 0, segments)
+	for s := 1; s < segments; s++ {
+		theta := start + delta*float64(s)/float64(segments)
+		x, y := rx*math.Cos(theta), ry*math.Sin(theta)
+		points = append(points, [2]float64{cos*x - sin*y + cx, sin*x + cos*y + cy})
+	}
+	//End exactly where the path says
+	return append(points, to)
+}
@@ -0,0 +1,697 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	imagecolor "image/color"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Everything that decides how a model is drawn
+type renderSettings struct {
+	//Direction (front, side, top, iso, dimetric or sheet), overridden by the camera (yaw,pitch[,roll])
+	view, camera string
+	//Width of the grid and its height in rows (0 to keep the proportions), with the cell proportions
+	size, height int
+	aspect       float64
+	//Place the model centered inside the margin (a fraction of each side), zoomed and panned (right,up
+	//as fractions of the grid), fitting its bounding sphere when steady
+	margin, zoom float64
+	pan          string
+	steady       bool
+	//Model units per cell instead of fitting the model (0 to fit it), or the models whose largest one
+	//is fitted along with the one drawn so they all share the scale
+	scale       float64
+	scaleModels []*model.Model
+	//What to draw: vertices, depth, wire, shaded or curvature (mean or gaussian)
+	mode, curvature string
+	cull            bool
+	light           string
+	ambient         float64
+	//How to paint it in the terminal
+	braille                  bool
+	color, palette, graphics string
+	//Paint each shell, color, material or region in its own color instead (empty for none)
+	colorBy string
+	//Image file to write instead, optionally as a 16 bit depth map, rendered this many times larger
+	//and scaled down to smooth the edges
+	output      string
+	depthMap    bool
+	supersample int
+	//Highlight the facets overhanging more than this angle in degrees and the walls thinner than this (0 for none)
+	overhang, thinWall float64
+	//Highlight the edges where the surface bends more than this angle in degrees (0 for none)
+	features float64
+	//Highlight the cross sections at these heights, separated by commas (empty for none)
+	sections string
+	//Highlight these polylines, such as a toolpath
+	lines [][]model.Vec3
+	//View file or preset to start from, and file to save the view to
+	viewConfig, saveView string
+}
+
+// Set every drawing option from a view
+func (settings *renderSettings) setView(v model.View) {
+	settings.view, settings.camera = v.Projection, ""
+	if v.Camera != nil {
+		settings.camera = fmt.Sprintf("%v,%v,%v", v.Camera.Yaw, v.Camera.Pitch, v.Camera.Roll)
+	}
+	settings.size, settings.height, settings.aspect = v.Width, v.Height, v.Aspect
+	settings.margin, settings.zoom, settings.pan, settings.steady, settings.scale = 0, 1, "0,0", false, 0
+	if v.Frame != nil {
+		settings.margin, settings.steady, settings.scale = float64(v.Frame.Margin), v.Frame.Steady, float64(v.Frame.Scale)
+		if v.Frame.Zoom != 0 {
+			settings.zoom = float64(v.Frame.Zoom)
+		}
+		settings.pan = fmt.Sprintf("%v,%v", v.Frame.Pan[0], v.Frame.Pan[1])
+	}
+	settings.mode, settings.curvature, settings.cull = v.Mode, v.Curvature, v.Cull
+	settings.light, settings.ambient = fmt.Sprintf("%v,%v,%v", v.Light[0], v.Light[1], v.Light[2]), v.Ambient
+	settings.palette, settings.color, settings.braille, settings.colorBy = v.Palette, v.Color, v.Braille, v.ColorBy
+	settings.overhang, settings.thinWall, settings.features = v.Overhang, v.ThinWall, v.Features
+	settings.supersample = max(v.Supersample, 1)
+	settings.sections = ""
+	for i, z := range v.Sections {
+		if i > 0 {
+			settings.sections += ","
+		}
+		settings.sections += strconv.FormatFloat(z, 'g', -1, 64)
+	}
+	//Empty names keep the defaults
+	if settings.curvature == "" {
+		settings.curvature = model.DefaultView.Curvature
+	}
+	if settings.palette == "" {
+		settings.palette = model.DefaultView.Palette
+	}
+	if settings.color == "" {
+		settings.color = model.DefaultView.Color
+	}
+}
+
+// Get the view of the drawing options
+func (settings renderSettings) toView() (v model.View, err error) {
+	v = model.View{
+		Projection: settings.view, Width: settings.size, Height: settings.height, Aspect: settings.aspect,
+		Mode: settings.mode, Curvature: settings.curvature, Cull: settings.cull, Ambient: settings.ambient,
+		Palette: settings.palette, Color: settings.color, Braille: settings.braille, ColorBy: settings.colorBy,
+		Overhang: settings.overhang, ThinWall: settings.thinWall, Features: settings.features,
+		Supersample: settings.supersample,
+	}
+	if settings.camera != "" {
+		camera, err := model.ParseCamera(settings.camera)
+		if err != nil {
+			return v, err
+		}
+		v.Camera = &camera
+	}
+	frame, err := settings.frame()
+	if err != nil {
+		return v, err
+	}
+	if frame != (model.Frame{Zoom: 1}) {
+		v.Frame = &frame
+	}
+	if v.Light, err = model.ParseVec3(settings.light); err != nil {
+		return v, err
+	}
+	if v.Sections, err = parseSections(settings.sections); err != nil {
+		return v, err
+	}
+	return v, v.Validate()
+}
+
+// Start from the view of the -view-config file or preset, keeping the flags given on the command line,
+// and save the view drawn to the -save-view file
+func (settings *renderSettings) applyViewFiles(flags *flag.FlagSet) error {
+	if settings.viewConfig != "" {
+		//The values of the flags given, which the view overwrites
+		given := make(map[string]string)
+		flags.Visit(func(f *flag.Flag) { given[f.Name] = f.Value.String() })
+		v, err := loadView(settings.viewConfig)
+		if err != nil {
+			return err
+		}
+		settings.setView(v)
+		for name, value := range given {
+			flags.Set(name, value)
+		}
+		//A view given replaces the camera of the file
+		if _, ok := given["camera"]; !ok && given["view"] != "" {
+			settings.camera = ""
+		}
+	}
+	if settings.saveView != "" {
+		v, err := settings.toView()
+		if err != nil {
+			return err
+		}
+		file, err := os.Create(settings.saveView)
+		if err != nil {
+			return err
+		}
+		err = model.WriteView(file, v)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+	return nil
+}
+
+// Read a view from a JSON file, or get the preset of the name
+func loadView(name string) (model.View, error) {
+	if _, ok := model.ViewPresets[name]; ok {
+		return model.ViewPreset(name)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return model.View{}, err
+	}
+	defer file.Close()
+	v, err := model.ReadView(file)
+	if err != nil {
+		return v, fmt.Errorf("%s: %v", name, err)
+	}
+	return v, nil
+}
+
+// Get the function projecting the model for a mode
+func (settings renderSettings) projector() (model.Projector, error) {
+	renderOptions := model.RenderOptions{CullBackFaces: settings.cull}
+	switch settings.mode {
+	case "vertices":
+		return model.ProjectVertices, nil
+	case "depth":
+		return func(m *model.Model, viewport model.Viewport, perspective model.Perspective) [][]float32 {
+			return model.RasterizeModelTriangles(m, viewport, perspective, renderOptions)
+		}, nil
+	case "wire":
+		return func(m *model.Model, viewport model.Viewport, perspective model.Perspective) [][]float32 {
+			return model.ProjectModelEdges(m, viewport, perspective, renderOptions)
+		}, nil
+	case "shaded":
+		lightDirection, err := model.ParseVec3(settings.light)
+		if err != nil {
+			return nil, err
+		}
+		lighting := model.Lighting{Direction: lightDirection, Ambient: float32(settings.ambient)}
+		return func(m *model.Model, viewport model.Viewport, perspective model.Perspective) [][]float32 {
+			return model.RasterizeShaded(m, viewport, perspective, renderOptions, lighting)
+		}, nil
+	case "curvature":
+		kind, err := parseCurvature(settings.curvature)
+		if err != nil {
+			return nil, err
+		}
+		return func(m *model.Model, viewport model.Viewport, perspective model.Perspective) [][]float32 {
+			return model.RasterizeCurvature(m, viewport, perspective, renderOptions, kind)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown mode %q", settings.mode)
+}
+
+// Get the function rasterizing the model and writing it a band of rows at a time, nil for the modes
+// needing the whole matrix
+func (settings renderSettings) bandRenderer() (func(w io.Writer, m *model.Model, viewport model.Viewport, perspective model.Perspective, write model.MatrixWriter) error, error) {
+	renderOptions := model.RenderOptions{CullBackFaces: settings.cull}
+	switch settings.mode {
+	case "depth":
+		return func(w io.Writer, m *model.Model, viewport model.Viewport, perspective model.Perspective, write model.MatrixWriter) error {
+			return model.RenderDepth(w, m, viewport, perspective, renderOptions, write)
+		}, nil
+	case "shaded":
+		lightDirection, err := model.ParseVec3(settings.light)
+		if err != nil {
+			return nil, err
+		}
+		lighting := model.Lighting{Direction: lightDirection, Ambient: float32(settings.ambient)}
+		return func(w io.Writer, m *model.Model, viewport model.Viewport, perspective model.Perspective, write model.MatrixWriter) error {
+			return model.RenderShaded(w, m, viewport, perspective, renderOptions, lighting, write)
+		}, nil
+	}
+	return nil, nil
+}
+
+// Get the kind of curvature from its name
+func parseCurvature(name string) (model.CurvatureKind, error) {
+	switch name {
+	case "mean":
+		return model.MeanCurvature, nil
+	case "gaussian":
+		return model.GaussianCurvature, nil
+	}
+	return model.MeanCurvature, fmt.Errorf("unknown curvature %q", name)
+}
+
+// Get the function painting a matrix as text in the terminal
+func (settings renderSettings) drawer() (model.Drawer, error) {
+	write, err := settings.highlightWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	return write.Drawer(), nil
+}
+
+// Get the function writing a matrix as text for the terminal a row at a time, marking the cells set
+// in the mask (nil for none)
+func (settings renderSettings) highlightWriter(mask [][]bool) (model.MatrixWriter, error) {
+	colorMode, err := model.ParseColorMode(settings.color, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	drawPalette, err := model.ParsePalette(settings.palette)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case settings.braille && mask != nil:
+		return nil, errors.New("braille can't show highlights")
+	case settings.braille:
+		return model.RenderMatrixBraille, nil
+	case settings.mode == "curvature" && mask != nil:
+		return nil, errors.New("the curvature can't show highlights")
+	case settings.mode == "curvature" && colorMode != model.ColorNone:
+		return func(w io.Writer, matrix [][]float32) error {
+			return model.RenderMatrixColorMap(w, matrix, model.DivergingColorMap, colorMode)
+		}, nil
+	case colorMode != model.ColorNone:
+		return func(w io.Writer, matrix [][]float32) error {
+			return model.RenderMatrixColorHighlighted(w, matrix, mask, colorMode)
+		}, nil
+	}
+	return func(w io.Writer, matrix [][]float32) error {
+		return model.RenderMatrixPaletteHighlighted(w, matrix, mask, drawPalette, 'X')
+	}, nil
+}
+
+// Write a matrix to the terminal as it is painted, followed by an empty line
+func writeMatrix(write model.MatrixWriter, matrix [][]float32) error {
+	out := bufio.NewWriter(os.Stdout)
+	if err := write(out, matrix); err != nil {
+		return err
+	}
+	out.WriteString("\n")
+	return out.Flush()
+}
+
+// Check if any facet is highlighted
+func (settings renderSettings) highlighting() bool {
+	return settings.overhang > 0 || settings.thinWall > 0 || settings.features > 0 || settings.sections != "" || len(settings.lines) > 0
+}
+
+// Get the cells to highlight in a render, nil if there are none
+func (settings renderSettings) highlight(aModel *model.Model, viewport model.Viewport, perspective model.Perspective) ([][]bool, error) {
+	if !settings.highlighting() {
+		return nil, nil
+	}
+	sections, err := parseSections(settings.sections)
+	if err != nil {
+		return nil, err
+	}
+	var facets []int
+	if settings.overhang > 0 {
+		facets = append(facets, model.FindOverhangs(aModel, model.OverhangOptions{MaxAngle: settings.overhang}).Facets...)
+	}
+	if settings.thinWall > 0 {
+		facets = append(facets, model.FindThinWalls(aModel, float32(settings.thinWall)).Facets...)
+	}
+	mask := model.HighlightFacets(aModel, viewport, perspective, model.RenderOptions{CullBackFaces: settings.cull}, facets)
+	lines := settings.lines
+	for _, z := range sections {
+		lines = append(model.SliceAt(aModel, float32(z)).Lines(), lines...)
+	}
+	if settings.features > 0 {
+		lines = append(model.NewTopology(aModel).FeatureLines(settings.features), lines...)
+	}
+	if len(lines) > 0 {
+		marked := model.HighlightLines(aModel, viewport, perspective, lines)
+		for i := range mask {
+			for j := range mask[i] {
+				mask[i][j] = mask[i][j] || marked[i][j]
+			}
+		}
+	}
+	return mask, nil
+}
+
+// Read the heights of the cross sections, separated by commas
+func parseSections(s string) (heights []float64, err error) {
+	if s == "" {
+		return nil, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		z, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid section height %q", part)
+		}
+		heights = append(heights, z)
+	}
+	return heights, nil
+}
+
+// Get the frame placing the model in the grid
+func (settings renderSettings) frame() (f model.Frame, err error) {
+	if settings.margin < 0 || settings.margin >= 0.5 || settings.zoom < 0 || settings.scale < 0 {
+		return f, errors.New("the margin must be between 0 and 0.5 and the zoom and the scale can't be negative")
+	}
+	f = model.Frame{Margin: float32(settings.margin), Zoom: float32(settings.zoom), Scale: float32(settings.scale), Steady: settings.steady}
+	if f.Zoom == 0 {
+		f.Zoom = 1
+	}
+	if settings.pan != "" {
+		parts := strings.Split(settings.pan, ",")
+		if len(parts) != 2 {
+			return f, fmt.Errorf("invalid pan %q, expected right,up", settings.pan)
+		}
+		for i, part := range parts {
+			offset, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+			if err != nil {
+				return f, fmt.Errorf("invalid pan %q, expected right,up", settings.pan)
+			}
+			f.Pan[i] = float32(offset)
+		}
+	}
+	return f, nil
+}
+
+// Get the viewport of the grid in the terminal, size + 1 columns by default like the original square
+// projection
+func (settings renderSettings) gridViewport() (model.Viewport, error) {
+	frame, err := settings.frame()
+	viewport := model.NewViewport(settings.size+1, settings.height, float32(settings.aspect))
+	//Every braille character shows 2 columns by 4 rows of the grid
+	if settings.braille {
+		viewport = model.NewViewport(2*(settings.size+1), 4*settings.height, float32(settings.aspect)/2)
+		frame.Scale /= 2
+	}
+	viewport.Frame = frame
+	return viewport, err
+}
+
+// Get the viewport of an image with square pixels, rendered factor times larger
+func (settings renderSettings) imageViewport(factor int) (model.Viewport, error) {
+	frame, err := settings.frame()
+	viewport := model.ImageViewport(settings.size*factor, settings.height*factor)
+	frame.Scale /= float32(factor)
+	viewport.Frame = frame
+	return viewport, err
+}
+
+// Share the scale of the viewport with the models of -scale-with, fitting the largest of them and
+// the one drawn
+func (settings renderSettings) shareScale(viewport model.Viewport, aModel *model.Model, perspective model.Perspective) model.Viewport {
+	if len(settings.scaleModels) > 0 {
+		viewport.Frame.Scale = model.FitScale(append([]*model.Model{aModel}, settings.scaleModels...), viewport, perspective)
+	}
+	return viewport
+}
+
+// Load the models to share the scale with, separated by commas
+func loadScaleModels(paths string) (models []*model.Model, err error) {
+	if paths == "" {
+		return nil, nil
+	}
+	for _, path := range strings.Split(paths, ",") {
+		aModel, err := loadModel(path, "", false)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, &aModel)
+	}
+	return models, nil
+}
+
+// Get the perspective of the view, or of the camera that overrides it (nil being the four view sheet)
+func (settings renderSettings) perspective() (model.Perspective, error) {
+	if settings.camera != "" {
+		return model.ParseCamera(settings.camera)
+	}
+	return model.ParseProjection(settings.view)
+}
+
+// Draw the model as an image with square pixels, or as a 16 bit depth map
+func renderImage(aModel *model.Model, settings renderSettings, perspective model.Perspective) (image.Image, error) {
+	if perspective == nil {
+		return nil, errors.New("the sheet can't be drawn as an image")
+	}
+	project, err := settings.projector()
+	if err != nil {
+		return nil, err
+	}
+	factor := settings.supersampling()
+	viewport, err := settings.imageViewport(factor)
+	if err != nil {
+		return nil, err
+	}
+	viewport = settings.shareScale(viewport, aModel, perspective)
+	if settings.depthMap {
+		return model.DrawMatrixDepth16(project(aModel, viewport, perspective)), nil
+	}
+	if settings.colorBy != "" {
+		colors, err := settings.renderColors(aModel, viewport, perspective)
+		if err != nil {
+			return nil, err
+		}
+		return model.Downsample(model.DrawColorsImage(colors, image.Transparent), factor), nil
+	}
+	matrix := project(aModel, viewport, perspective)
+	if settings.mode == "curvature" {
+		if settings.highlighting() {
+			return nil, errors.New("the curvature can't show highlights")
+		}
+		return model.Downsample(model.DrawMatrixImageColorMap(matrix, model.DivergingColorMap, image.Transparent), factor), nil
+	}
+	mask, err := settings.highlight(aModel, viewport, perspective)
+	if err != nil {
+		return nil, err
+	}
+	return model.Downsample(model.DrawMatrixImageHighlighted(matrix, mask, image.Transparent), factor), nil
+}
+
+// Shade the model painting each part in its own color, for -color-by
+func (settings renderSettings) renderColors(aModel *model.Model, viewport model.Viewport, perspective model.Perspective) ([][]imagecolor.RGBA, error) {
+	by, err := model.ParseColorBy(settings.colorBy)
+	if err != nil {
+		return nil, err
+	}
+	if settings.highlighting() {
+		return nil, errors.New("the colors by part can't show highlights")
+	}
+	lightDirection, err := model.ParseVec3(settings.light)
+	if err != nil {
+		return nil, err
+	}
+	lighting := model.Lighting{Direction: lightDirection, Ambient: float32(settings.ambient)}
+	return model.RenderColored(aModel, viewport, perspective, model.RenderOptions{CullBackFaces: settings.cull}, lighting, model.TriangleColors(aModel, by)), nil
+}
+
+// Get the factor images are supersampled by, depth maps keeping their exact values
+func (settings renderSettings) supersampling() int {
+	if settings.depthMap {
+		return 1
+	}
+	return min(max(settings.supersample, 1), model.MaxSupersample)
+}
+
+// Draw the model to the terminal or to an image file
+func render(aModel *model.Model, settings renderSettings) error {
+	perspective, err := settings.perspective()
+	if err != nil {
+		return err
+	}
+	if settings.size < 1 || settings.height < 0 || settings.aspect <= 0 {
+		return errors.New("the size must be positive and the height and the aspect can't be negative")
+	}
+	//Export the render as an image
+	if settings.output != "" {
+		format, err := model.ImageFormatFromPath(settings.output)
+		if err != nil {
+			return err
+		}
+		if settings.depthMap && format != model.ImagePNG {
+			return errors.New("depth maps can only be written as png")
+		}
+		img, err := renderImage(aModel, settings, perspective)
+		if err != nil {
+			return err
+		}
+		return writeImage(settings.output, img, format)
+	}
+	graphicsProtocol, err := model.ParseGraphicsProtocol(settings.graphics, os.Stdout)
+	if err != nil {
+		return err
+	}
+	//Show the render as an image in the terminal, the sheet is always drawn with characters
+	if graphicsProtocol != model.GraphicsNone && perspective != nil {
+		img, err := renderImage(aModel, settings, perspective)
+		if err != nil {
+			return err
+		}
+		if err := model.EncodeInlineImage(os.Stdout, img, graphicsProtocol); err != nil {
+			return err
+		}
+		fmt.Println()
+		return nil
+	}
+	viewport, err := settings.gridViewport()
+	if err != nil {
+		return err
+	}
+	project, err := settings.projector()
+	if err != nil {
+		return err
+	}
+	//Paint the model
+	if perspective == nil {
+		if settings.highlighting() {
+			return errors.New("the sheet can't show highlights")
+		}
+		if len(settings.scaleModels) > 0 {
+			return errors.New("the sheet can't share the scale with other models")
+		}
+		if settings.colorBy != "" {
+			return errors.New("the sheet can't show the colors by part")
+		}
+		paint, err := settings.drawer()
+		if err != nil {
+			return err
+		}
+		fmt.Println(model.DrawFourViewSheet(aModel, viewport, project, paint))
+		return nil
+	}
+	viewport = settings.shareScale(viewport, aModel, perspective)
+	if settings.colorBy != "" {
+		if settings.braille {
+			return errors.New("braille can't show the colors by part")
+		}
+		colorMode, err := model.ParseColorMode(settings.color, os.Stdout)
+		if err != nil {
+			return err
+		}
+		colors, err := settings.renderColors(aModel, viewport, perspective)
+		if err != nil {
+			return err
+		}
+		out := bufio.NewWriter(os.Stdout)
+		if err := model.RenderColors(out, colors, colorMode); err != nil {
+			return err
+		}
+		out.WriteString("\n")
+		return out.Flush()
+	}
+	mask, err := settings.highlight(aModel, viewport, perspective)
+	if err != nil {
+		return err
+	}
+	paint, err := settings.highlightWriter(mask)
+	if err != nil {
+		return err
+	}
+	//Depth and shaded renders without highlights never hold the whole matrix
+	renderBands, err := settings.bandRenderer()
+	if err != nil {
+		return err
+	}
+	if renderBands != nil && mask == nil {
+		out := bufio.NewWriter(os.Stdout)
+		if err := renderBands(out, aModel, viewport, perspective, paint); err != nil {
+			return err
+		}
+		out.WriteString("\n")
+		return out.Flush()
+	}
+	return writeMatrix(paint, project(aModel, viewport, perspective))
+}
+
+// Write an image file in the given format
+func writeImage(path string, img image.Image, format model.ImageFormat) error {
+	outputFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = model.EncodeImage(outputFile, img, format)
+	if closeErr := outputFile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Register a flag for every rendering option
+func (settings *renderSettings) addFlags(flags *flag.FlagSet) {
+	flags.StringVar(&settings.view, "view", "front", "Direction to draw the model from (front|side|top|iso|dimetric|sheet)")
+	flags.StringVar(&settings.camera, "camera", "", "Draw from any angle instead of the view (yaw,pitch[,roll] in degrees)")
+	flags.IntVar(&settings.size, "size", 160, "Width of the grid in characters (or pixels for images)")
+	flags.IntVar(&settings.height, "height", 0, "Number of rows of the grid (0 keeps the proportions of the size)")
+	flags.Float64Var(&settings.aspect, "aspect", 2, "Height of a character relative to its width, used to keep the proportions of the model")
+	settings.addFrameFlags(flags)
+	flags.StringVar(&settings.mode, "mode", "vertices", "What to draw (vertices|depth|wire|shaded|curvature)")
+	flags.StringVar(&settings.curvature, "curvature", "mean", "Curvature painted by the curvature mode, from blue for concave to red for convex (mean|gaussian)")
+	flags.BoolVar(&settings.cull, "cull", false, "Skip the triangles facing away from the viewer")
+	flags.StringVar(&settings.light, "light", "-0.5,0.5,1", "Direction the light comes from when shading (right,up,towards the viewer)")
+	flags.Float64Var(&settings.ambient, "ambient", 0.15, "Light reaching every surface when shading (0 to 1)")
+	flags.BoolVar(&settings.braille, "braille", false, "Draw with braille dots, each character showing 2 x 4 cells of a grid twice as wide and four times as tall")
+	flags.StringVar(&settings.color, "color", "none", "Paint the model with terminal colors instead of characters (none|256|true|auto)")
+	flags.StringVar(&settings.colorBy, "color-by", "", "Shade the model painting each part in its own color, with -color or -out (shell|color|material|region, empty for none)")
+	flags.StringVar(&settings.palette, "palette", "block", "Characters used to draw, from the lowest to the highest value (block|ascii|custom characters)")
+	flags.StringVar(&settings.graphics, "graphics", "none", "Draw a real image in terminals supporting inline bitmaps (none|sixel|kitty|iterm2|auto)")
+	flags.StringVar(&settings.output, "out", "", "Write the render to an image file instead of the terminal (.png or .jpg)")
+	flags.BoolVar(&settings.depthMap, "depth-map", false, "Write the depth as a 16 bit grayscale PNG with -out")
+	flags.IntVar(&settings.supersample, "supersample", 1, "Render images this many times larger and scale them down, smoothing the edges (1 for none, up to 8)")
+	flags.Float64Var(&settings.overhang, "overhang", 0, "Highlight the facets overhanging more than this angle from the vertical, in degrees (45 is typical, 0 for none)")
+	flags.Float64Var(&settings.thinWall, "thin", 0, "Highlight the walls thinner than this (0 for none)")
+	flags.StringVar(&settings.viewConfig, "view-config", "", "Start from the view of this JSON file or preset (terminal|shaded|sheet|thumbnail|preview|print), the other flags given changing it")
+	flags.StringVar(&settings.saveView, "save-view", "", "Save the view drawn to this JSON file, for -view-config")
+	flags.StringVar(&settings.sections, "sections", "", "Highlight the cross sections by the horizontal planes at these heights, separated by commas")
+	flags.Float64Var(&settings.features, "features", 0, "Highlight the edges where the surface bends more than this angle, in degrees (30 is typical with -mode wire, 0 for none)")
+}
+
+// Register the flags placing the model in the grid
+func (settings *renderSettings) addFrameFlags(flags *flag.FlagSet) {
+	flags.Float64Var(&settings.margin, "margin", 0, "Center the model leaving this fraction of the width and of the height empty on each side")
+	flags.Float64Var(&settings.zoom, "zoom", 1, "Magnify the model around its center, cropping what goes out of the grid (centers the model)")
+	flags.StringVar(&settings.pan, "pan", "0,0", "Move the model right and up by these fractions of the width and of the height (centers the model)")
+	flags.Float64Var(&settings.scale, "scale", 0, "Draw this many model units per character or pixel instead of fitting the model, so models drawn with the same scale keep their relative sizes (centers the model, 0 fits it)")
+	flags.BoolVar(&settings.steady, "steady", false, "Fit the bounding sphere of the model, keeping the same scale and center from every direction (centers the model)")
+}
+
+// Draw a model with every rendering option available as a flag
+func renderCommand(args []string) {
+	var settings renderSettings
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	settings.addFlags(flags)
+	subdivide := flags.Float64("subdivide", 0, "Split the facets until no edge is longer than this, so big flat faces show up in the vertices mode (0 to keep them)")
+	scaleWith := flags.String("scale-with", "", "Share the scale with these models, separated by commas, fitting the largest of them and this one so renders of each keep their relative sizes")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii render [flags] pathtofile")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 {
+		flags.Usage()
+	}
+	check(settings.applyViewFiles(flags))
+
+	var err error
+	settings.scaleModels, err = loadScaleModels(*scaleWith)
+	check(err)
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	if *subdivide > 0 {
+		check(model.SubdivideLongEdges(&aModel, *subdivide))
+	}
+	if err := render(&aModel, settings); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
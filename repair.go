@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Fix the common defects of a model and report what changed
+func repairCommand(args []string) {
+	opts := model.DefaultRepairOptions
+	flags := flag.NewFlagSet("repair", flag.ExitOnError)
+	output := flags.String("o", "", "Write the repaired model to this file (only report when empty)")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	weld := flags.Float64("weld", float64(opts.WeldTolerance), "Merge the vertices closer than this (0 to skip)")
+	flags.BoolVar(&opts.RemoveDegenerate, "degenerate", opts.RemoveDegenerate, "Remove the facets without area")
+	flags.BoolVar(&opts.RemoveDuplicates, "duplicates", opts.RemoveDuplicates, "Remove the repeated facets")
+	flags.BoolVar(&opts.Orient, "orient", opts.Orient, "Flip the facets facing the wrong way")
+	flags.BoolVar(&opts.FillHoles, "holes", opts.FillHoles, "Fill the holes with new facets")
+	flags.BoolVar(&opts.FixNormals, "normals", opts.FixNormals, "Recompute the normals that don't match the facets")
+	asJSON := flags.Bool("json", false, "Print the report as JSON, with the triangles changed by every pass")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii repair [flags] pathtofile")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *weld < 0 {
+		flags.Usage()
+	}
+	opts.WeldTolerance = float32(*weld)
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	report := model.Repair(&aModel, opts)
+	watertight := model.NewTopology(&aModel).IsWatertight()
+	if *asJSON {
+		encoded, err := json.MarshalIndent(struct {
+			model.RepairReport
+			Watertight bool `json:"watertight"`
+		}{report, watertight}, "", "  ")
+		check(err)
+		fmt.Println(string(encoded))
+	} else {
+		fmt.Print(report)
+		fmt.Printf("Watertight: %v\n", watertight)
+	}
+	if *output != "" {
+		check(saveModel(*output, "", &aModel, *ascii))
+	}
+}
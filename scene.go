@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Draw several models together, each placed at an offset and in its own color
+func sceneCommand(args []string) {
+	settings := renderSettings{}
+	flags := flag.NewFlagSet("scene", flag.ExitOnError)
+	flags.StringVar(&settings.view, "view", "iso", "Direction to draw the scene from (front|side|top|iso|dimetric)")
+	flags.StringVar(&settings.camera, "camera", "", "Draw from any angle instead of the view (yaw,pitch[,roll] in degrees)")
+	flags.IntVar(&settings.size, "size", 160, "Width of the grid in characters (or pixels for images)")
+	flags.IntVar(&settings.height, "height", 0, "Number of rows of the grid (0 keeps the proportions of the size)")
+	flags.Float64Var(&settings.aspect, "aspect", 2, "Height of a character relative to its width, used to keep the proportions of the scene")
+	settings.addFrameFlags(flags)
+	flags.StringVar(&settings.light, "light", "-0.5,0.5,1", "Direction the light comes from (right,up,towards the viewer)")
+	flags.Float64Var(&settings.ambient, "ambient", 0.15, "Light reaching every surface (0 to 1)")
+	flags.StringVar(&settings.color, "color", "auto", "Terminal colors (none|256|true|auto)")
+	flags.StringVar(&settings.output, "out", "", "Write the render to an image file instead of the terminal (.png or .jpg)")
+	flags.IntVar(&settings.supersample, "supersample", 1, "Render images this many times larger and scale them down, smoothing the edges (1 for none, up to 8)")
+	merge := flags.String("merge", "", "Write all the models where they are placed to this file as a single model")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii scene [flags] pathtofile[@x,y,z] ...")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) == 0 || settings.size < 1 || settings.height < 0 || settings.aspect <= 0 {
+		flags.Usage()
+	}
+
+	var scene model.Scene
+	for i, file := range files {
+		//Each file may be followed by the offset it is placed at
+		transform := model.IdentityAffine()
+		if at := strings.LastIndex(file, "@"); at >= 0 {
+			offset, err := model.ParseVec3(file[at+1:])
+			check(err)
+			file, transform = file[:at], model.Translate(offset)
+		}
+		aModel, err := loadModel(file, "", false)
+		check(err)
+		scene.Add(filepath.Base(file), &aModel, transform, model.DistinctColors[i%len(model.DistinctColors)])
+	}
+	if *merge != "" {
+		check(saveModel(*merge, "", scene.Merge(), *ascii))
+		return
+	}
+	perspective, err := settings.perspective()
+	check(err)
+	if perspective == nil {
+		fmt.Fprintln(os.Stderr, "the sheet can't draw scenes")
+		os.Exit(1)
+	}
+	lightDirection, err := model.ParseVec3(settings.light)
+	check(err)
+	lighting := model.Lighting{Direction: lightDirection, Ambient: float32(settings.ambient)}
+	if settings.output != "" {
+		format, err := model.ImageFormatFromPath(settings.output)
+		check(err)
+		factor := settings.supersampling()
+		viewport, err := settings.imageViewport(factor)
+		check(err)
+		colors := model.RenderScene(&scene, viewport, perspective, model.RenderOptions{}, lighting)
+		check(writeImage(settings.output, model.Downsample(model.DrawColorsImage(colors, image.Transparent), factor), format))
+		return
+	}
+	colorMode, err := model.ParseColorMode(settings.color, os.Stdout)
+	check(err)
+	viewport, err := settings.gridViewport()
+	check(err)
+	out := bufio.NewWriter(os.Stdout)
+	check(model.RenderColors(out, model.RenderScene(&scene, viewport, perspective, model.RenderOptions{}, lighting), colorMode))
+	out.WriteString("\n")
+	check(out.Flush())
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Limits of the HTTP server
+type server struct {
+	//Largest upload in bytes
+	maxSize int64
+	//Largest width or height of a rendered image in pixels
+	maxImage int
+	//Download the model from the url parameter instead of taking an upload
+	allowURLs bool
+	//View of the renders, changed by the parameters of each request
+	view model.View
+}
+
+// Serve the info, render and convert commands over HTTP
+func serveCommand(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "Address to listen on")
+	maxSize := flags.Int64("max-size", 64, "Largest upload accepted, in megabytes")
+	maxImage := flags.Int("max-image", 4096, "Largest width or height of a rendered image, in pixels")
+	allowURLs := flags.Bool("allow-urls", false, "Download the model from the url parameter of a GET request (only on trusted networks, the server fetches any address)")
+	viewConfig := flags.String("view-config", "thumbnail", "View of the renders, a JSON file or preset (terminal|shaded|sheet|thumbnail|preview|print)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii serve [flags]")
+		fmt.Println("\nEndpoints, taking the model as the body of a POST request or as the file field of a form:")
+		fmt.Println("  /info      the info of the model as JSON")
+		fmt.Println("  /render    an image (preset, view, camera, size, height, margin, zoom, scale, pan, steady, mode, curvature, cull, light, ambient, colorby, overhang, thin, features, sections, depth, supersample and format parameters)")
+		fmt.Println("  /convert   the model in another format (to and ascii parameters)")
+		fmt.Println("The from parameter (stl or obj) sets the format of the upload, guessed from the file name or STL by default.")
+		fmt.Println("With -allow-urls, a GET request with a url parameter downloads the model from there instead.")
+		fmt.Println()
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	if len(parseArgs(flags, args)) != 0 || *maxSize < 1 || *maxImage < 1 {
+		flags.Usage()
+	}
+
+	view, err := loadView(*viewConfig)
+	check(err)
+	s := server{maxSize: *maxSize << 20, maxImage: *maxImage, allowURLs: *allowURLs, view: view}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", s.info)
+	mux.HandleFunc("/render", s.render)
+	mux.HandleFunc("/convert", s.convert)
+	httpServer := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	log.Printf("listening on %s", *addr)
+	log.Fatal(httpServer.ListenAndServe())
+}
+
+// Read the model uploaded with the request, or downloaded from its url parameter when allowed,
+// answering with the error if there is none
+func (s server) upload(w http.ResponseWriter, r *http.Request) (aModel model.Model, ok bool) {
+	if modelURL := r.URL.Query().Get("url"); s.allowURLs && r.Method == http.MethodGet && modelURL != "" {
+		aModel, err := model.CreateFromURLWith(r.Context(), modelURL, model.URLOptions{MaxSize: s.maxSize, Format: r.URL.Query().Get("from")})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return aModel, false
+		}
+		return aModel, true
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "upload the model with a POST request", http.StatusMethodNotAllowed)
+		return aModel, false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxSize)
+	var body io.Reader = r.Body
+	name := ""
+	//Take the file field of forms, or the whole body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), uploadErrorStatus(err))
+			return aModel, false
+		}
+		defer file.Close()
+		body, name = file, header.Filename
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), uploadErrorStatus(err))
+		return aModel, false
+	}
+	format := r.URL.Query().Get("from")
+	if format == "" {
+		format, err = fileFormat(name)
+		if err != nil {
+			format = "stl"
+		}
+	}
+	aModel, err = decodeModel(data, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return aModel, false
+	}
+	return aModel, true
+}
+
+// Get the response status for an error reading the upload
+func uploadErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// Answer with the info of the model as JSON
+func (s server) info(w http.ResponseWriter, r *http.Request) {
+	aModel, ok := s.upload(w, r)
+	if !ok {
+		return
+	}
+	encoded, err := json.Marshal(model.Inspect(&aModel))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(encoded)
+}
+
+// Answer with an image of the model
+func (s server) render(w http.ResponseWriter, r *http.Request) {
+	settings, format, err := renderQuery(r.URL.Query(), s.view)
+	//Supersampled images are rasterized that many times larger, whether the factor comes from the
+	//query or from the preset
+	if factor := settings.supersampling(); err == nil && (settings.size*factor > s.maxImage || settings.height*factor > s.maxImage) {
+		err = fmt.Errorf("images can't be larger than %d pixels, supersampling included", s.maxImage)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	aModel, ok := s.upload(w, r)
+	if !ok {
+		return
+	}
+	perspective, err := settings.perspective()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	img, err := renderImage(&aModel, settings, perspective)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	//Encode it first so errors can still change the status
+	var buffer bytes.Buffer
+	if err := model.EncodeImage(&buffer, img, format); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	contentType := "image/png"
+	if format == model.ImageJPEG {
+		contentType = "image/jpeg"
+	}
+	w.Header().Set("Content-Type", contentType)
+	buffer.WriteTo(w)
+}
+
+// Read the render settings of the query, changing the view of its preset or the given one
+func renderQuery(query url.Values, view model.View) (settings renderSettings, format model.ImageFormat, err error) {
+	if v := query.Get("preset"); v != "" {
+		if view, err = model.ViewPreset(v); err != nil {
+			return settings, format, err
+		}
+	}
+	settings.setView(view)
+	//A view given replaces the camera of the preset, and a camera given overrides the view
+	if v := query.Get("view"); v != "" {
+		settings.view, settings.camera = v, ""
+	}
+	if v := query.Get("camera"); v != "" {
+		settings.camera = v
+	}
+	if v := query.Get("mode"); v != "" {
+		settings.mode = v
+	}
+	if v := query.Get("curvature"); v != "" {
+		settings.curvature = v
+	}
+	if v := query.Get("light"); v != "" {
+		settings.light = v
+	}
+	if v := query.Get("pan"); v != "" {
+		settings.pan = v
+	}
+	if v := query.Get("sections"); v != "" {
+		settings.sections = v
+	}
+	if v := query.Get("colorby"); v != "" {
+		settings.colorBy = v
+	}
+	for name, target := range map[string]*int{"size": &settings.size, "height": &settings.height, "supersample": &settings.supersample} {
+		if v := query.Get(name); v != "" {
+			if *target, err = strconv.Atoi(v); err != nil {
+				return settings, format, fmt.Errorf("invalid %s %q", name, v)
+			}
+		}
+	}
+	for name, target := range map[string]*float64{"ambient": &settings.ambient, "margin": &settings.margin, "zoom": &settings.zoom, "scale": &settings.scale, "overhang": &settings.overhang, "thin": &settings.thinWall, "features": &settings.features} {
+		if v := query.Get(name); v != "" {
+			if *target, err = strconv.ParseFloat(v, 64); err != nil {
+				return settings, format, fmt.Errorf("invalid %s %q", name, v)
+			}
+		}
+	}
+	for name, target := range map[string]*bool{"cull": &settings.cull, "depth": &settings.depthMap, "steady": &settings.steady} {
+		if v := query.Get(name); v != "" {
+			if *target, err = strconv.ParseBool(v); err != nil {
+				return settings, format, fmt.Errorf("invalid %s %q", name, v)
+			}
+		}
+	}
+	if settings.size < 1 || settings.height < 0 {
+		return settings, format, errors.New("the size must be positive and the height can't be negative")
+	}
+	if settings.supersample < 1 || settings.supersample > model.MaxSupersample {
+		return settings, format, fmt.Errorf("the supersample must be between 1 and %d", model.MaxSupersample)
+	}
+	format = model.ImagePNG
+	if v := query.Get("format"); v != "" {
+		if format, err = model.ImageFormatFromPath("render." + v); err != nil {
+			return settings, format, err
+		}
+	}
+	if settings.depthMap && format != model.ImagePNG {
+		return settings, format, errors.New("depth maps can only be written as png")
+	}
+	return settings, format, nil
+}
+
+// Answer with the model converted to another format
+func (s server) convert(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	to := query.Get("to")
+	if !writableFormat(to) {
+		http.Error(w, "the to parameter must be stl, obj, json, csv or tsv", http.StatusBadRequest)
+		return
+	}
+	ascii, err := strconv.ParseBool(query.Get("ascii"))
+	if err != nil && query.Get("ascii") != "" {
+		http.Error(w, fmt.Sprintf("invalid ascii %q", query.Get("ascii")), http.StatusBadRequest)
+		return
+	}
+	aModel, ok := s.upload(w, r)
+	if !ok {
+		return
+	}
+	var buffer bytes.Buffer
+	if err := writeModel(&buffer, to, &aModel, ascii); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	contentType := "model/" + to
+	switch to {
+	case "json":
+		contentType = "application/json"
+	case "csv", "tsv":
+		contentType = "text/" + to
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=model."+to)
+	buffer.WriteTo(w)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Slice a model in layers, listing their area and perimeter and drawing one of them
+func sliceCommand(args []string) {
+	flags := flag.NewFlagSet("slice", flag.ExitOnError)
+	layerHeight := flags.Float64("layer-height", 0.2, "Height of each layer")
+	layerIndex := flags.Int("layer", -1, "Draw this layer, counting from 0 at the bottom (-1 only lists them)")
+	size := flags.Int("size", 80, "Width of the drawing in characters")
+	height := flags.Int("height", 0, "Number of rows of the drawing (0 keeps the proportions of the size)")
+	aspect := flags.Float64("aspect", 2, "Height of a character relative to its width")
+	palette := flags.String("palette", "block", "Characters used to draw the solid and the contours (block|ascii|custom characters)")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii slice [flags] pathtofile")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *layerHeight <= 0 || *size < 1 || *height < 0 || *aspect <= 0 {
+		flags.Usage()
+	}
+	drawPalette, err := model.ParsePalette(*palette)
+	if err != nil {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	layers := model.Slice(&aModel, float32(*layerHeight))
+	if *layerIndex >= len(layers) {
+		fmt.Fprintf(os.Stderr, "there are only %d layers\n", len(layers))
+		os.Exit(1)
+	}
+	for i, layer := range layers {
+		if *layerIndex < 0 || i == *layerIndex {
+			fmt.Printf("Layer %d: z %v, %d contours, area %v, perimeter %v\n", i, layer.Z, len(layer.Contours), layer.Area, layer.Perimeter)
+		}
+	}
+	if *layerIndex >= 0 {
+		info := model.Inspect(&aModel)
+		mins, maxs := model.Vec2{info.Mins[0], info.Mins[1]}, model.Vec2{info.Maxs[0], info.Maxs[1]}
+		viewport := model.NewViewport(*size+1, *height, float32(*aspect))
+		check(writeMatrix(func(w io.Writer, matrix [][]float32) error {
+			return model.RenderMatrixPalette(w, matrix, drawPalette)
+		}, model.RasterizeLayer(layers[*layerIndex], mins, maxs, viewport)))
+	}
+}
@@ -1,10 +1,8 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"runtime/pprof"
 	"strconv"
@@ -20,6 +18,34 @@ func check(e error) {
 
 func usage() {
 	fmt.Println("usage: stl2ascii [flags] [pathtofile]")
+	fmt.Println("       stl2ascii info [flags] pathtofile")
+	fmt.Println("       stl2ascii convert [flags] input output")
+	fmt.Println("       stl2ascii render [flags] pathtofile")
+	fmt.Println("       stl2ascii repair [flags] pathtofile")
+	fmt.Println("       stl2ascii transform [flags] pathtofile")
+	fmt.Println("       stl2ascii slice [flags] pathtofile")
+	fmt.Println("       stl2ascii diff [flags] pathtofile pathtofile")
+	fmt.Println("       stl2ascii serve [flags]")
+	fmt.Println("       stl2ascii thumbs [flags] directory")
+	fmt.Println("       stl2ascii orient [flags] pathtofile")
+	fmt.Println("       stl2ascii check [flags] pathtofile")
+	fmt.Println("       stl2ascii hollow [flags] pathtofile")
+	fmt.Println("       stl2ascii offset [flags] pathtofile")
+	fmt.Println("       stl2ascii cut [flags] pathtofile")
+	fmt.Println("       stl2ascii decompose [flags] pathtofile")
+	fmt.Println("       stl2ascii infill [flags] pathtofile")
+	fmt.Println("       stl2ascii wireframe [flags] pathtofile")
+	fmt.Println("       stl2ascii scene [flags] pathtofile[@x,y,z] ...")
+	fmt.Println("       stl2ascii arrange [flags] pathtofile ...")
+	fmt.Println("       stl2ascii watch [flags] pathtofile")
+	fmt.Println("       stl2ascii batch [flags] directory|pathtofile ...")
+	fmt.Println("       stl2ascii validate [flags] pathtofile ...")
+	fmt.Println("       stl2ascii supports [flags] pathtofile -o output")
+	fmt.Println("       stl2ascii adhesion [flags] pathtofile -o output")
+	fmt.Println("       stl2ascii voxel [flags] pathtofile -o output")
+	fmt.Println("       stl2ascii extrude [flags] drawing.svg|drawing.dxf -o output")
+	fmt.Println("       stl2ascii gcode [flags] pathtofile.gcode")
+	fmt.Println("       stl2ascii measure [flags] pathtofile")
 	flag.PrintDefaults()
 	os.Exit(1)
 }
@@ -27,16 +53,41 @@ func usage() {
 var (
 	// Command Flag Declaration
 	info = flag.Bool("i", true, "Print gathered information about the file")
-	draw = flag.Bool("d", true, "Draw the model from a direction on a size x size grid (-d [front|side|top] size)")
+	draw = flag.Bool("d", true, "Draw the model from a direction on a size x size grid (-d [front|side|top|iso|dimetric|sheet] size)")
+
+	// Draw Flags
+	fill     = flag.Bool("f", false, "Fill the triangles instead of only marking the vertices")
+	wire     = flag.Bool("w", false, "Draw the edges of the triangles instead of only marking the vertices")
+	shade    = flag.Bool("s", false, "Fill the triangles shading them with a light instead of showing the depth")
+	light    = flag.String("light", "-0.5,0.5,1", "Direction the light comes from when shading (right,up,towards the viewer)")
+	ambient  = flag.Float64("ambient", 0.15, "Light reaching every surface when shading (0 to 1)")
+	cull     = flag.Bool("cull", false, "Skip the triangles facing away from the viewer when filling or drawing edges")
+	braille  = flag.Bool("braille", false, "Draw with braille dots, each character showing 2 x 4 cells of a grid twice as wide and four times as tall")
+	color    = flag.String("color", "none", "Paint the model with terminal colors instead of characters (none|256|true|auto)")
+	camera   = flag.String("camera", "", "Draw from any angle instead of the -d direction (yaw,pitch[,roll] in degrees, -d takes only the size)")
+	graphics = flag.String("graphics", "none", "Draw a real image in terminals supporting inline bitmaps, size pixels wide (none|sixel|kitty|iterm2|auto)")
+	palette  = flag.String("palette", "block", "Characters used to draw, from the lowest to the highest value (block|ascii|custom characters)")
+	height   = flag.Int("height", 0, "Number of rows of the grid (0 keeps the proportions of the size)")
+	aspect   = flag.Float64("aspect", 2, "Height of a character relative to its width, used to keep the proportions of the model")
 
 	// Option Flags
 	preLoad = flag.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	output  = flag.String("o", "", "Write the render to an image file instead of the terminal, size pixels wide (.png or .jpg)")
+	depth   = flag.Bool("depth", false, "Write the depth as a 16 bit grayscale PNG depth map with -o (use with -f)")
 
 	// Debugging
 	cpuprofile = flag.String("cpuprofile", "", "Write cpu profile to this file")
 )
 
 func main() {
+	//Run a subcommand if one is given
+	if len(os.Args) > 1 {
+		if command, found := commands[os.Args[1]]; found {
+			command(os.Args[2:])
+			return
+		}
+	}
+
 	//Read the flags
 	flag.Usage = usage
 	flag.Parse()
@@ -55,53 +106,15 @@ func main() {
 
 	//for runs := 0; runs < 50; runs++ {
 
-	//Create the model
-	var aModel model.Model
-
 	//File path
 	filePath := flag.Arg(flag.NArg() - 1)
 	if filePath == "" {
 		usage()
 	}
 
-	//If we want to preload the model in memory
-	if *preLoad {
-		//Load the whole file to memory
-		fileSlice, err := ioutil.ReadFile(filePath)
-		check(err)
-		//Create the model from it
-		aModel, err = model.CreateFromByteSlice(fileSlice)
-		check(err)
-	} else {
-		//Open the passed file for reading
-		fileHandle, err := os.Open(filePath)
-		check(err)
-		defer fileHandle.Close()
-
-		//Create the reader
-		fileReader := bufio.NewReaderSize(fileHandle, 50*1000)
-
-		//Check if it is ASCII
-		asciiCheck, err := fileReader.Peek(5)
-		check(err)
-
-		//Try ASCII if it looks like it
-		if string(asciiCheck) == "solid" {
-			//Discard the error so we try binary if this fails
-			aModel, _ = model.CreateFromASCIISTL(fileReader)
-		}
-		//If it failed, try binary
-		if len(aModel.Triangles) == 0 {
-			//Reset the reader in case it tried ASCII
-			if string(asciiCheck) == "solid" {
-				fileHandle.Seek(0, 0)
-				fileReader = bufio.NewReader(fileHandle)
-			}
-			//Read the Binary STL
-			aModel, err = model.CreateFromBinarySTL(fileReader)
-			check(err)
-		}
-	}
+	//Create the model
+	aModel, err := loadModel(filePath, "", *preLoad)
+	check(err)
 
 	if *info {
 		//Print the Model Info
@@ -109,32 +122,41 @@ func main() {
 	}
 
 	if *draw {
-		var perspective model.ProjectFrom
-		var size int64
-
-		if flag.NArg() == 1 {
-			perspective = model.ProjectFromFront
-			size = 160
-		} else {
+		settings := renderSettings{
+			view: "front", camera: *camera, size: 160, height: *height, aspect: *aspect,
+			mode: "vertices", cull: *cull, light: *light, ambient: *ambient,
+			braille: *braille, color: *color, palette: *palette, graphics: *graphics,
+			output: *output, depthMap: *depth,
+		}
+		if flag.NArg() == 2 && *camera != "" {
+			//With a camera only the size is needed
+			settings.size, err = strconv.Atoi(flag.Arg(0))
+			if err != nil {
+				usage()
+			}
+		} else if flag.NArg() > 1 {
 			//Check the perspective and size params
-			switch flag.Arg(0) {
-			case "front":
-				perspective = model.ProjectFromFront
-			case "side":
-				perspective = model.ProjectFromSide
-			case "top":
-				perspective = model.ProjectFromTop
-			default:
+			settings.view = flag.Arg(0)
+			if _, err := model.ParseProjection(settings.view); err != nil {
 				usage()
 			}
-			var err error
-			size, err = strconv.ParseInt(flag.Arg(1), 10, 0)
+			settings.size, err = strconv.Atoi(flag.Arg(1))
 			if err != nil {
 				usage()
 			}
 		}
-		//Paint the model
-		fmt.Println(model.DrawMatrix(model.ProjectModelVertices(&aModel, int(size), perspective)))
+		switch {
+		case *shade:
+			settings.mode = "shaded"
+		case *fill:
+			settings.mode = "depth"
+		case *wire:
+			settings.mode = "wire"
+		}
+		if err := render(&aModel, settings); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			usage()
+		}
 	}
 	// }
 }
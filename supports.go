@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Build the supports holding the overhangs of a model
+func supportsCommand(args []string) {
+	defaults := model.DefaultSupportOptions
+	flags := flag.NewFlagSet("supports", flag.ExitOnError)
+	output := flags.String("o", "", "Write the supports to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	angle := flags.Float64("angle", defaults.OverhangAngle, "Largest overhang angle from the vertical printed without supports")
+	spacing := flags.Float64("spacing", float64(defaults.Spacing), "Distance between the support points")
+	radius := flags.Float64("radius", float64(defaults.Radius), "Radius of the pillars and branches")
+	gap := flags.Float64("gap", float64(defaults.Gap), "Room left between the supports and the part")
+	tree := flags.Bool("tree", false, "Grow trees whose branches join into shared trunks instead of straight pillars")
+	branchAngle := flags.Float64("branch-angle", defaults.BranchAngle, "Largest angle of the branches from the vertical for -tree")
+	cell := flags.Float64("cell", 0, "Size of the cells of the distance field (0 for half the radius)")
+	merge := flags.Bool("merge", false, "Write the part with its supports instead of the supports alone")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii supports [flags] pathtofile -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *output == "" || *angle <= 0 || *angle >= 90 || *spacing <= 0 || *radius <= 0 || *gap < 0 || *cell < 0 {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	opts := model.SupportOptions{
+		OverhangAngle: *angle,
+		Spacing:       float32(*spacing),
+		Radius:        float32(*radius),
+		Gap:           float32(*gap),
+		Tree:          *tree,
+		BranchAngle:   *branchAngle,
+		CellSize:      float32(*cell),
+	}
+	points, _ := model.SupportPoints(&aModel, opts)
+	supports, err := model.GenerateSupports(&aModel, opts)
+	check(err)
+	if supports == nil {
+		fmt.Println("Nothing needs supports")
+		os.Exit(0)
+	}
+	fmt.Printf("Support points: %v\nTriangles: %v\n", len(points), len(supports.Triangles))
+	if *merge {
+		aModel.Append(supports.Triangles...)
+		supports = &aModel
+	}
+	check(saveModel(*output, "", supports, *ascii))
+}
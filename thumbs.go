@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Render a PNG thumbnail for every model in a directory tree
+func thumbsCommand(args []string) {
+	flags := flag.NewFlagSet("thumbs", flag.ExitOnError)
+	size := flags.Int("size", 256, "Width and height of the thumbnails in pixels")
+	view := flags.String("view", "iso", "Direction to draw the models from (front|side|top|iso|dimetric)")
+	camera := flags.String("camera", "", "Draw from any angle instead of the view (yaw,pitch[,roll] in degrees)")
+	outputDir := flags.String("out", "", "Directory for the thumbnails, mirroring the tree (next to each model by default)")
+	workers := flags.Int("workers", 0, "Models rendered at the same time (0 for one per CPU)")
+	force := flags.Bool("force", false, "Render the thumbnails again even if they are newer than their model")
+	supersample := flags.Int("supersample", 1, "Render the thumbnails this many times larger and scale them down, smoothing the edges (1 for none, up to 8)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii thumbs [flags] directory")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	dirs := parseArgs(flags, args)
+	if len(dirs) != 1 || *size < 1 || *supersample < 1 || *supersample > model.MaxSupersample {
+		flags.Usage()
+	}
+	perspective, err := renderSettings{view: *view, camera: *camera}.perspective()
+	if err != nil || perspective == nil {
+		flags.Usage()
+	}
+
+	failed := 0
+	opts := model.ThumbnailOptions{Size: *size, Perspective: perspective, Workers: *workers, OutputDir: *outputDir, Force: *force, Supersample: *supersample}
+	err = model.GenerateThumbnails(dirs[0], opts, func(result model.ThumbnailResult) {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %v\n", result.Model, result.Err)
+		case result.Skipped:
+			fmt.Printf("%s -> %s (up to date)\n", result.Model, result.Thumbnail)
+		default:
+			fmt.Printf("%s -> %s\n", result.Model, result.Thumbnail)
+		}
+	})
+	check(err)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Apply transformations to a model in the order they are given
+func transformCommand(args []string) {
+	var steps []func(m *model.Model)
+	addStep := func(affine func(m *model.Model) model.Affine) {
+		steps = append(steps, func(m *model.Model) { model.Transform(m, affine(m)) })
+	}
+	rotation := func(rotate func(angle float64) model.Affine) func(string) error {
+		return func(value string) error {
+			degrees, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			addStep(func(*model.Model) model.Affine { return rotate(degrees * math.Pi / 180) })
+			return nil
+		}
+	}
+	flags := flag.NewFlagSet("transform", flag.ExitOnError)
+	output := flags.String("o", "", "Write the transformed model to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Func("scale", "Scale around the origin by a factor or by x,y,z (negative to mirror)", func(value string) error {
+		factors, err := parseScale(value)
+		if err != nil {
+			return err
+		}
+		addStep(func(*model.Model) model.Affine { return model.Scale(factors) })
+		return nil
+	})
+	flags.Func("rotate-x", "Rotate around the X axis by these degrees", rotation(model.RotateX))
+	flags.Func("rotate-y", "Rotate around the Y axis by these degrees", rotation(model.RotateY))
+	flags.Func("rotate-z", "Rotate around the Z axis by these degrees", rotation(model.RotateZ))
+	flags.Func("rotate-axis", "Rotate around the axis x,y,z through the origin by these degrees (x,y,z,degrees)", func(value string) error {
+		parts := strings.Split(value, ",")
+		if len(parts) != 4 {
+			return fmt.Errorf("expected x,y,z,degrees, got %q", value)
+		}
+		axis, err := model.ParseVec3(strings.Join(parts[:3], ","))
+		if err != nil {
+			return err
+		}
+		degrees, err := strconv.ParseFloat(parts[3], 64)
+		if err != nil {
+			return err
+		}
+		q := model.QuaternionFromAxisAngle(axis, degrees*math.Pi/180)
+		addStep(func(*model.Model) model.Affine { return q.Affine() })
+		return nil
+	})
+	flags.Func("quat", "Rotate around the origin by the quaternion w,x,y,z (normalized)", func(value string) error {
+		parts := strings.Split(value, ",")
+		if len(parts) != 4 {
+			return fmt.Errorf("expected w,x,y,z, got %q", value)
+		}
+		var components [4]float64
+		for i, part := range parts {
+			component, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return err
+			}
+			components[i] = component
+		}
+		q := model.Quaternion{W: components[0], X: components[1], Y: components[2], Z: components[3]}
+		addStep(func(*model.Model) model.Affine { return q.Affine() })
+		return nil
+	})
+	flags.Func("translate", "Move by x,y,z", func(value string) error {
+		offset, err := model.ParseVec3(value)
+		if err != nil {
+			return err
+		}
+		addStep(func(*model.Model) model.Affine { return model.Translate(offset) })
+		return nil
+	})
+	flags.BoolFunc("center", "Move the center of the bounding box to the origin", func(string) error {
+		addStep(model.CenterTransform)
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii transform [flags] pathtofile -o output")
+		fmt.Println("the transformations are applied in the order they are given")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *output == "" {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	for _, step := range steps {
+		step(&aModel)
+	}
+	check(saveModel(*output, "", &aModel, *ascii))
+}
+
+// Read a single scale factor or one per axis
+func parseScale(value string) (model.Vec3, error) {
+	if strings.Contains(value, ",") {
+		return model.ParseVec3(value)
+	}
+	factor, err := strconv.ParseFloat(value, 32)
+	return model.Vec3{float32(factor), float32(factor), float32(factor)}, err
+}
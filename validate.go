@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Check models for defects, exiting with an error status when one has errors, for CI pipelines
+func validateCommand(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print the issues as JSON")
+	allowOpen := flags.Bool("allow-open", false, "Accept open surfaces such as sheets")
+	normalAngle := flags.Float64("normal-angle", model.DefaultTolerances.NormalAngle, "Largest angle in degrees between a stored normal and the one of the winding")
+	strict := flags.Bool("strict", false, "Fail on warnings too")
+	preLoad := flags.Bool("pl", false, "Preload the files into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii validate [flags] pathtofile ...")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) == 0 || *normalAngle <= 0 {
+		flags.Usage()
+	}
+
+	type result struct {
+		File   string       `json:"file"`
+		Valid  bool         `json:"valid"`
+		Issues model.Issues `json:"issues"`
+	}
+	results := make([]result, len(files))
+	failed := false
+	for i, file := range files {
+		aModel, err := loadModel(file, "", *preLoad)
+		check(err)
+		issues := model.Validate(&aModel, model.ValidateOptions{Tolerances: model.Tolerances{NormalAngle: *normalAngle}, AllowOpen: *allowOpen})
+		//List no issues as an empty list in the JSON
+		if issues == nil {
+			issues = model.Issues{}
+		}
+		valid := issues.Worst() < model.SeverityError && (!*strict || len(issues) == 0)
+		results[i] = result{File: file, Valid: valid, Issues: issues}
+		failed = failed || !valid
+	}
+	if *asJSON {
+		encoded, err := json.MarshalIndent(results, "", "  ")
+		check(err)
+		fmt.Println(string(encoded))
+	} else {
+		for _, r := range results {
+			status := "ok"
+			if !r.Valid {
+				status = "failed"
+			}
+			fmt.Printf("%s: %s\n", r.File, status)
+			fmt.Print(r.Issues)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Rebuild a model from voxels, closing gaps and filling cavities on the way
+func voxelCommand(args []string) {
+	flags := flag.NewFlagSet("voxel", flag.ExitOnError)
+	output := flags.String("o", "", "Write the rebuilt model to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	size := flags.Float64("size", 0, "Side of the voxels (0 for 1/100 of the longest side of the model)")
+	fill := flags.Bool("fill-cavities", false, "Fill the closed cavities inside the model")
+	closing := flags.Int("close", 0, "Close the gaps narrower than twice this many voxels")
+	opening := flags.Int("open", 0, "Remove the parts thinner than twice this many voxels")
+	dilate := flags.Int("dilate", 0, "Grow the model by this many voxels")
+	erode := flags.Int("erode", 0, "Shrink the model by this many voxels")
+	octree := flags.Bool("octree", false, "Keep the voxels in an octree, taking far less memory for fine voxels, without the other operations")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii voxel [flags] pathtofile -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *output == "" || *size < 0 || *closing < 0 || *opening < 0 || *dilate < 0 || *erode < 0 ||
+		*octree && (*fill || *closing > 0 || *opening > 0 || *dilate > 0 || *erode > 0) {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	voxelSize := float32(*size)
+	if voxelSize == 0 {
+		dimensions := aModel.Bounds().Size()
+		voxelSize = max(dimensions[0], dimensions[1], dimensions[2]) / 100
+	}
+	var voxels model.Voxels
+	if *octree {
+		voxels, err = model.VoxelizeOctree(&aModel, voxelSize)
+		check(err)
+	} else {
+		grid, err := model.Voxelize(&aModel, voxelSize)
+		check(err)
+		applyMorphology(grid, *fill, *closing, *opening, *dilate, *erode)
+		voxels = grid
+	}
+	rebuilt := voxels.Model()
+	rebuilt.Header = aModel.Header
+	fmt.Printf("Voxels: %v\nVolume: %v\nTriangles: %v\n", voxels.Count(), voxels.Volume(), len(rebuilt.Triangles))
+	check(saveModel(*output, "", rebuilt, *ascii))
+}
+
+// Fill the cavities if asked, then close, open, dilate and erode the voxels
+func applyMorphology(grid *model.VoxelGrid, fill bool, closing, opening, dilate, erode int) {
+	if fill {
+		fmt.Printf("Cavity voxels filled: %v\n", grid.FillCavities())
+	}
+	grid.Close(closing)
+	grid.Open(opening)
+	grid.Dilate(dilate)
+	grid.Erode(erode)
+}
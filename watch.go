@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Render a model again every time its file changes
+func watchCommand(args []string) {
+	var settings renderSettings
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	settings.addFlags(flags)
+	interval := flags.Duration("interval", 500*time.Millisecond, "How often the file is checked for changes")
+	subdivide := flags.Float64("subdivide", 0, "Split the facets until no edge is longer than this, so big flat faces show up in the vertices mode (0 to keep them)")
+	scaleWith := flags.String("scale-with", "", "Share the scale with these models, separated by commas, fitting the largest of them and this one")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii watch [flags] pathtofile")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *interval <= 0 || isURL(files[0]) {
+		flags.Usage()
+	}
+	check(settings.applyViewFiles(flags))
+	scaleModels, err := loadScaleModels(*scaleWith)
+	check(err)
+	settings.scaleModels = scaleModels
+
+	//Render what is there, errors included, so a broken save shows up instead of stopping the preview
+	show := func() {
+		//Clear the terminal and go back to its top
+		if settings.output == "" {
+			fmt.Print("\x1b[H\x1b[2J")
+		}
+		aModel, err := loadModel(files[0], "", false)
+		if err == nil && *subdivide > 0 {
+			err = model.SubdivideLongEdges(&aModel, *subdivide)
+		}
+		if err == nil {
+			err = render(&aModel, settings)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		fmt.Printf("Watching %s, last rendered at %s\n", files[0], time.Now().Format("15:04:05"))
+	}
+	//The file changed when its size or modification time did, it is missing while being replaced
+	changed := func(before, after os.FileInfo) bool {
+		return before == nil || after == nil || before.Size() != after.Size() || !before.ModTime().Equal(after.ModTime())
+	}
+	last, err := os.Stat(files[0])
+	check(err)
+	show()
+	for {
+		time.Sleep(*interval)
+		current, err := os.Stat(files[0])
+		if err != nil || !changed(last, current) {
+			continue
+		}
+		//Wait for the program saving it to finish writing
+		for {
+			time.Sleep(*interval)
+			settled, err := os.Stat(files[0])
+			if err == nil && !changed(current, settled) {
+				break
+			}
+			current = settled
+		}
+		last = current
+		show()
+	}
+}
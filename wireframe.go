@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pmmaga/stl2ascii/model"
+)
+
+// Turn the edges of a model into printable struts
+func wireframeCommand(args []string) {
+	flags := flag.NewFlagSet("wireframe", flag.ExitOnError)
+	output := flags.String("o", "", "Write the wireframe to this file")
+	ascii := flags.Bool("ascii", false, "Write STL files as ASCII")
+	radius := flags.Float64("radius", 1, "Radius of the struts")
+	joint := flags.Float64("joint", 0, "Radius of the spheres joining the struts (0 for the strut radius)")
+	angle := flags.Float64("angle", 0, "Only turn the edges bending more than this many degrees into struts (0 for every edge)")
+	cell := flags.Float64("cell", 0, "Size of the cells of the distance field (0 for half the strut radius)")
+	preLoad := flags.Bool("pl", false, "Preload the file into memory (binary STL only)")
+	flags.Usage = func() {
+		fmt.Println("usage: stl2ascii wireframe [flags] pathtofile -o output")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+	files := parseArgs(flags, args)
+	if len(files) != 1 || *output == "" || *radius <= 0 || *joint < 0 || *angle < 0 || *cell < 0 {
+		flags.Usage()
+	}
+
+	aModel, err := loadModel(files[0], "", *preLoad)
+	check(err)
+	wireframe, err := model.WireframeSolid(&aModel, model.StrutOptions{
+		Radius: float32(*radius), JointRadius: float32(*joint), FeatureAngle: *angle, CellSize: float32(*cell),
+	})
+	check(err)
+	fmt.Printf("Triangles: %v\n", len(wireframe.Triangles))
+	check(saveModel(*output, "", wireframe, *ascii))
+}